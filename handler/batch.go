@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"book-api/model"
+	"book-api/utils"
+)
+
+// maxBatchOperations adalah jumlah operasi maksimum dalam satu request batch.
+const maxBatchOperations = 1000
+
+// batchInput adalah DTO body POST /books/batch yang bisa diubah menjadi daftar operasi.
+type batchInput interface {
+	ops() []model.BatchOp
+}
+
+// batchRequest adalah body POST /books/batch pada API v1.
+type batchRequest struct {
+	Operations []model.BatchOp `json:"operations"`
+}
+
+func (req *batchRequest) ops() []model.BatchOp { return req.Operations }
+
+// batchResult adalah hasil satu operasi batch dengan status HTTP yang setara
+// dengan request tunggalnya (201 create, 200 update, 204 delete).
+type batchResult struct {
+	Index   int         `json:"index"`
+	Op      string      `json:"op"`
+	Status  int         `json:"status"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// batchMeta merangkum hasil batch best-effort.
+type batchMeta struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// BatchBooksHandler menangani permintaan POST /books/batch untuk menjalankan banyak
+// operasi create, update, dan delete sekaligus. Setiap operasi divalidasi dengan
+// aturan yang sama seperti request tunggalnya.
+//
+// Dengan ?atomic=true semua operasi diterapkan di dalam store secara all-or-nothing;
+// tanpanya (best-effort) setiap operasi dijalankan sendiri-sendiri dan hasilnya
+// dilaporkan per operasi seperti 207 Multi-Status.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request dengan body {"operations": [...]}.
+//
+// Response:
+//   - 200 OK (atomic) berisi hasil setiap operasi
+//   - 207 Multi-Status (best-effort) berisi status setiap operasi dan ringkasannya di "meta"
+//   - 400 Bad Request jika body atau salah satu operasi tidak valid (atomic)
+//   - 404/409/412 (atomic) jika salah satu operasi gagal; operasi tersebut ada di "details"
+//   - 413 Request Entity Too Large jika body melebihi batas ukuran
+//   - 415 Unsupported Media Type jika Content-Type bukan application/json
+//   - 422 Unprocessable Entity jika Idempotency-Key sudah dipakai dengan body berbeda
+//   - 5xx jika store gagal
+func (bh *bookHandler) BatchBooksHandler(w http.ResponseWriter, r *http.Request) {
+	if key := r.Header.Get(idempotencyKeyHeader); key != "" {
+		bh.withIdempotency(w, r, key, bh.batchBooks)
+		return
+	}
+	bh.batchBooks(w, r)
+}
+
+// batchBooks berisi logika POST /books/batch tanpa penanganan Idempotency-Key.
+func (bh *bookHandler) batchBooks(w http.ResponseWriter, r *http.Request) {
+	atomic := false
+	if v := r.URL.Query().Get("atomic"); v != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(v); err != nil {
+			utils.WriteError(w, r, http.StatusBadRequest, "atomic must be true or false")
+			return
+		}
+	}
+
+	req := mapperFor(r).newBatchRequest()
+	if err := utils.DecodeJSON(w, r, req, bh.maxBodyBytes); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	ops := req.ops()
+	switch n := len(ops); {
+	case n == 0:
+		utils.WriteError(w, r, http.StatusBadRequest, "operations must not be empty")
+		return
+	case n > maxBatchOperations:
+		utils.WriteError(w, r, http.StatusBadRequest, fmt.Sprintf("operations must contain at most %d items, got %d", maxBatchOperations, n))
+		return
+	}
+
+	if atomic {
+		bh.applyBatchAtomic(w, r, ops)
+		return
+	}
+	bh.applyBatchBestEffort(w, r, ops)
+}
+
+// applyBatchAtomic memvalidasi semua operasi lalu menerapkannya dalam satu ApplyBatch.
+func (bh *bookHandler) applyBatchAtomic(w http.ResponseWriter, r *http.Request, ops []model.BatchOp) {
+	verr := &model.ValidationError{}
+	for i, op := range ops {
+		var opErr *model.ValidationError
+		if errors.As(bh.validateBatchOp(op), &opErr) {
+			for _, f := range opErr.Fields {
+				f.Field = fmt.Sprintf("operations[%d].%s", i, f.Field)
+				verr.Fields = append(verr.Fields, f)
+			}
+		}
+	}
+	if len(verr.Fields) > 0 {
+		writeStoreError(w, r, verr)
+		return
+	}
+
+	books, err := bh.service.ApplyBatch(r.Context(), ops)
+	if err != nil {
+		var batchErr *model.BatchError
+		if !errors.As(err, &batchErr) {
+			writeStoreError(w, r, err)
+			return
+		}
+		status, msg := storeErrorStatus(batchErr.Err)
+		failed := batchResult{Index: batchErr.Index, Op: ops[batchErr.Index].Op, Status: status, Error: msg}
+		utils.WriteErrorWithDetails(w, r, status, fmt.Sprintf("operation %d: %s", batchErr.Index, msg), []batchResult{failed})
+		return
+	}
+
+	m := mapperFor(r)
+	results := make([]batchResult, len(ops))
+	for i, op := range ops {
+		results[i] = batchSuccess(m, i, op, books[i])
+	}
+	utils.WriteJSON(w, r, http.StatusOK, results)
+}
+
+// applyBatchBestEffort menjalankan setiap operasi secara terpisah; kegagalan satu
+// operasi tidak membatalkan operasi lainnya.
+func (bh *bookHandler) applyBatchBestEffort(w http.ResponseWriter, r *http.Request, ops []model.BatchOp) {
+	m := mapperFor(r)
+	results := make([]batchResult, len(ops))
+	var meta batchMeta
+	for i, op := range ops {
+		var result model.Book
+		err := bh.validateBatchOp(op)
+		if err == nil {
+			result, err = bh.applyBatchOp(r, op)
+		}
+		if err != nil {
+			results[i] = batchFailure(m, i, op, err)
+			meta.Failed++
+			continue
+		}
+		results[i] = batchSuccess(m, i, op, result)
+		meta.Succeeded++
+	}
+	utils.WriteJSONWithMeta(w, r, http.StatusMultiStatus, results, meta)
+}
+
+// applyBatchOp menjalankan satu operasi batch dengan method store untuk request tunggalnya.
+func (bh *bookHandler) applyBatchOp(r *http.Request, op model.BatchOp) (model.Book, error) {
+	switch op.Op {
+	case model.BatchCreate:
+		return bh.service.AddBook(r.Context(), *op.Book)
+	case model.BatchUpdate:
+		book := *op.Book
+		book.Version = op.Version
+		return bh.service.UpdateBook(r.Context(), op.ID, book)
+	default:
+		return model.Book{ID: op.ID}, bh.service.DeleteBook(r.Context(), op.ID, op.Version)
+	}
+}
+
+// validateBatchOp memvalidasi operasi seperti request tunggalnya, termasuk kewajiban
+// version untuk update dan delete jika If-Match diwajibkan.
+func (bh *bookHandler) validateBatchOp(op model.BatchOp) error {
+	verr := &model.ValidationError{}
+	if err := op.Validate(); err != nil {
+		verr = err.(*model.ValidationError)
+	}
+	if bh.requireIfMatch && op.Version == 0 && (op.Op == model.BatchUpdate || op.Op == model.BatchDelete) {
+		verr.Fields = append(verr.Fields, model.FieldError{Field: "version", Code: model.RuleRequired, Message: "version is required"})
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// batchSuccess membuat hasil operasi yang berhasil dengan buku dalam representasi m.
+func batchSuccess(m bookMapper, index int, op model.BatchOp, book model.Book) batchResult {
+	result := batchResult{Index: index, Op: op.Op}
+	switch op.Op {
+	case model.BatchCreate:
+		result.Status = http.StatusCreated
+		result.Data = m.book(book)
+	case model.BatchUpdate:
+		result.Status = http.StatusOK
+		result.Data = m.book(book)
+	default:
+		result.Status = http.StatusNoContent
+	}
+	return result
+}
+
+// batchFailure membuat hasil operasi yang gagal dengan status dan pesan yang sama
+// seperti request tunggalnya.
+func batchFailure(m bookMapper, index int, op model.BatchOp, err error) batchResult {
+	result := batchResult{Index: index, Op: op.Op}
+	result.Status, result.Error = storeErrorStatus(err)
+	var verr *model.ValidationError
+	if errors.As(err, &verr) {
+		result.Error = model.ErrValidation.Error()
+		result.Details = mapFieldErrors(m, verr.Fields)
+	}
+	return result
+}
@@ -1,41 +1,147 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"book-api/model"
 	"book-api/utils"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/graphql-go/graphql"
 )
 
 type BookHandler interface {
 	GetBooksHandler(w http.ResponseWriter, r *http.Request)
 	GetBookHandler(w http.ResponseWriter, r *http.Request)
+	SearchBooksHandler(w http.ResponseWriter, r *http.Request)
 	CreateBookHandler(w http.ResponseWriter, r *http.Request)
+	BatchBooksHandler(w http.ResponseWriter, r *http.Request)
+	ExportBooksHandler(w http.ResponseWriter, r *http.Request)
+	ImportBooksHandler(w http.ResponseWriter, r *http.Request)
 	UpdateBookHandler(w http.ResponseWriter, r *http.Request)
+	PatchBookHandler(w http.ResponseWriter, r *http.Request)
 	DeleteBookHandler(w http.ResponseWriter, r *http.Request)
+	GetBookHistoryHandler(w http.ResponseWriter, r *http.Request)
+	GetBookRevisionHandler(w http.ResponseWriter, r *http.Request)
+	DiffBookRevisionsHandler(w http.ResponseWriter, r *http.Request)
+	ListTrashHandler(w http.ResponseWriter, r *http.Request)
+	RestoreBookHandler(w http.ResponseWriter, r *http.Request)
+	BookEventsHandler(w http.ResponseWriter, r *http.Request)
+	BookEventsWebSocketHandler(w http.ResponseWriter, r *http.Request)
+	CreateWebhookHandler(w http.ResponseWriter, r *http.Request)
+	ListWebhooksHandler(w http.ResponseWriter, r *http.Request)
+	GetWebhookHandler(w http.ResponseWriter, r *http.Request)
+	UpdateWebhookHandler(w http.ResponseWriter, r *http.Request)
+	DeleteWebhookHandler(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeadLettersHandler(w http.ResponseWriter, r *http.Request)
+	GraphQLHandler(w http.ResponseWriter, r *http.Request)
+	VersionMiddleware(v APIVersion) func(http.Handler) http.Handler
 }
 
 type bookHandler struct {
-	service model.BookStore
+	service        model.BookStore
+	requireIfMatch bool
+	maxBodyBytes   int64
+	maxImportBytes int64
+	adminToken     string
+	idempotency    *idempotencyCache
+	webhooks       model.WebhookStore
+	v1Sunset       time.Time
+	shutdown       <-chan struct{}
+
+	graphqlSchema        graphql.Schema
+	graphqlMaxDepth      int
+	graphqlMaxComplexity int
 }
 
-// NewBookHandler menginisialisasi BookHandler dengan BookStore.
-func NewBookHandler(service model.BookStore) BookHandler {
-	return &bookHandler{service}
+// NewBookHandler menginisialisasi BookHandler dengan BookStore dan opsi tambahan.
+func NewBookHandler(service model.BookStore, opts ...Option) BookHandler {
+	bh := &bookHandler{
+		service:        service,
+		maxBodyBytes:   utils.DefaultMaxBodyBytes,
+		maxImportBytes: DefaultMaxImportBytes,
+		idempotency:    newIdempotencyCache(DefaultIdempotencyTTL, DefaultIdempotencyMaxEntries),
+		v1Sunset:       DefaultV1Sunset,
+
+		graphqlMaxDepth:      DefaultGraphQLMaxDepth,
+		graphqlMaxComplexity: DefaultGraphQLMaxComplexity,
+	}
+	for _, opt := range opts {
+		opt(bh)
+	}
+
+	schema, err := newGraphQLSchema(bh)
+	if err != nil {
+		// Schema statis; error berarti definisi di newGraphQLSchema salah.
+		panic("handler: invalid GraphQL schema: " + err.Error())
+	}
+	bh.graphqlSchema = schema
+	return bh
 }
 
-// GetBooksHandler menangani permintaan GET /books.
+// GetBooksHandler menangani permintaan GET /books dengan filter, sort, dan paginasi
+// (lihat parseBookQuery untuk parameter yang didukung).
 //
 // Params:
 //   - w: http.ResponseWriter untuk menulis response ke client.
 //   - r: *http.Request yang berisi informasi request dari client.
+//
+// Response:
+//   - 200 OK berisi daftar buku di "data" dan info paginasi di "meta", dengan ETag dan Last-Modified.
+//     Dalam JSON, "data" dikirim per buku langsung dari store (lihat utils.WriteJSONStream);
+//     jika store gagal atau client memutus koneksi di tengah daftar, koneksi diputus.
+//   - 304 Not Modified jika If-None-Match / If-Modified-Since menunjukkan data belum berubah
+//   - 400 Bad Request jika parameter query tidak valid
+//   - 5xx jika store gagal
 func (bh *bookHandler) GetBooksHandler(w http.ResponseWriter, r *http.Request) {
-	books := bh.service.GetAllBooks()
-	utils.WriteJSON(w, http.StatusOK, books)
+	m := mapperFor(r)
+	q, err := parseBookQuery(r.URL.Query())
+	if err == nil {
+		q, err = mapQueryFields(m, q).Normalize()
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stamp, err := bh.service.CollectionStamp(r.Context())
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	// Stamp dibaca sebelum query agar ETag tidak pernah lebih baru dari isi response.
+	if checkNotModified(w, r, collectionETag(r, stamp), stamp.ModifiedAt) {
+		return
+	}
+
+	var page model.BookPage
+	err = utils.WriteJSONStream(w, r, http.StatusOK, func(emit func(interface{}) error) error {
+		var err error
+		page, err = bh.service.StreamBooks(r.Context(), q, func(b model.Book) error {
+			return emit(m.book(b))
+		})
+		return err
+	}, func() interface{} {
+		return pageMeta{Total: page.Total, Limit: q.Limit, Offset: q.Offset, NextCursor: page.NextCursor}
+	})
+	switch {
+	case errors.Is(err, utils.ErrResponseCommitted):
+		// Client yang memutus koneksi tidak perlu dicatat; error lain berarti daftar terpotong.
+		if r.Context().Err() == nil {
+			log.Printf("book listing aborted after partial response: %v", err)
+		}
+		panic(http.ErrAbortHandler)
+	case err != nil:
+		// Validator dari checkNotModified hanya berlaku untuk daftar 200, bukan response error.
+		w.Header().Del("ETag")
+		w.Header().Del("Last-Modified")
+		writeStoreError(w, r, err)
+	}
 }
 
 // GetBookHandler menangani permintaan GET /books/{id}.
@@ -45,113 +151,251 @@ func (bh *bookHandler) GetBooksHandler(w http.ResponseWriter, r *http.Request) {
 //   - r: *http.Request yang mengandung parameter URL "id"
 //
 // Response:
-//   - 200 OK jika buku ditemukan
+//   - 200 OK jika buku ditemukan, dengan header ETag berisi versi buku dan Last-Modified
+//   - 304 Not Modified jika If-None-Match / If-Modified-Since menunjukkan buku belum berubah
 //   - 400 Bad Request jika ID tidak valid
 //   - 404 Not Found jika buku tidak ditemukan
+//   - 5xx jika store gagal
 func (bh *bookHandler) GetBookHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid book ID")
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid book ID")
 		return
 	}
 
-	book, err := bh.service.GetBookByID(id)
+	book, err := bh.service.GetBookByID(r.Context(), id)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, err.Error())
+		writeStoreError(w, r, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, book)
+	if checkNotModified(w, r, bookETag(r, book), book.UpdatedAt) {
+		return
+	}
+	utils.WriteJSON(w, r, http.StatusOK, mapperFor(r).book(book))
+}
+
+// SearchBooksHandler menangani permintaan GET /books/search?q=...&limit=...
+// untuk pencarian teks penuh pada judul dan penulis.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request dengan parameter query "q" (wajib) dan "limit" (opsional).
+//
+// Response:
+//   - 200 OK berisi daftar hasil dengan skor dan highlight
+//   - 400 Bad Request jika q kosong atau limit tidak valid
+//   - 501 Not Implemented jika store tidak mendukung pencarian
+func (bh *bookHandler) SearchBooksHandler(w http.ResponseWriter, r *http.Request) {
+	searcher, ok := bh.service.(model.BookSearcher)
+	if !ok {
+		utils.WriteError(w, r, http.StatusNotImplemented, "search is not supported by this store")
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	limit := 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > model.MaxSearchLimit {
+			utils.WriteError(w, r, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(model.MaxSearchLimit))
+			return
+		}
+		limit = n
+	}
+
+	hits, err := searcher.SearchBooks(r.Context(), query, limit)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	m := mapperFor(r)
+	out := make([]interface{}, len(hits))
+	for i, hit := range hits {
+		out[i] = m.searchHit(hit)
+	}
+	utils.WriteJSON(w, r, http.StatusOK, out)
 }
 
 // CreateBookHandler menangani permintaan POST /books untuk menambahkan buku baru.
+// Jika header Idempotency-Key dikirim, request ulang dengan key dan body yang sama
+// menerima response 201 yang sama tanpa membuat buku baru.
 //
 // Params:
 //   - w: http.ResponseWriter untuk menulis response ke client.
-//   - r: *http.Request yang membawa data JSON dari body.
+//   - r: *http.Request yang membawa data buku dari body (JSON, XML, CSV, YAML, atau
+//     MessagePack sesuai Content-Type).
 //
 // Response:
-//   - 201 Created jika sukses
-//   - 400 Bad Request jika body tidak valid atau field kosong
+//   - 201 Created jika sukses (atau salinannya, dengan header Idempotent-Replayed: true)
+//   - 400 Bad Request jika body tidak valid atau gagal validasi (rincian per field di "details")
+//   - 413 Request Entity Too Large jika body melebihi batas ukuran
+//   - 415 Unsupported Media Type jika Content-Type bukan format yang didukung
+//   - 422 Unprocessable Entity jika Idempotency-Key sudah dipakai dengan body berbeda
+//   - 5xx jika store gagal
 func (bh *bookHandler) CreateBookHandler(w http.ResponseWriter, r *http.Request) {
-	var book model.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	if key := r.Header.Get(idempotencyKeyHeader); key != "" {
+		bh.withIdempotency(w, r, key, bh.createBook)
+		return
+	}
+	bh.createBook(w, r)
+}
+
+// createBook berisi logika POST /books tanpa penanganan Idempotency-Key.
+func (bh *bookHandler) createBook(w http.ResponseWriter, r *http.Request) {
+	m := mapperFor(r)
+	input := m.newInput()
+	if err := utils.DecodeBody(w, r, input, bh.maxBodyBytes); err != nil {
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	if book.Title == "" || book.Author == "" || book.PublishedYear == 0 {
-		utils.WriteError(w, http.StatusBadRequest, "all fields are required")
+	book := input.toBook()
+	if err := book.Validate(); err != nil {
+		writeStoreError(w, r, err)
 		return
 	}
 
-	created := bh.service.AddBook(book)
-	utils.WriteJSON(w, http.StatusCreated, created)
+	created, err := bh.service.AddBook(r.Context(), book)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", bookETag(r, created))
+	utils.WriteJSON(w, r, http.StatusCreated, m.book(created))
 }
 
 // UpdateBookHandler menangani permintaan PUT /books/{id} untuk memperbarui data buku.
+// Header If-Match (atau field "version" di body) membuat update hanya berhasil jika
+// versi buku saat ini cocok.
 //
 // Params:
 //   - w: http.ResponseWriter untuk menulis response ke client.
-//   - r: *http.Request yang mengandung parameter URL "id" dan data JSON baru dari body.
+//   - r: *http.Request yang mengandung parameter URL "id" dan data buku baru dari body
+//     (JSON, XML, CSV, YAML, atau MessagePack sesuai Content-Type).
 //
 // Response:
-//   - 200 OK jika update berhasil
-//   - 400 Bad Request jika ID/body tidak valid atau field kosong
+//   - 200 OK jika update berhasil, dengan header ETag versi baru
+//   - 400 Bad Request jika ID/body tidak valid atau gagal validasi (rincian per field di "details")
+//   - 413 Request Entity Too Large jika body melebihi batas ukuran
+//   - 415 Unsupported Media Type jika Content-Type bukan format yang didukung
 //   - 404 Not Found jika ID buku tidak ditemukan
+//   - 412 Precondition Failed jika versi tidak cocok
+//   - 428 Precondition Required jika If-Match diwajibkan tetapi tidak dikirim
+//   - 5xx jika store gagal
 func (bh *bookHandler) UpdateBookHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid book ID")
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid book ID")
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" && bh.requireIfMatch {
+		utils.WriteError(w, r, http.StatusPreconditionRequired, "If-Match header is required")
 		return
 	}
 
-	var book model.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid request body")
+	m := mapperFor(r)
+	input := m.newInput()
+	if err := utils.DecodeBody(w, r, input, bh.maxBodyBytes); err != nil {
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	if book.Title == "" || book.Author == "" || book.PublishedYear == 0 {
-		utils.WriteError(w, http.StatusBadRequest, "all fields are required")
+	book := input.toBook()
+	if err := book.Validate(); err != nil {
+		writeStoreError(w, r, err)
 		return
 	}
 
-	updated, err := bh.service.UpdateBook(id, book)
+	if ifMatch != "" {
+		book.Version, err = bh.resolveIfMatch(r.Context(), id, ifMatch)
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+	}
+
+	updated, err := bh.service.UpdateBook(r.Context(), id, book)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, err.Error())
+		writeStoreError(w, r, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, updated)
+	w.Header().Set("ETag", bookETag(r, updated))
+	utils.WriteJSON(w, r, http.StatusOK, m.book(updated))
 }
 
-// DeleteBookHandler menangani permintaan DELETE /books/{id} untuk menghapus buku.
+// DeleteBookHandler menangani permintaan DELETE /books/{id} untuk memindahkan buku ke
+// trash. Dengan ?hard=true buku (termasuk yang sudah di trash) dihapus permanen; ini
+// hanya untuk admin (lihat WithAdminToken). Header If-Match membuat penghapusan hanya
+// berhasil jika versi buku saat ini cocok.
 //
 // Params:
 //   - w: http.ResponseWriter untuk menulis response ke client.
-//   - r: *http.Request yang mengandung parameter URL "id".
+//   - r: *http.Request yang mengandung parameter URL "id" dan query "hard" (opsional).
 //
 // Response:
 //   - 200 OK jika buku berhasil dihapus
-//   - 400 Bad Request jika ID tidak valid
+//   - 400 Bad Request jika ID atau parameter hard tidak valid
+//   - 401 Unauthorized / 403 Forbidden jika hard delete diminta tanpa token admin yang benar
 //   - 404 Not Found jika ID buku tidak ditemukan
+//   - 412 Precondition Failed jika versi tidak cocok
+//   - 428 Precondition Required jika If-Match diwajibkan tetapi tidak dikirim
+//   - 5xx jika store gagal
 func (bh *bookHandler) DeleteBookHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid book ID")
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid book ID")
+		return
+	}
+
+	hard := false
+	if v := r.URL.Query().Get("hard"); v != "" {
+		if hard, err = strconv.ParseBool(v); err != nil {
+			utils.WriteError(w, r, http.StatusBadRequest, "hard must be true or false")
+			return
+		}
+	}
+	if hard && !bh.authorizeAdmin(w, r) {
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" && bh.requireIfMatch {
+		utils.WriteError(w, r, http.StatusPreconditionRequired, "If-Match header is required")
+		return
+	}
+
+	expectedVersion := 0
+	if ifMatch != "" {
+		expectedVersion, err = bh.resolveIfMatch(r.Context(), id, ifMatch)
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+	}
+
+	if hard {
+		if err := bh.service.PurgeBook(r.Context(), id, expectedVersion); err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+		utils.WriteJSON(w, r, http.StatusOK, map[string]string{"message": "book permanently deleted"})
 		return
 	}
 
-	err = bh.service.DeleteBook(id)
+	err = bh.service.DeleteBook(r.Context(), id, expectedVersion)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, err.Error())
+		writeStoreError(w, r, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "book deleted"})
+	utils.WriteJSON(w, r, http.StatusOK, map[string]string{"message": "book moved to trash"})
 }
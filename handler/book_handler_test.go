@@ -1,18 +1,25 @@
 package handler_test
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"book-api/handler"
 	"book-api/model"
+	"book-api/utils"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
 )
 
 var service = model.NewBookStore()
@@ -35,6 +42,9 @@ func setupRequestWithID(method, path string, body *bytes.Buffer, handlerFunc htt
 	}
 
 	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	rr := httptest.NewRecorder()
 
 	r.ServeHTTP(rr, req)
@@ -42,10 +52,31 @@ func setupRequestWithID(method, path string, body *bytes.Buffer, handlerFunc htt
 	return rr
 }
 
+// doRequest menjalankan h dengan request method ke target dan mengembalikan response-nya.
+// headers ditambahkan ke request (Content-Type default application/json jika body tidak
+// kosong), dan params diisi sebagai URL param chi seperti {id} pada route.
+func doRequest(h http.HandlerFunc, method, target string, headers map[string]string, body string, params map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+	h(rr, req)
+	return rr
+}
+
 func setupHandlerWithData() {
 
 	for _, b := range mockBooks {
-		service.AddBook(b)
+		service.AddBook(context.Background(), b)
 	}
 }
 
@@ -72,11 +103,109 @@ func TestGetBooksHandler_Success(t *testing.T) {
 	}
 }
 
+func TestGetBooksHandler_Query(t *testing.T) {
+	store := model.NewBookStore()
+	for _, b := range mockBooks {
+		store.AddBook(context.Background(), b)
+	}
+	h := handler.NewBookHandler(store)
+
+	req := httptest.NewRequest("GET", "/books?sort=-published_year&published_year[gte]=2021&limit=1", nil)
+	rr := httptest.NewRecorder()
+	h.GetBooksHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GetBooks: expected 200, got %d", rr.Code)
+	}
+
+	var response struct {
+		Data []model.Book `json:"data"`
+		Meta struct {
+			Total      int    `json:"total"`
+			Limit      int    `json:"limit"`
+			NextCursor string `json:"next_cursor"`
+		} `json:"meta"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+
+	if len(response.Data) != 1 || response.Data[0].Title != "Book 3" {
+		t.Errorf("GetBooks: unexpected data: %+v", response.Data)
+	}
+	if response.Meta.Total != 2 || response.Meta.Limit != 1 || response.Meta.NextCursor == "" {
+		t.Errorf("GetBooks: unexpected meta: %+v", response.Meta)
+	}
+
+	req = httptest.NewRequest("GET", "/books?sort=-published_year&published_year[gte]=2021&limit=1&cursor="+response.Meta.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	h.GetBooksHandler(rr, req)
+
+	response.Data = nil
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].Title != "Book 2" {
+		t.Errorf("GetBooks next page: unexpected data: %+v", response.Data)
+	}
+}
+
+func TestGetBooksHandler_InvalidQuery(t *testing.T) {
+	for _, query := range []string{"limit=abc", "offset=-1", "sort=price", "isbn=1", "published_year[between]=1", "title[=x"} {
+		req := httptest.NewRequest("GET", "/books?"+query, nil)
+		rr := httptest.NewRecorder()
+
+		bookHandler.GetBooksHandler(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("GetBooks %q: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+func TestSearchBooksHandler(t *testing.T) {
+	store := model.NewBookStore()
+	store.AddBook(context.Background(), model.Book{Title: "Laskar Pelangi", Author: "Andrea Hirata", PublishedYear: 2005})
+	store.AddBook(context.Background(), model.Book{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", PublishedYear: 1980})
+	h := handler.NewBookHandler(store)
+
+	req := httptest.NewRequest("GET", "/books/search?q=lask", nil)
+	rr := httptest.NewRecorder()
+	h.SearchBooksHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("SearchBooks: expected 200, got %d", rr.Code)
+	}
+
+	var response struct {
+		Data []model.SearchHit `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].Book.Title != "Laskar Pelangi" || response.Data[0].Score <= 0 {
+		t.Errorf("SearchBooks: unexpected hits: %+v", response.Data)
+	}
+	if response.Data[0].Highlights["title"] != "<mark>Laskar</mark> Pelangi" {
+		t.Errorf("SearchBooks: unexpected highlight: %+v", response.Data[0].Highlights)
+	}
+
+	for _, query := range []string{"", "q=lask&limit=0", "q=lask&limit=abc"} {
+		req := httptest.NewRequest("GET", "/books/search?"+query, nil)
+		rr := httptest.NewRecorder()
+		h.SearchBooksHandler(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("SearchBooks %q: expected 400, got %d", query, rr.Code)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	handler.NewBookHandler(failingStore{}).SearchBooksHandler(rr, httptest.NewRequest("GET", "/books/search?q=x", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("SearchBooks without index: expected 501, got %d", rr.Code)
+	}
+}
+
 func TestCreateBookHandler_Success(t *testing.T) {
 	book := model.Book{Title: "Test Book", Author: "Tester", PublishedYear: 2023}
 	body, _ := json.Marshal(book)
 
 	req := httptest.NewRequest("POST", "/books", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
 	bookHandler.CreateBookHandler(rr, req)
@@ -101,6 +230,7 @@ func TestCreateBookHandler_Success(t *testing.T) {
 
 func TestCreateBookHandler_InvalidBody(t *testing.T) {
 	req := httptest.NewRequest("POST", "/books", nil)
+	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
 	bookHandler.CreateBookHandler(rr, req)
@@ -115,6 +245,7 @@ func TestCreateBookHandler_BadRequest(t *testing.T) {
 	body, _ := json.Marshal(book)
 
 	req := httptest.NewRequest("POST", "/books", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
 	bookHandler.CreateBookHandler(rr, req)
@@ -124,6 +255,123 @@ func TestCreateBookHandler_BadRequest(t *testing.T) {
 	}
 }
 
+func TestCreateBookHandler_ValidationDetails(t *testing.T) {
+	body := `{"title":" Padded ","author":"","published_year":99999,"isbn":"978-0-306-40615-8"}`
+	req := httptest.NewRequest("POST", "/books", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	bookHandler.CreateBookHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("CreateBook: expected 400, got %d", rr.Code)
+	}
+	var resp struct {
+		Error   string             `json:"error"`
+		Details []model.FieldError `json:"details"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	want := map[string]string{
+		"title":          model.RuleTrimmed,
+		"author":         model.RuleRequired,
+		"published_year": model.RuleNotFuture,
+		"isbn":           model.RuleISBNChecksum,
+	}
+	if resp.Error != model.ErrValidation.Error() || len(resp.Details) != len(want) {
+		t.Fatalf("Unexpected error body: %+v", resp)
+	}
+	for _, d := range resp.Details {
+		if want[d.Field] != d.Code || d.Message == "" {
+			t.Errorf("Unexpected violation: %+v", d)
+		}
+	}
+}
+
+func TestCreateBookHandler_ValidationProblem(t *testing.T) {
+	req := httptest.NewRequest("POST", "/books", bytes.NewBufferString(`{"title":"","author":"A","published_year":2000}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/problem+json")
+	rr := httptest.NewRecorder()
+
+	bookHandler.CreateBookHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest || rr.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("CreateBook: expected 400 problem+json, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var problem struct {
+		Type    string             `json:"type"`
+		Status  int                `json:"status"`
+		Details []model.FieldError `json:"details"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if problem.Type != "/problems/validation-error" || problem.Status != http.StatusBadRequest ||
+		len(problem.Details) != 1 || problem.Details[0].Field != "title" {
+		t.Errorf("Unexpected problem: %+v", problem)
+	}
+}
+
+func TestCreateBookHandler_StrictDecoding(t *testing.T) {
+	h := handler.NewBookHandler(model.NewBookStore(), handler.WithMaxBodyBytes(128))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantField   string
+		wantOffset  int64
+	}{
+		{"wrong content type", "text/plain", `{}`, http.StatusUnsupportedMediaType, "unsupported_media_type", "", 0},
+		{"missing content type", "", `{}`, http.StatusUnsupportedMediaType, "unsupported_media_type", "", 0},
+		{"too large", "application/json", `{"title":"` + strings.Repeat("x", 200) + `"}`, http.StatusRequestEntityTooLarge, "body_too_large", "", 128},
+		{"unknown field", "application/json", `{"title":"Go","publisher":"X"}`, http.StatusBadRequest, "unknown_field", "publisher", 14},
+		{"trailing data", "application/json", `{"title":"Go"} {"title":"Again"}`, http.StatusBadRequest, "trailing_data", "", 15},
+		{"type mismatch", "application/json", `{"title":"Go","published_year":"2020"}`, http.StatusBadRequest, "type_mismatch", "published_year", 37},
+		{"syntax error", "application/json", `{"title":}`, http.StatusBadRequest, "syntax_error", "", 10},
+		{"empty body", "application/json", ``, http.StatusBadRequest, "empty_body", "", 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/books", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rr := httptest.NewRecorder()
+
+			h.CreateBookHandler(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			var resp struct {
+				Details []utils.DecodeError `json:"details"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || len(resp.Details) != 1 {
+				t.Fatalf("Expected one decode detail, got %v (%v)", resp.Details, err)
+			}
+			d := resp.Details[0]
+			if d.Code != tc.wantCode || d.Field != tc.wantField || d.Offset != tc.wantOffset {
+				t.Errorf("Expected %s/%q@%d, got %s/%q@%d", tc.wantCode, tc.wantField, tc.wantOffset, d.Code, d.Field, d.Offset)
+			}
+		})
+	}
+
+	req := httptest.NewRequest("POST", "/books", strings.NewReader(`{"title":"Go","author":"Riki","published_year":2024}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rr := httptest.NewRecorder()
+	h.CreateBookHandler(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 with charset parameter, got %d", rr.Code)
+	}
+}
+
 func TestGetBookHandler_Success(t *testing.T) {
 
 	rr := setupRequestWithID("GET", "/books/"+strconv.Itoa(mockBooks[0].ID), nil, bookHandler.GetBookHandler)
@@ -193,7 +441,7 @@ func TestUpdateBookHandler_InvalidID(t *testing.T) {
 }
 
 func TestUpdateBookHandler_InvalidBody(t *testing.T) {
-	rr := setupRequestWithID("PUT", "/books/1", nil, bookHandler.UpdateBookHandler)
+	rr := setupRequestWithID("PUT", "/books/1", bytes.NewBufferString("{"), bookHandler.UpdateBookHandler)
 
 	if rr.Code != http.StatusBadRequest {
 		t.Errorf("UpdateBook: expected 400, got %d", rr.Code)
@@ -231,7 +479,7 @@ func TestDeleteBookHandler_Success(t *testing.T) {
 		t.Errorf("DeletedBook: expected 200, got %d", rr.Code)
 	}
 
-	_, err := service.GetBookByID(mockBooks[0].ID)
+	_, err := service.GetBookByID(context.Background(), mockBooks[0].ID)
 
 	if err == nil {
 		t.Errorf("DeletedBook: expected book to be deleted, but it still exists")
@@ -253,3 +501,1501 @@ func TestDeleteBookHandler_NotFound(t *testing.T) {
 		t.Errorf("DeletedBook: expected 404, got %d", rr.Code)
 	}
 }
+
+// failingStore adalah BookStore yang selalu gagal dengan error tertentu.
+type failingStore struct{ err error }
+
+func (f failingStore) AddBook(context.Context, model.Book) (model.Book, error) {
+	return model.Book{}, f.err
+}
+func (f failingStore) GetAllBooks(context.Context) ([]model.Book, error) { return nil, f.err }
+func (f failingStore) QueryBooks(context.Context, model.BookQuery) (model.BookPage, error) {
+	return model.BookPage{}, f.err
+}
+func (f failingStore) StreamBooks(context.Context, model.BookQuery, func(model.Book) error) (model.BookPage, error) {
+	return model.BookPage{}, f.err
+}
+func (f failingStore) CollectionStamp(context.Context) (model.CollectionStamp, error) {
+	return model.CollectionStamp{}, f.err
+}
+func (f failingStore) GetBookByID(context.Context, int) (model.Book, error) {
+	return model.Book{}, f.err
+}
+func (f failingStore) UpdateBook(context.Context, int, model.Book) (model.Book, error) {
+	return model.Book{}, f.err
+}
+func (f failingStore) PatchBook(context.Context, int, func(model.Book) (model.Book, error)) (model.Book, error) {
+	return model.Book{}, f.err
+}
+func (f failingStore) DeleteBook(context.Context, int, int) error { return f.err }
+func (f failingStore) ApplyBatch(context.Context, []model.BatchOp) ([]model.Book, error) {
+	return nil, f.err
+}
+func (f failingStore) EachBook(context.Context, func(model.Book) error) error { return f.err }
+func (f failingStore) BookHistory(context.Context, int) ([]model.Revision, error) {
+	return nil, f.err
+}
+func (f failingStore) BookRevision(context.Context, int, int) (model.Revision, error) {
+	return model.Revision{}, f.err
+}
+func (f failingStore) ListTrash(context.Context) ([]model.TrashedBook, error) { return nil, f.err }
+func (f failingStore) RestoreBook(context.Context, int) (model.Book, error) {
+	return model.Book{}, f.err
+}
+func (f failingStore) PurgeBook(context.Context, int, int) error { return f.err }
+func (f failingStore) PurgeTrash(context.Context, time.Time) (int, error) {
+	return 0, f.err
+}
+
+func TestHandlers_StoreErrorMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not found", model.ErrNotFound, http.StatusNotFound},
+		{"conflict", model.ErrConflict, http.StatusConflict},
+		{"validation", model.ErrValidation, http.StatusBadRequest},
+		{"unavailable", model.ErrUnavailable, http.StatusServiceUnavailable},
+		{"deadline", context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{"backend failure", errors.New("disk full"), http.StatusInternalServerError},
+	}
+
+	body, _ := json.Marshal(model.Book{Title: "Updated", Author: "Someone", PublishedYear: 2000})
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := handler.NewBookHandler(failingStore{err: tc.err})
+
+			rr := setupRequestWithID("PUT", "/books/1", bytes.NewBuffer(body), h.UpdateBookHandler)
+			if rr.Code != tc.wantStatus {
+				t.Errorf("UpdateBook: expected %d, got %d", tc.wantStatus, rr.Code)
+			}
+
+			req := httptest.NewRequest("GET", "/books", nil)
+			rr = httptest.NewRecorder()
+			h.GetBooksHandler(rr, req)
+			if rr.Code != tc.wantStatus {
+				t.Errorf("GetBooks: expected %d, got %d", tc.wantStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateBookHandler_IfMatch(t *testing.T) {
+	store := model.NewBookStore()
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	h := handler.NewBookHandler(store)
+	id := strconv.Itoa(added.ID)
+	path, params := "/books/"+id, map[string]string{"id": id}
+	body, _ := json.Marshal(model.Book{Title: "Edited", Author: "Author", PublishedYear: 2020})
+
+	rr := doRequest(h.GetBookHandler, "GET", path, nil, "", params)
+	etag := rr.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("GetBook: expected ETag \"1\", got %q", etag)
+	}
+
+	rr = doRequest(h.UpdateBookHandler, "PUT", path, map[string]string{"If-Match": etag}, string(body), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateBook with current ETag: expected 200, got %d", rr.Code)
+	}
+	if rr.Header().Get("ETag") != `"2"` {
+		t.Errorf("UpdateBook: expected new ETag \"2\", got %q", rr.Header().Get("ETag"))
+	}
+
+	// Editor kedua masih memegang ETag lama dan tidak boleh menimpa perubahan.
+	rr = doRequest(h.UpdateBookHandler, "PUT", path, map[string]string{"If-Match": etag}, string(body), params)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("UpdateBook with stale ETag: expected 412, got %d", rr.Code)
+	}
+
+	for _, ifMatch := range []string{`"1", "2"`, "*"} {
+		rr = doRequest(h.UpdateBookHandler, "PUT", path, map[string]string{"If-Match": ifMatch}, string(body), params)
+		if rr.Code != http.StatusOK {
+			t.Errorf("UpdateBook with If-Match %s: expected 200, got %d", ifMatch, rr.Code)
+		}
+	}
+
+	rr = doRequest(h.UpdateBookHandler, "PUT", path, map[string]string{"If-Match": `W/"4"`}, string(body), params)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("UpdateBook with weak ETag: expected 412, got %d", rr.Code)
+	}
+
+	rr = doRequest(h.UpdateBookHandler, "PUT", "/books/999", map[string]string{"If-Match": "*"}, string(body), map[string]string{"id": "999"})
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("UpdateBook with * on missing book: expected 412, got %d", rr.Code)
+	}
+}
+
+func TestDeleteBookHandler_IfMatch(t *testing.T) {
+	store := model.NewBookStore()
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	h := handler.NewBookHandler(store)
+	id := strconv.Itoa(added.ID)
+	path, params := "/books/"+id, map[string]string{"id": id}
+
+	rr := doRequest(h.DeleteBookHandler, "DELETE", path, map[string]string{"If-Match": `"7"`}, "", params)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("DeleteBook with stale ETag: expected 412, got %d", rr.Code)
+	}
+
+	rr = doRequest(h.DeleteBookHandler, "DELETE", path, map[string]string{"If-Match": `"1"`}, "", params)
+	if rr.Code != http.StatusOK {
+		t.Errorf("DeleteBook with current ETag: expected 200, got %d", rr.Code)
+	}
+}
+
+func TestHandlers_RequireIfMatch(t *testing.T) {
+	store := model.NewBookStore()
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	h := handler.NewBookHandler(store, handler.WithRequireIfMatch(true))
+	id := strconv.Itoa(added.ID)
+	path, params := "/books/"+id, map[string]string{"id": id}
+	body, _ := json.Marshal(model.Book{Title: "Edited", Author: "Author", PublishedYear: 2020})
+
+	if rr := doRequest(h.UpdateBookHandler, "PUT", path, nil, string(body), params); rr.Code != http.StatusPreconditionRequired {
+		t.Errorf("UpdateBook without If-Match: expected 428, got %d", rr.Code)
+	}
+	if rr := doRequest(h.DeleteBookHandler, "DELETE", path, nil, "", params); rr.Code != http.StatusPreconditionRequired {
+		t.Errorf("DeleteBook without If-Match: expected 428, got %d", rr.Code)
+	}
+	if rr := doRequest(h.UpdateBookHandler, "PUT", path, map[string]string{"If-Match": `"1"`}, string(body), params); rr.Code != http.StatusOK {
+		t.Errorf("UpdateBook with If-Match: expected 200, got %d", rr.Code)
+	}
+}
+
+func TestGetBookHandler_NotModified(t *testing.T) {
+	store := model.NewBookStore()
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	h := handler.NewBookHandler(store)
+	id := strconv.Itoa(added.ID)
+	path, params := "/books/"+id, map[string]string{"id": id}
+
+	rr := doRequest(h.GetBookHandler, "GET", path, nil, "", params)
+	etag, lastModified := rr.Header().Get("ETag"), rr.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("GetBook: expected ETag and Last-Modified, got %q and %q", etag, lastModified)
+	}
+
+	conditional := func(header, value string) *httptest.ResponseRecorder {
+		r := chi.NewRouter()
+		r.Get("/books/{id}", h.GetBookHandler)
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(header, value)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := conditional("If-None-Match", etag); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("GetBook If-None-Match: expected empty 304, got %d with %q", rr.Code, rr.Body.String())
+	}
+	if rr := conditional("If-None-Match", "W/"+etag); rr.Code != http.StatusNotModified {
+		t.Errorf("GetBook weak If-None-Match: expected 304, got %d", rr.Code)
+	}
+	if rr := conditional("If-Modified-Since", lastModified); rr.Code != http.StatusNotModified {
+		t.Errorf("GetBook If-Modified-Since: expected 304, got %d", rr.Code)
+	}
+
+	store.UpdateBook(context.Background(), added.ID, model.Book{Title: "Edited", Author: "Author", PublishedYear: 2020})
+
+	if rr := conditional("If-None-Match", etag); rr.Code != http.StatusOK {
+		t.Errorf("GetBook stale If-None-Match: expected 200, got %d", rr.Code)
+	}
+}
+
+func TestGetBooksHandler_NotModified(t *testing.T) {
+	store := model.NewBookStore()
+	store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	h := handler.NewBookHandler(store)
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		h.GetBooksHandler(rr, req)
+		return rr
+	}
+
+	rr := get("/books?limit=10", "")
+	etag := rr.Header().Get("ETag")
+	if etag == "" || rr.Header().Get("Last-Modified") == "" {
+		t.Fatalf("GetBooks: expected ETag and Last-Modified headers, got %v", rr.Header())
+	}
+
+	if rr := get("/books?limit=10", etag); rr.Code != http.StatusNotModified {
+		t.Errorf("GetBooks unchanged: expected 304, got %d", rr.Code)
+	}
+	if rr := get("/books?limit=5", etag); rr.Code != http.StatusOK {
+		t.Errorf("GetBooks with different query: expected 200, got %d", rr.Code)
+	}
+
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Another", Author: "Author", PublishedYear: 2021})
+	if rr := get("/books?limit=10", etag); rr.Code != http.StatusOK {
+		t.Errorf("GetBooks after add: expected 200, got %d", rr.Code)
+	}
+
+	etag = get("/books?limit=10", "").Header().Get("ETag")
+	store.DeleteBook(context.Background(), added.ID, 0)
+	if rr := get("/books?limit=10", etag); rr.Code != http.StatusOK {
+		t.Errorf("GetBooks after delete: expected 200, got %d", rr.Code)
+	}
+}
+
+// interruptedStore meneruskan StreamBooks ke BookStore di bawahnya, lalu memanggil
+// interrupt setelah after buku terkirim.
+type interruptedStore struct {
+	model.BookStore
+	after     int
+	interrupt func() error
+}
+
+func (s interruptedStore) StreamBooks(ctx context.Context, q model.BookQuery, fn func(model.Book) error) (model.BookPage, error) {
+	sent := 0
+	return s.BookStore.StreamBooks(ctx, q, func(b model.Book) error {
+		if sent == s.after {
+			if err := s.interrupt(); err != nil {
+				return err
+			}
+		}
+		sent++
+		return fn(b)
+	})
+}
+
+func TestGetBooksHandler_StreamAborts(t *testing.T) {
+	store := model.NewBookStore()
+	for i := 0; i < utils.StreamFlushEvery+10; i++ {
+		store.AddBook(context.Background(), model.Book{Title: "Book " + strconv.Itoa(i), Author: "Author", PublishedYear: 2020})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		interrupt func() error
+	}{
+		{"store failure", context.Background(), func() error { return model.ErrUnavailable }},
+		{"client disconnect", ctx, func() error { cancel(); return nil }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := handler.NewBookHandler(interruptedStore{BookStore: store, after: utils.StreamFlushEvery + 5, interrupt: tc.interrupt})
+			rr := httptest.NewRecorder()
+			defer func() {
+				if rec := recover(); rec != http.ErrAbortHandler {
+					t.Errorf("expected panic http.ErrAbortHandler, got %v", rec)
+				}
+				if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Body.String(), `{"data":[{"id":1,`) || strings.Contains(rr.Body.String(), `"meta"`) {
+					t.Errorf("expected truncated listing, got %d %s", rr.Code, rr.Body.String())
+				}
+			}()
+			h.GetBooksHandler(rr, httptest.NewRequest("GET", "/books?limit=1000", nil).WithContext(tc.ctx))
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	h := handler.NewBookHandler(interruptedStore{BookStore: store, after: 1, interrupt: func() error { return model.ErrUnavailable }})
+	h.GetBooksHandler(rr, httptest.NewRequest("GET", "/books", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("failure before first flush: expected 503, got %d", rr.Code)
+	}
+	if rr.Header().Get("ETag") != "" || rr.Header().Get("Last-Modified") != "" {
+		t.Errorf("failure before first flush: expected no validators, got %v", rr.Header())
+	}
+}
+
+func TestPatchBookHandler_MergePatch(t *testing.T) {
+	store := model.NewBookStore()
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	h := handler.NewBookHandler(store)
+	id := strconv.Itoa(added.ID)
+	path, params := "/books/"+id, map[string]string{"id": id}
+
+	rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": "application/merge-patch+json"}, `{"title":"Patched"}`, params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got, _ := store.GetBookByID(context.Background(), added.ID)
+	if got.Title != "Patched" || got.Author != "Author" || got.PublishedYear != 2020 || got.Version != 2 {
+		t.Errorf("Unexpected book after merge patch: %+v", got)
+	}
+	if etag := rr.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("Expected ETag \"2\", got %s", etag)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"remove required field", `{"author":null}`, http.StatusBadRequest},
+		{"read-only id", `{"id":99}`, http.StatusBadRequest},
+		{"read-only version", `{"version":7}`, http.StatusBadRequest},
+		{"unknown field", `{"publisher":"Gramedia"}`, http.StatusBadRequest},
+		{"invalid isbn", `{"isbn":"123"}`, http.StatusBadRequest},
+		{"wrong type", `{"published_year":"2020"}`, http.StatusBadRequest},
+		{"malformed", `{"title":`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": "application/merge-patch+json"}, tc.body, params); rr.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d", tc.wantStatus, rr.Code)
+			}
+		})
+	}
+
+	if got, _ := store.GetBookByID(context.Background(), added.ID); got.Version != 2 {
+		t.Errorf("Expected rejected patches to leave book untouched, got %+v", got)
+	}
+}
+
+func TestPatchBookHandler_JSONPatch(t *testing.T) {
+	store := model.NewBookStore()
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	h := handler.NewBookHandler(store)
+	id := strconv.Itoa(added.ID)
+	path, params := "/books/"+id, map[string]string{"id": id}
+	const contentType = "application/json-patch+json"
+
+	body := `[{"op":"test","path":"/title","value":"Book"},{"op":"replace","path":"/published_year","value":2021}]`
+	rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": contentType}, body, params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got, _ := store.GetBookByID(context.Background(), added.ID); got.PublishedYear != 2021 {
+		t.Errorf("Expected published_year 2021, got %+v", got)
+	}
+
+	// Operasi test yang gagal membatalkan seluruh patch.
+	body = `[{"op":"replace","path":"/author","value":"Other"},{"op":"test","path":"/title","value":"Nope"}]`
+	if rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": contentType}, body, params); rr.Code != http.StatusConflict {
+		t.Errorf("Failed test op: expected 409, got %d", rr.Code)
+	}
+	if got, _ := store.GetBookByID(context.Background(), added.ID); got.Author != "Author" {
+		t.Errorf("Expected failed patch to be atomic, got %+v", got)
+	}
+
+	if rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": contentType}, `[{"op":"remove","path":"/missing"}]`, params); rr.Code != http.StatusBadRequest {
+		t.Errorf("Remove missing path: expected 400, got %d", rr.Code)
+	}
+	if rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": contentType}, `{"op":"remove"}`, params); rr.Code != http.StatusBadRequest {
+		t.Errorf("Non-array patch: expected 400, got %d", rr.Code)
+	}
+}
+
+func TestPatchBookHandler_Preconditions(t *testing.T) {
+	store := model.NewBookStore()
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	id := strconv.Itoa(added.ID)
+	path, params := "/books/"+id, map[string]string{"id": id}
+	h := handler.NewBookHandler(store, handler.WithRequireIfMatch(true))
+
+	rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": "application/json", "If-Match": `"1"`}, `{"title":"X"}`, params)
+	if rr.Code != http.StatusUnsupportedMediaType || rr.Header().Get("Accept-Patch") == "" {
+		t.Errorf("Expected 415 with Accept-Patch, got %d %v", rr.Code, rr.Header())
+	}
+	if rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": "application/merge-patch+json"}, `{"title":"X"}`, params); rr.Code != http.StatusPreconditionRequired {
+		t.Errorf("Missing If-Match: expected 428, got %d", rr.Code)
+	}
+	if rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"5"`}, `{"title":"X"}`, params); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Stale If-Match: expected 412, got %d", rr.Code)
+	}
+	if rr := doRequest(h.PatchBookHandler, "PATCH", path, map[string]string{"Content-Type": "application/merge-patch+json; charset=utf-8", "If-Match": `"1"`}, `{"title":"X"}`, params); rr.Code != http.StatusOK {
+		t.Errorf("Current If-Match: expected 200, got %d", rr.Code)
+	}
+	if rr := doRequest(h.PatchBookHandler, "PATCH", "/books/999", map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `*`}, `{"title":"X"}`, map[string]string{"id": "999"}); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("If-Match * on missing book: expected 412, got %d", rr.Code)
+	}
+	if rr := doRequest(handler.NewBookHandler(store).PatchBookHandler, "PATCH", "/books/999", map[string]string{"Content-Type": "application/merge-patch+json"}, `{"title":"X"}`, map[string]string{"id": "999"}); rr.Code != http.StatusNotFound {
+		t.Errorf("Missing book: expected 404, got %d", rr.Code)
+	}
+}
+
+func TestPatchBookHandler_V2(t *testing.T) {
+	store := model.NewBookStore()
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Book", Author: "Author", PublishedYear: 2020})
+	h := handler.NewBookHandler(store)
+	r := chi.NewRouter()
+	r.With(h.VersionMiddleware(handler.APIVersion2)).Patch("/books/{id}", h.PatchBookHandler)
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/books/"+strconv.Itoa(added.ID), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := patch("application/json-patch+json", `[{"op":"replace","path":"/author/name","value":"Other"},{"op":"replace","path":"/isbn","value":"0-306-40615-2"}]`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"author":{"name":"Other"}`) {
+		t.Fatalf("Expected v2 book, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got, _ := store.GetBookByID(context.Background(), added.ID); got.Author != "Other" || got.ISBN != "0-306-40615-2" {
+		t.Errorf("Unexpected book after v2 patch: %+v", got)
+	}
+
+	rr = patch("application/merge-patch+json", `{"publication":{"year":0},"published_year":2021}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected v1 field to be rejected, got %d", rr.Code)
+	}
+	rr = patch("application/merge-patch+json", `{"publication":{"year":0}}`)
+	var resp struct {
+		Details []model.FieldError `json:"details"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if rr.Code != http.StatusBadRequest || len(resp.Details) != 1 || resp.Details[0].Field != "publication.year" {
+		t.Errorf("Expected publication.year validation error, got %d %+v", rr.Code, resp.Details)
+	}
+}
+
+func TestCreateBookHandler_IdempotencyKey(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	body := `{"title":"Go","author":"Riki","published_year":2024}`
+
+	first := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "import-1"}, body, nil)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("First request: expected 201, got %d", first.Code)
+	}
+	retry := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "import-1"}, body, nil)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("Retry: expected replayed 201 %s, got %d %s", first.Body.String(), retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" || retry.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Errorf("Retry: unexpected headers %v", retry.Header())
+	}
+
+	if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "import-1"}, `{"title":"Other","author":"Riki","published_year":2024}`, nil); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Different body: expected 422, got %d", rr.Code)
+	}
+	if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "import-2"}, body, nil); rr.Code != http.StatusCreated || rr.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("New key: expected fresh 201, got %d", rr.Code)
+	}
+	if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": strings.Repeat("k", 256)}, body, nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("Long key: expected 400, got %d", rr.Code)
+	}
+
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 2 {
+		t.Errorf("Expected 2 books, got %d", len(books))
+	}
+}
+
+func TestCreateBookHandler_IdempotencyKeyFailedRequestIsNotStored(t *testing.T) {
+	h := handler.NewBookHandler(model.NewBookStore())
+
+	if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "retry-me"}, `{"title":""}`, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("Invalid body: expected 400, got %d", rr.Code)
+	}
+	if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "retry-me"}, `{"title":"Go","author":"Riki","published_year":2024}`, nil); rr.Code != http.StatusCreated {
+		t.Errorf("Corrected retry: expected 201, got %d", rr.Code)
+	}
+}
+
+func TestCreateBookHandler_IdempotencyKeyExpires(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store, handler.WithIdempotencyTTL(20*time.Millisecond))
+	body := `{"title":"Go","author":"Riki","published_year":2024}`
+
+	doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "short-lived"}, body, nil)
+	time.Sleep(40 * time.Millisecond)
+	if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "short-lived"}, body, nil); rr.Code != http.StatusCreated || rr.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("After TTL: expected fresh 201, got %d %v", rr.Code, rr.Header())
+	}
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 2 {
+		t.Errorf("Expected expired key to create a second book, got %d books", len(books))
+	}
+}
+
+func TestCreateBookHandler_IdempotencyKeyEvictsOldestWhenFull(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store, handler.WithIdempotencyMaxEntries(2))
+	body := `{"title":"Go","author":"Riki","published_year":2024}`
+
+	for _, key := range []string{"a", "b", "c"} {
+		if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": key}, body, nil); rr.Code != http.StatusCreated {
+			t.Fatalf("Key %s: expected 201, got %d", key, rr.Code)
+		}
+	}
+	if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "c"}, body, nil); rr.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Newest key: expected replayed response, got %v", rr.Header())
+	}
+	if rr := doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "a"}, body, nil); rr.Code != http.StatusCreated || rr.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("Evicted key: expected fresh 201, got %d %v", rr.Code, rr.Header())
+	}
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 4 {
+		t.Errorf("Expected 4 books, got %d", len(books))
+	}
+}
+
+// slowStore menahan AddBook sampai release ditutup agar request duplikat datang saat in-flight.
+type slowStore struct {
+	model.BookStore
+	release chan struct{}
+}
+
+func (s slowStore) AddBook(ctx context.Context, b model.Book) (model.Book, error) {
+	<-s.release
+	return s.BookStore.AddBook(ctx, b)
+}
+
+func TestCreateBookHandler_IdempotencyKeyConcurrent(t *testing.T) {
+	store := slowStore{BookStore: model.NewBookStore(), release: make(chan struct{})}
+	h := handler.NewBookHandler(store)
+	body := `{"title":"Go","author":"Riki","published_year":2024}`
+
+	const n = 8
+	results := make(chan *httptest.ResponseRecorder, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			results <- doRequest(h.CreateBookHandler, "POST", "/books", map[string]string{"Idempotency-Key": "burst"}, body, nil)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(store.release)
+
+	var bodies []string
+	for i := 0; i < n; i++ {
+		rr := <-results
+		if rr.Code != http.StatusCreated {
+			t.Errorf("Expected 201, got %d", rr.Code)
+		}
+		bodies = append(bodies, rr.Body.String())
+	}
+	for _, b := range bodies[1:] {
+		if b != bodies[0] {
+			t.Errorf("Expected identical responses, got %s and %s", bodies[0], b)
+		}
+	}
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 1 {
+		t.Errorf("Expected exactly 1 book, got %d", len(books))
+	}
+}
+
+// batchResponse adalah bentuk response POST /books/batch yang diperiksa di test.
+type batchResponse struct {
+	Data []struct {
+		Index   int               `json:"index"`
+		Op      string            `json:"op"`
+		Status  int               `json:"status"`
+		Data    *model.Book       `json:"data"`
+		Error   string            `json:"error"`
+		Details []json.RawMessage `json:"details"`
+	} `json:"data"`
+	Meta struct {
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+	} `json:"meta"`
+	Error   string             `json:"error"`
+	Details []model.FieldError `json:"details"`
+}
+
+func TestBatchBooksHandler_Atomic(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	existing, _ := store.AddBook(context.Background(), model.Book{Title: "Old", Author: "Riki", PublishedYear: 2020})
+
+	rr := doRequest(h.BatchBooksHandler, "POST", "/books/batch?atomic=true", nil, `{"operations":[
+		{"op":"create","book":{"title":"New","author":"Riki","published_year":2024}},
+		{"op":"update","id":1,"version":1,"book":{"title":"Renamed","author":"Riki","published_year":2020}},
+		{"op":"delete","id":1,"version":2}
+	]}`, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp batchResponse
+	json.NewDecoder(rr.Body).Decode(&resp)
+	wantStatus := []int{http.StatusCreated, http.StatusOK, http.StatusNoContent}
+	for i, res := range resp.Data {
+		if res.Index != i || res.Status != wantStatus[i] {
+			t.Errorf("Operation %d: expected status %d, got %+v", i, wantStatus[i], res)
+		}
+	}
+	if resp.Data[0].Data == nil || resp.Data[0].Data.ID != existing.ID+1 {
+		t.Errorf("Expected created book in result, got %+v", resp.Data[0].Data)
+	}
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 1 || books[0].Title != "New" {
+		t.Errorf("Expected only the new book to remain, got %+v", books)
+	}
+
+	// Operasi kedua gagal sehingga operasi pertama juga tidak disimpan.
+	rr = doRequest(h.BatchBooksHandler, "POST", "/books/batch?atomic=true", nil, `{"operations":[
+		{"op":"create","book":{"title":"Never","author":"Riki","published_year":2024}},
+		{"op":"delete","id":99}
+	]}`, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var failed struct {
+		Error   string `json:"error"`
+		Details []struct {
+			Index  int `json:"index"`
+			Status int `json:"status"`
+		} `json:"details"`
+	}
+	json.NewDecoder(rr.Body).Decode(&failed)
+	if len(failed.Details) != 1 || failed.Details[0].Index != 1 || failed.Details[0].Status != http.StatusNotFound {
+		t.Errorf("Expected failing operation 1 in details, got %+v", failed)
+	}
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 1 {
+		t.Errorf("Expected failed batch to store nothing, got %d books", len(books))
+	}
+}
+
+func TestBatchBooksHandler_AtomicValidation(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+
+	rr := doRequest(h.BatchBooksHandler, "POST", "/books/batch?atomic=true", nil, `{"operations":[
+		{"op":"create","book":{"title":"Valid","author":"Riki","published_year":2024}},
+		{"op":"create","book":{"title":"","author":"Riki","published_year":2024}},
+		{"op":"rename","id":1}
+	]}`, nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp batchResponse
+	json.NewDecoder(rr.Body).Decode(&resp)
+	want := []string{"operations[1].book.title", "operations[2].op"}
+	if len(resp.Details) != len(want) {
+		t.Fatalf("Expected %d field errors, got %+v", len(want), resp.Details)
+	}
+	for i, field := range want {
+		if resp.Details[i].Field != field {
+			t.Errorf("Field error %d: expected %s, got %s", i, field, resp.Details[i].Field)
+		}
+	}
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 0 {
+		t.Errorf("Expected invalid batch to store nothing, got %d books", len(books))
+	}
+}
+
+func TestBatchBooksHandler_BestEffort(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	existing, _ := store.AddBook(context.Background(), model.Book{Title: "Old", Author: "Riki", PublishedYear: 2020})
+
+	rr := doRequest(h.BatchBooksHandler, "POST", "/books/batch", nil, `{"operations":[
+		{"op":"create","book":{"title":"New","author":"Riki","published_year":2024}},
+		{"op":"create","book":{"title":"Bad","author":"Riki","published_year":0}},
+		{"op":"update","id":1,"version":7,"book":{"title":"Stale","author":"Riki","published_year":2020}},
+		{"op":"delete","id":42},
+		{"op":"delete","id":1}
+	]}`, nil)
+	if rr.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected 207, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp batchResponse
+	json.NewDecoder(rr.Body).Decode(&resp)
+	wantStatus := []int{
+		http.StatusCreated,
+		http.StatusBadRequest,
+		http.StatusPreconditionFailed,
+		http.StatusNotFound,
+		http.StatusNoContent,
+	}
+	if len(resp.Data) != len(wantStatus) {
+		t.Fatalf("Expected %d results, got %d", len(wantStatus), len(resp.Data))
+	}
+	for i, want := range wantStatus {
+		if resp.Data[i].Status != want {
+			t.Errorf("Operation %d: expected %d, got %+v", i, want, resp.Data[i])
+		}
+	}
+	if len(resp.Data[1].Details) != 1 {
+		t.Errorf("Expected validation details for operation 1, got %+v", resp.Data[1])
+	}
+	if resp.Meta.Succeeded != 2 || resp.Meta.Failed != 3 {
+		t.Errorf("Expected 2 succeeded and 3 failed, got %+v", resp.Meta)
+	}
+	if _, err := store.GetBookByID(context.Background(), existing.ID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("Expected book %d to be deleted, got %v", existing.ID, err)
+	}
+}
+
+func TestBatchBooksHandler_BadRequest(t *testing.T) {
+	h := handler.NewBookHandler(model.NewBookStore(), handler.WithRequireIfMatch(true))
+
+	tests := []struct {
+		name, query, body string
+		wantStatus        int
+	}{
+		{"empty", "", `{"operations":[]}`, http.StatusBadRequest},
+		{"invalid atomic", "?atomic=maybe", `{"operations":[{"op":"delete","id":1}]}`, http.StatusBadRequest},
+		{"unknown field", "", `{"operations":[{"op":"delete","id":1,"force":true}]}`, http.StatusBadRequest},
+		{"version required", "?atomic=true", `{"operations":[{"op":"delete","id":1}]}`, http.StatusBadRequest},
+		{"too many", "", `{"operations":[` + strings.Repeat(`{"op":"delete","id":1},`, 1000) + `{"op":"delete","id":1}]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := doRequest(h.BatchBooksHandler, "POST", "/books/batch"+tt.query, nil, tt.body, nil); rr.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestExportBooksHandler(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	store.AddBook(context.Background(), model.Book{Title: "Go", Author: "Riki", PublishedYear: 2024, ISBN: "978-0-306-40615-7"})
+	store.AddBook(context.Background(), model.Book{Title: "Quotes, \"and\" commas", Author: "Ana", PublishedYear: 2020})
+
+	rr := httptest.NewRecorder()
+	h.ExportBooksHandler(rr, httptest.NewRequest("GET", "/books/export?format=jsonl", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("Expected 200 application/x-ndjson, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", rr.Body.String())
+	}
+	var first model.Book
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.ID != 1 || first.ISBN != "978-0-306-40615-7" {
+		t.Errorf("Unexpected first line %q (%v)", lines[0], err)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ExportBooksHandler(rr, httptest.NewRequest("GET", "/books/export?format=csv", nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected 200 text/csv, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if got := rr.Header().Get("Content-Disposition"); got != `attachment; filename="books.csv"` {
+		t.Errorf("Unexpected Content-Disposition %q", got)
+	}
+	csvLines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(csvLines) != 3 || csvLines[0] != "id,title,author,published_year,isbn,version,updated_at" {
+		t.Fatalf("Unexpected CSV export %q", rr.Body.String())
+	}
+	if !strings.HasPrefix(csvLines[2], `2,"Quotes, ""and"" commas",Ana,2020,,1,`) {
+		t.Errorf("Expected quoted CSV row, got %q", csvLines[2])
+	}
+
+	// Hasil export bisa di-import ulang ke store lain.
+	target := model.NewBookStore()
+	req := httptest.NewRequest("POST", "/books/import", strings.NewReader(rr.Body.String()))
+	req.Header.Set("Content-Type", "text/csv")
+	rr = httptest.NewRecorder()
+	handler.NewBookHandler(target).ImportBooksHandler(rr, req)
+	if books, _ := target.GetAllBooks(context.Background()); rr.Code != http.StatusOK || len(books) != 2 {
+		t.Errorf("Expected round trip to import 2 books, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExportBooksHandler_CSVFormulas(t *testing.T) {
+	store := model.NewBookStore()
+	store.AddBook(context.Background(), model.Book{Title: `=HYPERLINK("http://evil","klik")`, Author: "-Riki", PublishedYear: 2024})
+
+	rr := doRequest(handler.NewBookHandler(store).ExportBooksHandler, "GET", "/books/export?format=csv", nil, "", nil)
+	if !strings.Contains(rr.Body.String(), `"'=HYPERLINK(""http://evil"",""klik"")",'-Riki,2024,,`) {
+		t.Fatalf("Expected formula cells to be prefixed with ', got %q", rr.Body.String())
+	}
+
+	target := model.NewBookStore()
+	rr = doRequest(handler.NewBookHandler(target).ImportBooksHandler, "POST", "/books/import", map[string]string{"Content-Type": "text/csv"}, rr.Body.String(), nil)
+	books, _ := target.GetAllBooks(context.Background())
+	if rr.Code != http.StatusOK || len(books) != 1 || books[0].Title != `=HYPERLINK("http://evil","klik")` || books[0].Author != "-Riki" {
+		t.Errorf("Expected import to strip the prefix, got %d %+v", rr.Code, books)
+	}
+}
+
+func TestExportBooksHandler_Errors(t *testing.T) {
+	rr := httptest.NewRecorder()
+	bookHandler.ExportBooksHandler(rr, httptest.NewRequest("GET", "/books/export?format=xml", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Unknown format: expected 400, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.NewBookHandler(failingStore{err: model.ErrUnavailable}).ExportBooksHandler(rr, httptest.NewRequest("GET", "/books/export?format=csv", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Store failure: expected 503, got %d", rr.Code)
+	}
+	if strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Expected error response instead of CSV, got Content-Type %q", rr.Header().Get("Content-Type"))
+	}
+}
+
+// importResponse adalah bentuk laporan POST /books/import yang diperiksa di test.
+type importResponse struct {
+	Data struct {
+		DryRun   bool `json:"dry_run"`
+		Total    int  `json:"total"`
+		Accepted []struct {
+			Line int `json:"line"`
+			ID   int `json:"id"`
+		} `json:"accepted"`
+		Rejected []struct {
+			Line   int    `json:"line"`
+			Reason string `json:"reason"`
+		} `json:"rejected"`
+		Duplicates []struct {
+			Line            int    `json:"line"`
+			Match           string `json:"match"`
+			ExistingID      int    `json:"existing_id"`
+			DuplicateOfLine int    `json:"duplicate_of_line"`
+		} `json:"duplicates"`
+	} `json:"data"`
+}
+
+func TestImportBooksHandler_CSVReport(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	existing, _ := store.AddBook(context.Background(), model.Book{Title: "Existing", Author: "Riki", PublishedYear: 2020, ISBN: "9780306406157"})
+
+	body := "title,author,published_year,isbn\n" +
+		"Go,Riki,2024,\n" + // 2: accepted
+		"Bad Year,Riki,soon,\n" + // 3: rejected (bukan angka)
+		",Riki,2024,\n" + // 4: rejected (validasi)
+		"Copy,Ana,2021,978-0-306-40615-7\n" + // 5: duplikat ISBN buku yang ada
+		"go,riki,2024,\n" + // 6: duplikat baris 2
+		"Too,Many,2024,,extra\n" + // 7: rejected (jumlah kolom)
+		"\"Multi\nLine\",Ana,2022,\n" // 8-9: accepted
+
+	rr := doRequest(h.ImportBooksHandler, "POST", "/books/import", map[string]string{"Content-Type": "text/csv"}, body, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp importResponse
+	json.NewDecoder(rr.Body).Decode(&resp)
+	report := resp.Data
+
+	if report.Total != 7 || report.DryRun {
+		t.Errorf("Expected 7 rows without dry run, got %+v", report)
+	}
+	if len(report.Accepted) != 2 || report.Accepted[0].Line != 2 || report.Accepted[1].Line != 8 || report.Accepted[0].ID == 0 {
+		t.Errorf("Unexpected accepted rows: %+v", report.Accepted)
+	}
+	var rejected []int
+	for _, r := range report.Rejected {
+		rejected = append(rejected, r.Line)
+	}
+	if len(rejected) != 3 || rejected[0] != 3 || rejected[1] != 4 || rejected[2] != 7 {
+		t.Errorf("Expected rejected lines [3 4 7], got %v", rejected)
+	}
+	if len(report.Duplicates) != 2 {
+		t.Fatalf("Expected 2 duplicates, got %+v", report.Duplicates)
+	}
+	if d := report.Duplicates[0]; d.Line != 5 || d.Match != "isbn" || d.ExistingID != existing.ID {
+		t.Errorf("Unexpected ISBN duplicate: %+v", d)
+	}
+	if d := report.Duplicates[1]; d.Line != 6 || d.Match != "title_author_year" || d.DuplicateOfLine != 2 {
+		t.Errorf("Unexpected in-file duplicate: %+v", d)
+	}
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 3 {
+		t.Errorf("Expected 3 books after import, got %d", len(books))
+	}
+}
+
+func TestImportBooksHandler_JSONLDryRun(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+
+	body := `{"title":"Go","author":"Riki","published_year":2024}` + "\n" +
+		"\n" +
+		`{"title":"Typo","auhtor":"Riki","published_year":2024}` + "\n" +
+		`{"id":7,"version":3,"title":"Exported","author":"Ana","published_year":2020}`
+
+	rr := doRequest(h.ImportBooksHandler, "POST", "/books/import?dry_run=true", map[string]string{"Content-Type": "application/x-ndjson"}, body, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp importResponse
+	json.NewDecoder(rr.Body).Decode(&resp)
+	report := resp.Data
+	if !report.DryRun || report.Total != 3 || len(report.Accepted) != 2 || report.Accepted[0].ID != 0 {
+		t.Errorf("Unexpected dry-run report: %+v", report)
+	}
+	if len(report.Rejected) != 1 || report.Rejected[0].Line != 3 || !strings.Contains(report.Rejected[0].Reason, "auhtor") {
+		t.Errorf("Expected unknown field rejected on line 3, got %+v", report.Rejected)
+	}
+	if books, _ := store.GetAllBooks(context.Background()); len(books) != 0 {
+		t.Errorf("Expected dry run to store nothing, got %d books", len(books))
+	}
+}
+
+func TestImportBooksHandler_BadRequest(t *testing.T) {
+	tests := []struct {
+		name, query, contentType, body string
+		wantStatus                     int
+	}{
+		{"unsupported media type", "", "application/json", `{}`, http.StatusUnsupportedMediaType},
+		{"format overrides content type", "?format=csv", "text/plain", "title,author\n", http.StatusBadRequest},
+		{"missing column", "", "text/csv", "title,author\nGo,Riki\n", http.StatusBadRequest},
+		{"unknown column", "", "text/csv", "title,author,published_year,genre\n", http.StatusBadRequest},
+		{"empty csv", "", "text/csv", "", http.StatusBadRequest},
+		{"invalid dry_run", "?dry_run=perhaps", "text/csv", "title,author,published_year\n", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := doRequest(bookHandler.ImportBooksHandler, "POST", "/books/import"+tt.query, map[string]string{"Content-Type": tt.contentType}, tt.body, nil); rr.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	h := handler.NewBookHandler(model.NewBookStore(), handler.WithMaxImportBytes(16))
+	if rr := doRequest(h.ImportBooksHandler, "POST", "/books/import", map[string]string{"Content-Type": "text/csv"}, "title,author,published_year\nGo,Riki,2024\n", nil); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", rr.Code)
+	}
+}
+
+func TestBookHistoryHandlers(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	ctx := model.WithAudit(context.Background(), model.Audit{Actor: "alice", RequestID: "req-1"})
+	added, _ := store.AddBook(ctx, model.Book{Title: "Draft", Author: "Riki", PublishedYear: 2023})
+	store.UpdateBook(ctx, added.ID, model.Book{Title: "Final", Author: "Riki", PublishedYear: 2024})
+	store.DeleteBook(ctx, added.ID, 0)
+	id := strconv.Itoa(added.ID)
+
+	rr := doRequest(h.GetBookHistoryHandler, "GET", "/books/"+id+"/history", nil, "", map[string]string{"id": id})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("History: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var history struct {
+		Data []struct {
+			Rev     int                 `json:"rev"`
+			Action  string              `json:"action"`
+			Actor   string              `json:"actor"`
+			Before  *model.Book         `json:"before"`
+			After   *model.Book         `json:"after"`
+			Changes []model.FieldChange `json:"changes"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&history)
+	if len(history.Data) != 3 {
+		t.Fatalf("Expected 3 revisions for deleted book, got %d", len(history.Data))
+	}
+	if r := history.Data[1]; r.Action != "update" || r.Actor != "alice" || len(r.Changes) != 2 || r.Changes[0].Field != "title" {
+		t.Errorf("Unexpected update revision: %+v", r)
+	}
+	if r := history.Data[2]; r.Action != "delete" || r.After != nil || r.Before == nil {
+		t.Errorf("Unexpected delete revision: %+v", r)
+	}
+
+	rr = doRequest(h.GetBookRevisionHandler, "GET", "/books/"+id+"/history/1", nil, "", map[string]string{"id": id, "rev": "1"})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"action":"create"`) {
+		t.Errorf("Revision 1: expected create revision, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doRequest(h.DiffBookRevisionsHandler, "GET", "/books/"+id+"/history/diff?from=1&to=2", nil, "", map[string]string{"id": id})
+	var diff struct {
+		Data struct {
+			From    int                 `json:"from"`
+			To      int                 `json:"to"`
+			Changes []model.FieldChange `json:"changes"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&diff)
+	if rr.Code != http.StatusOK || len(diff.Data.Changes) != 2 || diff.Data.Changes[1].Field != "published_year" {
+		t.Errorf("Diff 1..2: unexpected response %d %+v", rr.Code, diff.Data)
+	}
+
+	// Tanpa parameter: revision sebelum terakhir dibanding revision terakhir (hapus).
+	rr = doRequest(h.DiffBookRevisionsHandler, "GET", "/books/"+id+"/history/diff", nil, "", map[string]string{"id": id})
+	json.NewDecoder(rr.Body).Decode(&diff)
+	if rr.Code != http.StatusOK || diff.Data.From != 2 || diff.Data.To != 3 || len(diff.Data.Changes) != 3 {
+		t.Errorf("Default diff: unexpected response %d %+v", rr.Code, diff.Data)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		target     string
+		params     map[string]string
+		wantStatus int
+	}{
+		{"history unknown book", h.GetBookHistoryHandler, "/books/99/history", map[string]string{"id": "99"}, http.StatusNotFound},
+		{"history invalid id", h.GetBookHistoryHandler, "/books/x/history", map[string]string{"id": "x"}, http.StatusBadRequest},
+		{"revision missing", h.GetBookRevisionHandler, "/books/1/history/9", map[string]string{"id": id, "rev": "9"}, http.StatusNotFound},
+		{"revision invalid", h.GetBookRevisionHandler, "/books/1/history/x", map[string]string{"id": id, "rev": "x"}, http.StatusBadRequest},
+		{"diff out of range", h.DiffBookRevisionsHandler, "/books/1/history/diff?from=1&to=7", map[string]string{"id": id}, http.StatusNotFound},
+		{"diff invalid from", h.DiffBookRevisionsHandler, "/books/1/history/diff?from=first", map[string]string{"id": id}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := doRequest(tt.handler, "GET", tt.target, nil, "", tt.params); rr.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeleteBookHandler_TrashAndRestore(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Go", Author: "Riki", PublishedYear: 2024})
+	id := strconv.Itoa(added.ID)
+
+	if rr := doRequest(h.DeleteBookHandler, "DELETE", "/books/"+id, nil, "", map[string]string{"id": id}); rr.Code != http.StatusOK {
+		t.Fatalf("Delete: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doRequest(h.GetBookHandler, "GET", "/books/"+id, nil, "", map[string]string{"id": id}); rr.Code != http.StatusNotFound {
+		t.Errorf("Get trashed: expected 404, got %d", rr.Code)
+	}
+
+	rr := doRequest(h.ListTrashHandler, "GET", "/books/trash", nil, "", nil)
+	var trash struct {
+		Data []model.TrashedBook `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+	json.NewDecoder(rr.Body).Decode(&trash)
+	if rr.Code != http.StatusOK || trash.Meta.Total != 1 || trash.Data[0].ID != added.ID || trash.Data[0].DeletedAt.IsZero() {
+		t.Fatalf("Trash: unexpected response %d %+v", rr.Code, trash)
+	}
+
+	rr = doRequest(h.RestoreBookHandler, "POST", "/books/"+id+"/restore", nil, "", map[string]string{"id": id})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Restore: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if etag := rr.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("Restore: expected ETag \"2\", got %q", etag)
+	}
+	if rr := doRequest(h.GetBookHandler, "GET", "/books/"+id, nil, "", map[string]string{"id": id}); rr.Code != http.StatusOK {
+		t.Errorf("Get restored: expected 200, got %d", rr.Code)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		id         string
+		wantStatus int
+	}{
+		{"restore active book", h.RestoreBookHandler, id, http.StatusNotFound},
+		{"restore unknown book", h.RestoreBookHandler, "99", http.StatusNotFound},
+		{"restore invalid id", h.RestoreBookHandler, "x", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := doRequest(tt.handler, "POST", "/books/"+tt.id+"/restore", nil, "", map[string]string{"id": tt.id}); rr.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeleteBookHandler_Hard(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store, handler.WithAdminToken("s3cret"))
+	active, _ := store.AddBook(context.Background(), model.Book{Title: "Active", Author: "Riki", PublishedYear: 2024})
+	trashed, _ := store.AddBook(context.Background(), model.Book{Title: "Trashed", Author: "Riki", PublishedYear: 2024})
+	store.DeleteBook(context.Background(), trashed.ID, 0)
+	id := strconv.Itoa(active.ID)
+
+	tests := []struct {
+		name       string
+		handler    handler.BookHandler
+		query      string
+		auth       string
+		wantStatus int
+	}{
+		{"invalid hard", h, "?hard=maybe", "Bearer s3cret", http.StatusBadRequest},
+		{"missing token", h, "?hard=true", "", http.StatusUnauthorized},
+		{"wrong token", h, "?hard=true", "Bearer nope", http.StatusForbidden},
+		{"wrong scheme", h, "?hard=true", "Basic s3cret", http.StatusForbidden},
+		{"disabled without admin token", handler.NewBookHandler(store), "?hard=true", "Bearer s3cret", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := doRequest(tt.handler.DeleteBookHandler, "DELETE", "/books/"+id+tt.query, map[string]string{"Authorization": tt.auth}, "", map[string]string{"id": id})
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected WWW-Authenticate header on 401")
+			}
+		})
+	}
+	if _, err := store.GetBookByID(context.Background(), active.ID); err != nil {
+		t.Fatalf("Expected rejected hard deletes to leave the book, got %v", err)
+	}
+
+	for _, b := range []model.Book{active, trashed} {
+		bid := strconv.Itoa(b.ID)
+		if rr := doRequest(h.DeleteBookHandler, "DELETE", "/books/"+bid+"?hard=true", map[string]string{"Authorization": "Bearer s3cret"}, "", map[string]string{"id": bid}); rr.Code != http.StatusOK {
+			t.Errorf("Hard delete %d: expected 200, got %d: %s", b.ID, rr.Code, rr.Body.String())
+		}
+	}
+	if trash, _ := store.ListTrash(context.Background()); len(trash) != 0 {
+		t.Errorf("Expected empty trash after hard delete, got %+v", trash)
+	}
+	if _, err := store.GetBookByID(context.Background(), active.ID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("Expected book to be purged, got %v", err)
+	}
+}
+
+// readSSEEvent membaca satu event SSE (sampai baris kosong) dan mengembalikan field-nya.
+func readSSEEvent(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+	ev := make(map[string]string)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Read SSE: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(ev) > 0 {
+				return ev
+			}
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ": "); ok && k != "" {
+			ev[k] = v
+		}
+	}
+}
+
+func TestBookEventsHandler_SSE(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	srv := httptest.NewServer(http.HandlerFunc(h.BookEventsHandler))
+	defer srv.Close()
+
+	added, _ := store.AddBook(context.Background(), model.Book{Title: "Go", Author: "Riki", PublishedYear: 2024})
+	store.AddBook(context.Background(), model.Book{Title: "Rust", Author: "Budi", PublishedYear: 2023})
+
+	// Resume dari event pertama dengan filter penulis: hanya event buku Riki yang dikirim.
+	req, _ := http.NewRequest("GET", srv.URL+"?author=riki", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected 200 event stream, got %d %q", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	body := bufio.NewReader(res.Body)
+	if ev := readSSEEvent(t, body); ev["retry"] == "" {
+		t.Errorf("Expected retry hint first, got %v", ev)
+	}
+	store.UpdateBook(context.Background(), added.ID, model.Book{Title: "Go 2", Author: "Riki", PublishedYear: 2024})
+
+	ev := readSSEEvent(t, body)
+	var change model.ChangeEvent
+	json.Unmarshal([]byte(ev["data"]), &change)
+	wantID := model.FormatEventID(store.(model.ChangeNotifier).Changes().Epoch(), 3)
+	if ev["id"] != wantID || change.ID != wantID || ev["event"] != model.ChangeUpdated || change.Book == nil || change.Book.Title != "Go 2" {
+		t.Errorf("Expected updated event with seq 3, got %v", ev)
+	}
+}
+
+func TestBookEventsHandler_ResetAndErrors(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	srv := httptest.NewServer(http.HandlerFunc(h.BookEventsHandler))
+	defer srv.Close()
+
+	store.AddBook(context.Background(), model.Book{Title: "Go", Author: "Riki", PublishedYear: 2024})
+
+	// ID dari sebelum server dijalankan ulang (epoch lain, atau angka saja) dan seq yang
+	// belum pernah ada harus menghasilkan reset, bukan melanjutkan dari posisi yang salah.
+	wantID := model.FormatEventID(store.(model.ChangeNotifier).Changes().Epoch(), 1)
+	for _, lastID := range []string{"0123456789abcdef-1", "1", model.FormatEventID(store.(model.ChangeNotifier).Changes().Epoch(), 42)} {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		req.Header.Set("Last-Event-ID", lastID)
+		ctx, cancel := context.WithCancel(context.Background())
+		res, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		body := bufio.NewReader(res.Body)
+		readSSEEvent(t, body)
+		if ev := readSSEEvent(t, body); ev["event"] != "reset" || ev["id"] != wantID {
+			t.Errorf("Last-Event-ID %s: expected reset event, got %v", lastID, ev)
+		}
+		cancel()
+		res.Body.Close()
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		path       string
+		wantStatus int
+	}{
+		{"invalid last event id", h.BookEventsHandler, "/books/events?last_event_id=abc", http.StatusBadRequest},
+		{"invalid id filter", h.BookEventsHandler, "/books/events?id=1,x", http.StatusBadRequest},
+		{"websocket without upgrade", h.BookEventsWebSocketHandler, "/books/events/ws", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.handler(rr, httptest.NewRequest("GET", tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestBookEventsWebSocketHandler(t *testing.T) {
+	store := model.NewBookStore()
+	h := handler.NewBookHandler(store)
+	srv := httptest.NewServer(http.HandlerFunc(h.BookEventsWebSocketHandler))
+	defer srv.Close()
+
+	first, _ := store.AddBook(context.Background(), model.Book{Title: "Go", Author: "Riki", PublishedYear: 2024})
+	second, _ := store.AddBook(context.Background(), model.Book{Title: "Rust", Author: "Budi", PublishedYear: 2023})
+
+	lastID := model.FormatEventID(store.(model.ChangeNotifier).Changes().Epoch(), 1)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "?last_event_id=" + lastID + "&id=" + strconv.Itoa(second.ID)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	store.DeleteBook(context.Background(), first.ID, 0)
+	store.DeleteBook(context.Background(), second.ID, 0)
+
+	for _, want := range []model.ChangeEvent{
+		{Seq: 2, Type: model.ChangeCreated},
+		{Seq: 4, Type: model.ChangeDeleted},
+	} {
+		var ev model.ChangeEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if ev.Seq != want.Seq || ev.Type != want.Type || ev.BookID != second.ID {
+			t.Errorf("Expected %s event with seq %d, got %+v", want.Type, want.Seq, ev)
+		}
+	}
+}
+
+func TestBookEventsHandlers_Shutdown(t *testing.T) {
+	store := model.NewBookStore()
+	shuttingDown := make(chan struct{})
+	h := handler.NewBookHandler(store, handler.WithShutdown(shuttingDown))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/books/events", h.BookEventsHandler)
+	mux.HandleFunc("/books/events/ws", h.BookEventsWebSocketHandler)
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.RegisterOnShutdown(func() { close(shuttingDown) })
+	srv.Start()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/books/events", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer res.Body.Close()
+	readSSEEvent(t, bufio.NewReader(res.Body))
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/books/events/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// Shutdown tidak membatalkan context request; stream harus berakhir karena WithShutdown.
+	if err := srv.Config.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown: expected SSE stream to end, got %v", err)
+	}
+	if _, err := io.ReadAll(res.Body); err != nil {
+		t.Errorf("SSE: expected clean end of stream, got %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var ev model.ChangeEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+				t.Errorf("WebSocket: expected close 1001, got %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	hooks := model.NewWebhookStore()
+	h := handler.NewBookHandler(model.NewBookStore(), handler.WithWebhookStore(hooks))
+
+	rr := doRequest(h.CreateWebhookHandler, "POST", "/webhooks", nil, `{"url":"https://example.com/hook","events":["created"]}`, nil)
+	var createResp struct {
+		Data model.Webhook `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&createResp)
+	created := createResp.Data
+	if rr.Code != http.StatusCreated || created.ID == 0 || created.Secret == "" || !created.Active {
+		t.Fatalf("Create: expected 201 with secret, got %d %+v", rr.Code, created)
+	}
+	id := strconv.Itoa(created.ID)
+
+	rr = doRequest(h.GetWebhookHandler, "GET", "/webhooks/"+id, nil, "", map[string]string{"id": id})
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), created.Secret) {
+		t.Errorf("Get: expected 200 without secret, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doRequest(h.UpdateWebhookHandler, "PUT", "/webhooks/"+id, nil, `{"url":"https://example.com/v2","active":false}`, map[string]string{"id": id})
+	var updateResp struct {
+		Data model.Webhook `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&updateResp)
+	updated := updateResp.Data
+	if rr.Code != http.StatusOK || updated.Active || updated.Secret != "" || updated.URL != "https://example.com/v2" {
+		t.Errorf("Update: unexpected response %d %+v", rr.Code, updated)
+	}
+	if stored, _ := hooks.GetWebhook(context.Background(), created.ID); stored.Secret != created.Secret {
+		t.Errorf("Update: expected secret to be kept")
+	}
+
+	hooks.RecordDelivery(context.Background(), model.WebhookDelivery{WebhookID: created.ID, Attempt: 1, StatusCode: 500})
+	rr = doRequest(h.ListWebhookDeliveriesHandler, "GET", "/webhooks/"+id+"/deliveries", nil, "", map[string]string{"id": id})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status_code":500`) {
+		t.Errorf("Deliveries: unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	rr = doRequest(h.ListWebhookDeadLettersHandler, "GET", "/webhooks/"+id+"/dead-letters", nil, "", map[string]string{"id": id})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"total":0`) {
+		t.Errorf("Dead letters: unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := doRequest(h.DeleteWebhookHandler, "DELETE", "/webhooks/"+id, nil, "", map[string]string{"id": id}); rr.Code != http.StatusNoContent {
+		t.Errorf("Delete: expected 204, got %d", rr.Code)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		method     string
+		id         string
+		body       string
+		wantStatus int
+	}{
+		{"create invalid url", h.CreateWebhookHandler, "POST", "", `{"url":"ftp://x"}`, http.StatusBadRequest},
+		{"create unknown field", h.CreateWebhookHandler, "POST", "", `{"url":"https://x.test","retries":3}`, http.StatusBadRequest},
+		{"get deleted", h.GetWebhookHandler, "GET", id, "", http.StatusNotFound},
+		{"get invalid id", h.GetWebhookHandler, "GET", "x", "", http.StatusBadRequest},
+		{"update unknown", h.UpdateWebhookHandler, "PUT", "99", `{"url":"https://x.test"}`, http.StatusNotFound},
+		{"deliveries unknown", h.ListWebhookDeliveriesHandler, "GET", "99", "", http.StatusNotFound},
+		{"not configured", handler.NewBookHandler(model.NewBookStore()).ListWebhooksHandler, "GET", "", "", http.StatusNotImplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := doRequest(tt.handler, tt.method, "/webhooks", nil, tt.body, map[string]string{"id": tt.id}); rr.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+// graphQLResponse adalah response POST /graphql untuk test.
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// postGraphQL mengirim query GraphQL dengan variables opsional ke GraphQLHandler.
+func postGraphQL(t *testing.T, h handler.BookHandler, query string, variables map[string]interface{}) (int, graphQLResponse) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	rr := doRequest(h.GraphQLHandler, http.MethodPost, "/graphql", nil, string(body), nil)
+
+	var resp graphQLResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode GraphQL response: %v", err)
+	}
+	return rr.Code, resp
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	h := handler.NewBookHandler(model.NewBookStore())
+
+	status, resp := postGraphQL(t, h, `mutation($input: BookInput!) { createBook(input: $input) { id title publishedYear version } }`,
+		map[string]interface{}{"input": map[string]interface{}{"title": "Go", "author": "Riki", "publishedYear": 2024}})
+	if status != http.StatusOK || len(resp.Errors) > 0 || !strings.Contains(string(resp.Data["createBook"]), `"publishedYear":2024`) {
+		t.Fatalf("createBook: unexpected response %d %+v", status, resp)
+	}
+	postGraphQL(t, h, `mutation { createBook(input: {title: "Rust", author: "Ana", publishedYear: 2020}) { id } }`, nil)
+
+	status, resp = postGraphQL(t, h, `{
+		books(filter: [{field: PUBLISHED_YEAR, op: GTE, value: "2021"}], sort: [{field: TITLE, desc: true}], page: {limit: 1}) {
+			total limit items { title author }
+		}
+		book(id: 2) { author isbn }
+	}`, nil)
+	if status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("books: unexpected response %d %+v", status, resp)
+	}
+	var books struct {
+		Total, Limit int
+		Items        []model.Book
+	}
+	json.Unmarshal(resp.Data["books"], &books)
+	if books.Total != 1 || books.Limit != 1 || len(books.Items) != 1 || books.Items[0].Title != "Go" || books.Items[0].Author != "Riki" {
+		t.Errorf("books: unexpected data %s", resp.Data["books"])
+	}
+	if got := string(resp.Data["book"]); got != `{"author":"Ana","isbn":null}` {
+		t.Errorf("book: unexpected data %s", got)
+	}
+
+	status, resp = postGraphQL(t, h, `mutation { updateBook(id: 1, version: 1, input: {title: "Go 2", author: "Riki", publishedYear: 2024}) { version } }`, nil)
+	if status != http.StatusOK || string(resp.Data["updateBook"]) != `{"version":2}` {
+		t.Errorf("updateBook: unexpected response %d %+v", status, resp)
+	}
+	status, resp = postGraphQL(t, h, `mutation { deleteBook(id: 2) }`, nil)
+	if status != http.StatusOK || string(resp.Data["deleteBook"]) != "true" {
+		t.Errorf("deleteBook: unexpected response %d %+v", status, resp)
+	}
+}
+
+func TestGraphQLHandler_Errors(t *testing.T) {
+	store := model.NewBookStore()
+	store.AddBook(context.Background(), model.Book{Title: "Go", Author: "Riki", PublishedYear: 2024})
+	h := handler.NewBookHandler(store, handler.WithGraphQLLimits(3, 50))
+
+	tests := []struct {
+		name       string
+		query      string
+		variables  map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{"not found", `{ book(id: 99) { title } }`, nil, http.StatusOK, "NOT_FOUND"},
+		{"validation", `mutation { createBook(input: {title: "", author: "Riki", publishedYear: 2024}) { id } }`, nil, http.StatusOK, "BAD_REQUEST"},
+		{"version mismatch", `mutation { deleteBook(id: 1, version: 7) }`, nil, http.StatusOK, "PRECONDITION_FAILED"},
+		{"invalid filter", `{ books(filter: [{field: ID, op: CONTAINS, value: "1"}]) { total } }`, nil, http.StatusOK, "BAD_REQUEST"},
+		{"invalid query", `{ books { nope } }`, nil, http.StatusBadRequest, ""},
+		{"too complex", `{ books { items { title } } }`, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"too complex via variables", `query($page: PageInput) { books(page: $page) { ...Titles } } fragment Titles on BookPage { items { id title } }`,
+			map[string]interface{}{"page": map[string]interface{}{"limit": 30}}, http.StatusBadRequest, "BAD_REQUEST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := postGraphQL(t, h, tt.query, tt.variables)
+			if status != tt.wantStatus || len(resp.Errors) == 0 {
+				t.Fatalf("Expected %d with errors, got %d %+v", tt.wantStatus, status, resp)
+			}
+			if tt.wantCode != "" && resp.Errors[0].Extensions["code"] != tt.wantCode {
+				t.Errorf("Expected code %s, got %+v", tt.wantCode, resp.Errors[0])
+			}
+		})
+	}
+
+	status, resp := postGraphQL(t, h, `{ books(page: {limit: 10}) { total items { title } } book(id: 1) { title } __typename }`, nil)
+	if status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Errorf("Expected query within limits to succeed, got %d %+v", status, resp)
+	}
+
+	shallow := handler.NewBookHandler(store, handler.WithGraphQLLimits(2, 0))
+	status, resp = postGraphQL(t, shallow, `{ books(page: {limit: 1}) { items { title } } }`, nil)
+	if status != http.StatusBadRequest || len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, "depth 3") {
+		t.Errorf("Expected query deeper than the limit to be rejected, got %d %+v", status, resp)
+	}
+}
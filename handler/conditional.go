@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"book-api/model"
+)
+
+// collectionETag mengembalikan ETag untuk satu response GET /books. Nilainya bergantung
+// pada Seq koleksi, parameter query, dan representasi response (versi API dan format
+// Accept), karena filter, halaman, atau format berbeda menghasilkan isi yang berbeda.
+func collectionETag(r *http.Request, stamp model.CollectionStamp) string {
+	h := fnv.New64a()
+	h.Write([]byte(r.URL.Query().Encode()))
+	h.Write([]byte{0})
+	h.Write([]byte(representationTag(r)))
+	return fmt.Sprintf(`"c%d-%x"`, stamp.Seq, h.Sum64())
+}
+
+// etagMatchesWeak mengecek apakah salah satu ETag di header If-None-Match cocok
+// dengan etag menggunakan perbandingan lemah (awalan W/ diabaikan).
+func etagMatchesWeak(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range parseETagList(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNotModified menulis header ETag dan Last-Modified, lalu menjawab 304 Not Modified
+// jika If-None-Match (atau, bila tidak ada, If-Modified-Since) menunjukkan client
+// sudah memiliki versi terbaru.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mungkin membawa header kondisional.
+//   - etag: ETag representasi saat ini.
+//   - modified: waktu perubahan terakhir; diabaikan jika zero.
+//
+// Returns:
+//   - true jika response 304 sudah ditulis dan handler harus berhenti
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etagMatchesWeak(inm, etag) {
+			// ETag bergantung pada format Accept; cache harus tahu 304 ini juga begitu.
+			w.Header().Add("Vary", "Accept")
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err == nil && !modified.Truncate(time.Second).After(since) {
+			w.Header().Add("Vary", "Accept")
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
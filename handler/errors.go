@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"book-api/model"
+	"book-api/utils"
+)
+
+// statusClientClosedRequest adalah kode non-standar (konvensi nginx) untuk request
+// yang dibatalkan client sebelum server selesai memprosesnya.
+const statusClientClosedRequest = 499
+
+// problemTypeValidation adalah type problem+json untuk data buku yang gagal validasi;
+// rincian pelanggaran per field ada di extension "details".
+const problemTypeValidation = utils.ProblemTypeBase + "validation-error"
+
+// writeStoreError memetakan error dari BookStore ke status HTTP yang sesuai.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: request yang sedang diproses, untuk memilih format error dan nama field
+//     pada rincian validasi sesuai versi API.
+//   - err: error yang dikembalikan oleh BookStore.
+func writeStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *model.ValidationError
+	if errors.As(err, &verr) {
+		p := utils.NewProblem(r, http.StatusBadRequest, model.ErrValidation.Error())
+		p.Type = problemTypeValidation
+		p.Title = "Validation Failed"
+		p.Extensions = map[string]interface{}{"details": mapFieldErrors(mapperFor(r), verr.Fields)}
+		utils.WriteErrorResponse(w, r, p)
+		return
+	}
+	status, msg := storeErrorStatus(err)
+	utils.WriteError(w, r, status, msg)
+}
+
+// storeErrorStatus mengembalikan status HTTP dan pesan untuk client dari error BookStore.
+// Error backend dicatat ke log dan pesannya disamarkan.
+func storeErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, model.ErrValidation):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, model.ErrConflict):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, model.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, err.Error()
+	case errors.Is(err, model.ErrUnavailable):
+		log.Printf("book store unavailable: %v", err)
+		return http.StatusServiceUnavailable, "service unavailable"
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest, "request canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "request timed out"
+	default:
+		log.Printf("book store error: %v", err)
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
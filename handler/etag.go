@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"book-api/model"
+	"book-api/utils"
+)
+
+// bookETag mengembalikan strong ETag untuk satu buku dalam representasi response r:
+// versi buku, diikuti penanda representasi jika bukan v1 JSON (contoh "3-v2-csv").
+// Representasi berbeda memiliki byte berbeda sehingga tidak boleh berbagi strong ETag.
+func bookETag(r *http.Request, b model.Book) string {
+	tag := strconv.Itoa(b.Version)
+	if rep := representationTag(r); rep != "" {
+		tag += "-" + rep
+	}
+	return `"` + tag + `"`
+}
+
+// representationTag mengembalikan penanda representasi response r, yaitu versi API dan
+// format hasil negosiasi Accept (contoh "v2-yaml"); kosong untuk v1 JSON.
+func representationTag(r *http.Request) string {
+	var parts []string
+	if v := apiVersionFrom(r.Context()); v != APIVersion1 {
+		parts = append(parts, strings.TrimPrefix(v.Prefix(), "/"))
+	}
+	if f, ok := utils.NegotiateFormat(r); ok && f.MediaType != utils.MediaTypeJSON {
+		_, subtype, _ := strings.Cut(f.MediaType, "/")
+		parts = append(parts, subtype)
+	}
+	return strings.Join(parts, "-")
+}
+
+// parseETagList memecah nilai header If-Match / If-None-Match menjadi daftar entity tag.
+func parseETagList(header string) []string {
+	var tags []string
+	for _, part := range strings.Split(header, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			tags = append(tags, part)
+		}
+	}
+	return tags
+}
+
+// parseVersionETag membaca versi dari strong ETag seperti "3" atau "3-v2-csv"; penanda
+// representasi diabaikan karena If-Match memeriksa versi buku, bukan format response.
+// ETag lemah (W/"3") tidak pernah cocok pada If-Match karena memerlukan perbandingan kuat.
+func parseVersionETag(tag string) (int, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+	v, err := strconv.Atoi(version)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// resolveIfMatch menerjemahkan header If-Match menjadi versi yang diharapkan untuk store.
+// Satu ETag langsung dipakai sebagai versi sehingga pengecekannya atomik di dalam store.
+// Untuk daftar ETag, versi saat ini diambil dan dipilih jika ada di daftar; store tetap
+// memastikan versi itu belum berubah saat update dijalankan.
+//
+// Returns:
+//   - versi yang diharapkan (0 untuk "*", artinya buku cukup ada)
+//   - model.ErrPreconditionFailed jika tidak ada ETag yang cocok
+func (bh *bookHandler) resolveIfMatch(ctx context.Context, id int, header string) (int, error) {
+	tags := parseETagList(header)
+
+	if len(tags) == 1 && tags[0] == "*" {
+		if _, err := bh.service.GetBookByID(ctx, id); err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				return 0, fmt.Errorf("%w: book does not exist", model.ErrPreconditionFailed)
+			}
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	var versions []int
+	for _, tag := range tags {
+		if v, ok := parseVersionETag(tag); ok {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return 0, fmt.Errorf("%w: If-Match does not contain a current entity tag", model.ErrPreconditionFailed)
+	}
+	if len(versions) == 1 {
+		return versions[0], nil
+	}
+
+	current, err := bh.service.GetBookByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range versions {
+		if v == current.Version {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: current version is %d", model.ErrPreconditionFailed, current.Version)
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"book-api/model"
+	"book-api/utils"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// eventsHeartbeat adalah jeda komentar SSE / ping WebSocket agar proxy tidak
+	// menutup koneksi yang sedang diam.
+	eventsHeartbeat = 15 * time.Second
+	// eventsWriteTimeout membatasi waktu menulis satu pesan WebSocket ke client.
+	eventsWriteTimeout = 10 * time.Second
+	// eventsRetryMillis adalah jeda reconnect yang disarankan ke EventSource.
+	eventsRetryMillis = 3000
+)
+
+// eventTypeReset dikirim ketika event setelah Last-Event-ID sudah tidak tersedia atau
+// berasal dari sebelum server dijalankan ulang, sehingga client harus memuat ulang data
+// dari GET /books.
+const eventTypeReset = "reset"
+
+// changeReset adalah isi event reset; ID adalah posisi untuk melanjutkan langganan.
+type changeReset struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Seq  uint64 `json:"seq"`
+}
+
+var wsUpgrader = websocket.Upgrader{}
+
+// BookEventsHandler menangani permintaan GET /books/events untuk menerima perubahan buku
+// secara real-time lewat Server-Sent Events. Setiap event berisi ID ("<epoch>-<seq>"),
+// jenis perubahan, dan kondisi buku terbaru. Client yang tersambung ulang dengan header
+// Last-Event-ID menerima event yang terlewat dari buffer; jika sudah tidak tersedia,
+// event "reset" dikirim lebih dulu.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis stream ke client.
+//   - r: *http.Request dengan filter query "author" dan "id" (boleh berulang) serta
+//     header Last-Event-ID atau query "last_event_id" (opsional).
+//
+// Response:
+//   - 200 OK berisi stream text/event-stream sampai client menutup koneksi
+//   - 400 Bad Request jika filter atau Last-Event-ID tidak valid
+//   - 501 Not Implemented jika store tidak menerbitkan event
+func (bh *bookHandler) BookEventsHandler(w http.ResponseWriter, r *http.Request) {
+	feed, filter, epoch, lastSeq, ok := bh.changeFeedRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	if err := rc.Flush(); err != nil {
+		w.Header().Del("Content-Type")
+		utils.WriteError(w, r, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	sub, backlog, resumed := feed.Subscribe(epoch, lastSeq, filter)
+	defer sub.Close()
+
+	m := mapperFor(r)
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetryMillis)
+	if !resumed {
+		writeSSE(w, sub.StartID(), eventTypeReset, newChangeReset(sub))
+	}
+	for _, ev := range backlog {
+		writeSSE(w, ev.ID, ev.Type, m.changeEvent(ev))
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-bh.shutdown:
+			// EventSource akan tersambung ulang (ke instance lain) dengan Last-Event-ID.
+			return
+		case ev, open := <-sub.Events():
+			if !open {
+				// Subscriber tertinggal; EventSource akan tersambung ulang dengan Last-Event-ID.
+				return
+			}
+			if err := writeSSE(w, ev.ID, ev.Type, m.changeEvent(ev)); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// writeSSE menulis satu event SSE dengan id, nama event, dan data JSON.
+func writeSSE(w io.Writer, id string, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event, payload)
+	return err
+}
+
+// newChangeReset membuat isi event reset untuk langganan sub.
+func newChangeReset(sub *model.Subscription) changeReset {
+	return changeReset{Type: eventTypeReset, ID: sub.StartID(), Seq: sub.StartSeq()}
+}
+
+// BookEventsWebSocketHandler menangani permintaan GET /books/events/ws untuk menerima
+// perubahan buku lewat WebSocket. Setiap pesan teks berisi satu event JSON dengan
+// field "id"; client melanjutkan langganan dengan query "last_event_id" berisi id
+// terakhir yang diterimanya. Jika event setelahnya sudah tidak tersedia atau berasal dari
+// sebelum server dijalankan ulang, pesan {"type":"reset"} dikirim lebih dulu.
+//
+// Params:
+//   - w: http.ResponseWriter untuk upgrade koneksi.
+//   - r: *http.Request dengan filter query "author" dan "id" serta "last_event_id" (opsional).
+//
+// Response:
+//   - 101 Switching Protocols lalu stream pesan sampai salah satu pihak menutup koneksi
+//   - 400 Bad Request jika filter atau last_event_id tidak valid, atau bukan request WebSocket
+//   - 501 Not Implemented jika store tidak menerbitkan event
+func (bh *bookHandler) BookEventsWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	feed, filter, epoch, lastSeq, ok := bh.changeFeedRequest(w, r)
+	if !ok {
+		return
+	}
+	if !websocket.IsWebSocketUpgrade(r) {
+		utils.WriteError(w, r, http.StatusBadRequest, "WebSocket upgrade required")
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrader sudah menulis response error.
+		return
+	}
+	defer conn.Close()
+
+	sub, backlog, resumed := feed.Subscribe(epoch, lastSeq, filter)
+	defer sub.Close()
+
+	// Pesan dari client diabaikan, tetapi tetap dibaca agar ping/close diproses.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	m := mapperFor(r)
+	send := func(v interface{}) error {
+		conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+		return conn.WriteJSON(v)
+	}
+	if !resumed {
+		if send(newChangeReset(sub)) != nil {
+			return
+		}
+	}
+	for _, ev := range backlog {
+		if send(m.changeEvent(ev)) != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case <-bh.shutdown:
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down; resume with last_event_id")
+			conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(eventsWriteTimeout))
+			return
+		case ev, open := <-sub.Events():
+			if !open {
+				msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber lagged; resume with last_event_id")
+				conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(eventsWriteTimeout))
+				return
+			}
+			if err := send(m.changeEvent(ev)); err != nil {
+				log.Printf("events websocket: %v", err)
+				return
+			}
+		case <-heartbeat.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventsWriteTimeout)) != nil {
+				return
+			}
+		}
+	}
+}
+
+// changeFeedRequest mengambil ChangeFeed store serta filter dan Last-Event-ID dari
+// request. Jika gagal, response error sudah ditulis dan ok bernilai false.
+func (bh *bookHandler) changeFeedRequest(w http.ResponseWriter, r *http.Request) (feed *model.ChangeFeed, filter model.ChangeFilter, epoch string, lastSeq uint64, ok bool) {
+	notifier, supported := bh.service.(model.ChangeNotifier)
+	if !supported {
+		utils.WriteError(w, r, http.StatusNotImplemented, "change events are not supported by this store")
+		return nil, filter, "", 0, false
+	}
+
+	filter, err := parseChangeFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return nil, filter, "", 0, false
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	if lastID != "" {
+		if epoch, lastSeq, err = model.ParseEventID(lastID); err != nil {
+			utils.WriteError(w, r, http.StatusBadRequest, "Last-Event-ID must be an event ID")
+			return nil, filter, "", 0, false
+		}
+	}
+	return notifier.Changes(), filter, epoch, lastSeq, true
+}
+
+// parseChangeFilter membaca filter "author" (berulang) dan "id" (berulang atau
+// dipisah koma) dari query.
+func parseChangeFilter(q url.Values) (model.ChangeFilter, error) {
+	var f model.ChangeFilter
+	for _, a := range q["author"] {
+		if a = strings.TrimSpace(a); a != "" {
+			f.Authors = append(f.Authors, a)
+		}
+	}
+	for _, v := range q["id"] {
+		for _, s := range strings.Split(v, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || id <= 0 {
+				return f, fmt.Errorf("invalid id filter %q", s)
+			}
+			f.IDs = append(f.IDs, id)
+		}
+	}
+	return f, nil
+}
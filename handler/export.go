@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"book-api/model"
+	"book-api/utils"
+)
+
+// Format file untuk export dan import.
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+
+	contentTypeCSV   = "text/csv"
+	contentTypeJSONL = "application/x-ndjson"
+)
+
+// csvColumns adalah urutan kolom CSV hasil export dengan nama field model; header
+// memakai nama field versi API request (contoh author.name pada v2). Import menerima
+// kolom yang sama; id, version, dan updated_at diabaikan karena dikelola store.
+var csvColumns = []string{"id", "title", "author", "published_year", "isbn", "version", "updated_at"}
+
+// ExportBooksHandler menangani permintaan GET /books/export?format=csv|jsonl untuk
+// mengunduh seluruh katalog. Buku dikirim satu per satu dari store sehingga koleksi
+// tidak pernah dimuat seluruhnya ke memori. Jika store gagal setelah sebagian data
+// terkirim, koneksi diputus agar client tidak menganggap file terpotong sebagai lengkap.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request dengan parameter query "format" (default jsonl).
+//
+// Response:
+//   - 200 OK berisi file CSV (text/csv) atau JSON Lines (application/x-ndjson)
+//   - 400 Bad Request jika format tidak didukung
+//   - 5xx jika store gagal sebelum data pertama terkirim
+func (bh *bookHandler) ExportBooksHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatJSONL
+	}
+
+	var contentType string
+	var newEncoder func(io.Writer, bookMapper) bookEncoder
+	switch format {
+	case formatCSV:
+		contentType, newEncoder = contentTypeCSV+"; charset=utf-8", newCSVBookEncoder
+	case formatJSONL:
+		contentType, newEncoder = contentTypeJSONL, newJSONLBookEncoder
+	default:
+		utils.WriteError(w, r, http.StatusBadRequest, "format must be csv or jsonl")
+		return
+	}
+
+	cw := &utils.CommitWriter{W: w}
+	bw := bufio.NewWriter(cw)
+	enc := newEncoder(bw, mapperFor(r))
+	header := func() {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="books.`+format+`"`)
+	}
+	cw.OnCommit = header
+
+	err := enc.begin()
+	if err == nil {
+		err = bh.service.EachBook(r.Context(), enc.encode)
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		if !cw.Committed {
+			// Katalog kecil (atau kosong) belum mengirim apa pun; tulis header sekarang.
+			header()
+			w.WriteHeader(http.StatusOK)
+		}
+		return
+	}
+
+	if !cw.Committed {
+		writeStoreError(w, r, err)
+		return
+	}
+	log.Printf("export aborted after partial response: %v", err)
+	panic(http.ErrAbortHandler)
+}
+
+// bookEncoder menulis buku ke file export dengan format tertentu.
+type bookEncoder interface {
+	begin() error
+	encode(book model.Book) error
+}
+
+type csvBookEncoder struct {
+	w *csv.Writer
+	m bookMapper
+}
+
+func newCSVBookEncoder(w io.Writer, m bookMapper) bookEncoder {
+	return &csvBookEncoder{w: csv.NewWriter(w), m: m}
+}
+
+func (e *csvBookEncoder) begin() error {
+	return e.w.Write(csvHeader(e.m))
+}
+
+// csvHeader mengembalikan baris header CSV dengan nama field representasi m.
+func csvHeader(m bookMapper) []string {
+	header := make([]string, len(csvColumns))
+	for i, col := range csvColumns {
+		header[i] = m.field(col)
+	}
+	return header
+}
+
+func (e *csvBookEncoder) encode(b model.Book) error {
+	e.w.Write([]string{
+		strconv.Itoa(b.ID),
+		utils.EscapeCSVCell(b.Title),
+		utils.EscapeCSVCell(b.Author),
+		strconv.Itoa(b.PublishedYear),
+		utils.EscapeCSVCell(b.ISBN),
+		strconv.Itoa(b.Version),
+		b.UpdatedAt.Format(time.RFC3339Nano),
+	})
+	// csv.Writer menyangga sendiri; Flush meneruskannya ke bufio.Writer di bawahnya.
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonlBookEncoder struct {
+	enc *json.Encoder
+	m   bookMapper
+}
+
+func newJSONLBookEncoder(w io.Writer, m bookMapper) bookEncoder {
+	return &jsonlBookEncoder{enc: json.NewEncoder(w), m: m}
+}
+
+func (e *jsonlBookEncoder) begin() error {
+	return nil
+}
+
+func (e *jsonlBookEncoder) encode(b model.Book) error {
+	return e.enc.Encode(e.m.book(b))
+}
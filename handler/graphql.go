@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"book-api/model"
+	"book-api/utils"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+const (
+	// DefaultGraphQLMaxDepth adalah kedalaman field maksimum sebuah query GraphQL.
+	DefaultGraphQLMaxDepth = 10
+	// DefaultGraphQLMaxComplexity adalah kompleksitas maksimum sebuah query GraphQL
+	// (lihat checkGraphQLLimits untuk cara menghitungnya).
+	DefaultGraphQLMaxComplexity = 10000
+)
+
+// graphQLRequest adalah body POST /graphql.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	// Extensions diterima agar client GraphQL umum tidak ditolak, tetapi tidak dipakai.
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+// GraphQLHandler menangani permintaan POST /graphql. Query dan mutation di-resolve ke
+// BookStore yang sama dengan endpoint REST, dan error store dipetakan ke status yang
+// sama (dikirim di "extensions" setiap error).
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request dengan body {"query", "operationName", "variables"}.
+//
+// Response:
+//   - 200 OK berisi {"data", "errors"}; error resolver tidak mengubah status
+//   - 400 Bad Request berisi {"errors"} jika query gagal di-parse, tidak valid terhadap
+//     schema, atau melebihi batas kedalaman/kompleksitas
+//   - 413 Request Entity Too Large jika body melebihi batas ukuran
+//   - 415 Unsupported Media Type jika Content-Type bukan application/json
+func (bh *bookHandler) GraphQLHandler(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if err := utils.DecodeJSON(w, r, &req, bh.maxBodyBytes); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		writeGraphQL(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if vr := graphql.ValidateDocument(&bh.graphqlSchema, doc, nil); !vr.IsValid {
+		writeGraphQL(w, http.StatusBadRequest, &graphql.Result{Errors: vr.Errors})
+		return
+	}
+	if err := bh.checkGraphQLLimits(doc, req.Variables); err != nil {
+		// Dibungkus gqlerrors.Error agar extensions dari graphQLError ikut dikirim.
+		gerr := gqlerrors.NewError(err.Error(), nil, "", nil, nil, err)
+		writeGraphQL(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(gerr)})
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        bh.graphqlSchema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       r.Context(),
+	})
+	writeGraphQL(w, http.StatusOK, result)
+}
+
+// writeGraphQL mengirim hasil GraphQL apa adanya (tanpa envelope APIResponse), sesuai
+// format response GraphQL.
+func writeGraphQL(w http.ResponseWriter, status int, result *graphql.Result) {
+	buf, err := json.Marshal(result)
+	if err != nil {
+		writeGraphQL(w, http.StatusInternalServerError, &graphql.Result{Errors: gqlerrors.FormatErrors(errors.New("failed to encode response"))})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf)
+}
+
+// graphQLError adalah error resolver GraphQL dengan status HTTP yang sama seperti
+// endpoint REST untuk error yang sama. Status, kode, dan rincian validasi dikirim di
+// "extensions".
+type graphQLError struct {
+	status  int
+	message string
+	details []model.FieldError
+}
+
+// newGraphQLError memetakan error dari BookStore seperti writeStoreError.
+func newGraphQLError(err error) *graphQLError {
+	status, msg := storeErrorStatus(err)
+	gerr := &graphQLError{status: status, message: msg}
+	var verr *model.ValidationError
+	if errors.As(err, &verr) {
+		gerr.details = verr.Fields
+	}
+	return gerr
+}
+
+func (e *graphQLError) Error() string {
+	return e.message
+}
+
+// Extensions mengisi "extensions" pada error GraphQL, misalnya
+// {"code": "NOT_FOUND", "status": 404}.
+func (e *graphQLError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": graphQLErrorCode(e.status), "status": e.status}
+	if len(e.details) > 0 {
+		ext["details"] = e.details
+	}
+	return ext
+}
+
+// graphQLErrorCode membuat kode error dari status HTTP, contoh 412 menjadi "PRECONDITION_FAILED".
+func graphQLErrorCode(status int) string {
+	if status == statusClientClosedRequest {
+		return "REQUEST_CANCELED"
+	}
+	return strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+// checkGraphQLLimits menolak operasi yang terlalu dalam atau terlalu kompleks sebelum
+// dieksekusi. Kedalaman adalah tingkat field bersarang terdalam. Kompleksitas adalah
+// jumlah field, dengan field di dalam books.items dikalikan page.limit (default
+// model.DefaultQueryLimit). Field introspeksi (__schema, __type, __typename) tidak dihitung.
+func (bh *bookHandler) checkGraphQLLimits(doc *ast.Document, vars map[string]interface{}) error {
+	c := graphQLCost{fragments: make(map[string]*ast.FragmentDefinition), vars: vars}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			c.fragments[frag.Name.Value] = frag
+		}
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		depth, complexity := c.selectionSet(op.SelectionSet, 1, 1)
+		if depth > bh.graphqlMaxDepth {
+			return &graphQLError{status: http.StatusBadRequest, message: fmt.Sprintf("query depth %d exceeds the limit of %d", depth, bh.graphqlMaxDepth)}
+		}
+		if complexity > bh.graphqlMaxComplexity {
+			return &graphQLError{status: http.StatusBadRequest, message: fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, bh.graphqlMaxComplexity)}
+		}
+	}
+	return nil
+}
+
+// graphQLCost menghitung kedalaman dan kompleksitas dokumen yang sudah divalidasi
+// (sehingga fragment pasti ada dan tidak berputar).
+type graphQLCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	vars      map[string]interface{}
+}
+
+// selectionSet mengembalikan kedalaman terdalam dan kompleksitas ss, dengan depth
+// adalah tingkat field di ss dan items adalah jumlah buku pada field items di ss.
+func (c graphQLCost) selectionSet(ss *ast.SelectionSet, depth, items int) (maxDepth, complexity int) {
+	maxDepth = depth - 1
+	if ss == nil {
+		return maxDepth, 0
+	}
+	for _, sel := range ss.Selections {
+		var d, cost int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			d, cost = depth, 1
+			if sel.SelectionSet != nil {
+				childItems := 1
+				if sel.Name.Value == "books" {
+					childItems = c.pageLimit(sel)
+				}
+				childDepth, childCost := c.selectionSet(sel.SelectionSet, depth+1, childItems)
+				if sel.Name.Value == "items" {
+					childCost *= items
+				}
+				d, cost = max(d, childDepth), 1+childCost
+			}
+		case *ast.InlineFragment:
+			d, cost = c.selectionSet(sel.SelectionSet, depth, items)
+		case *ast.FragmentSpread:
+			if frag, ok := c.fragments[sel.Name.Value]; ok {
+				d, cost = c.selectionSet(frag.SelectionSet, depth, items)
+			}
+		}
+		maxDepth, complexity = max(maxDepth, d), complexity+cost
+	}
+	return maxDepth, complexity
+}
+
+// pageLimit mengembalikan jumlah buku yang mungkin dikembalikan field books, yaitu
+// page.limit (literal maupun dari variable) atau model.DefaultQueryLimit.
+func (c graphQLCost) pageLimit(f *ast.Field) int {
+	limit := model.DefaultQueryLimit
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "page" {
+			continue
+		}
+		switch page := arg.Value.(type) {
+		case *ast.ObjectValue:
+			for _, field := range page.Fields {
+				if field.Name.Value == "limit" {
+					limit = c.intValue(field.Value, limit)
+				}
+			}
+		case *ast.Variable:
+			if m, ok := c.vars[page.Name.Value].(map[string]interface{}); ok {
+				limit = toInt(m["limit"], limit)
+			}
+		}
+	}
+	return max(limit, 1)
+}
+
+// intValue membaca nilai Int literal atau variable; fallback jika tidak diisi.
+func (c graphQLCost) intValue(v ast.Value, fallback int) int {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		if n, err := strconv.Atoi(v.Value); err == nil {
+			return n
+		}
+	case *ast.Variable:
+		return toInt(c.vars[v.Name.Value], fallback)
+	}
+	return fallback
+}
+
+// toInt mengubah angka dari variable JSON menjadi int; fallback jika bukan angka.
+func toInt(v interface{}, fallback int) int {
+	switch v := v.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"net/http"
+
+	"book-api/model"
+
+	"github.com/graphql-go/graphql"
+)
+
+// newGraphQLSchema membuat schema GraphQL untuk Book:
+//
+//	type Query {
+//	  book(id: Int!): Book
+//	  books(filter: [BookFilter!], sort: [BookSort!], page: PageInput): BookPage!
+//	}
+//	type Mutation {
+//	  createBook(input: BookInput!): Book!
+//	  updateBook(id: Int!, input: BookInput!, version: Int): Book!
+//	  deleteBook(id: Int!, version: Int): Boolean!
+//	}
+//
+// Semua resolver memakai bh.service dan aturan yang sama dengan endpoint REST.
+func newGraphQLSchema(bh *bookHandler) (graphql.Schema, error) {
+	bookType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Book",
+		Fields: graphql.Fields{
+			"id":            bookField(graphql.NewNonNull(graphql.Int), func(b model.Book) interface{} { return b.ID }),
+			"title":         bookField(graphql.NewNonNull(graphql.String), func(b model.Book) interface{} { return b.Title }),
+			"author":        bookField(graphql.NewNonNull(graphql.String), func(b model.Book) interface{} { return b.Author }),
+			"publishedYear": bookField(graphql.NewNonNull(graphql.Int), func(b model.Book) interface{} { return b.PublishedYear }),
+			"isbn": bookField(graphql.String, func(b model.Book) interface{} {
+				if b.ISBN == "" {
+					return nil
+				}
+				return b.ISBN
+			}),
+			"version":   bookField(graphql.NewNonNull(graphql.Int), func(b model.Book) interface{} { return b.Version }),
+			"updatedAt": bookField(graphql.NewNonNull(graphql.DateTime), func(b model.Book) interface{} { return b.UpdatedAt }),
+		},
+	})
+
+	bookFieldEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "BookField",
+		Values: graphql.EnumValueConfigMap{
+			"ID":             {Value: "id"},
+			"TITLE":          {Value: "title"},
+			"AUTHOR":         {Value: "author"},
+			"PUBLISHED_YEAR": {Value: "published_year"},
+		},
+	})
+	filterOpEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "FilterOp",
+		Values: graphql.EnumValueConfigMap{
+			"EQ":       {Value: model.OpEq},
+			"NE":       {Value: model.OpNe},
+			"GT":       {Value: model.OpGt},
+			"GTE":      {Value: model.OpGte},
+			"LT":       {Value: model.OpLt},
+			"LTE":      {Value: model.OpLte},
+			"CONTAINS": {Value: model.OpContains},
+		},
+	})
+	filterInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"field": {Type: graphql.NewNonNull(bookFieldEnum)},
+			"op":    {Type: filterOpEnum, DefaultValue: model.OpEq},
+			"value": {Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	sortInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookSort",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"field": {Type: graphql.NewNonNull(bookFieldEnum)},
+			"desc":  {Type: graphql.Boolean, DefaultValue: false},
+		},
+	})
+	pageInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PageInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"limit":  {Type: graphql.Int},
+			"offset": {Type: graphql.Int},
+			"cursor": {Type: graphql.String},
+		},
+	})
+	bookInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":         {Type: graphql.NewNonNull(graphql.String)},
+			"author":        {Type: graphql.NewNonNull(graphql.String)},
+			"publishedYear": {Type: graphql.NewNonNull(graphql.Int)},
+			"isbn":          {Type: graphql.String},
+		},
+	})
+
+	pageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "BookPage",
+		Fields: graphql.Fields{
+			"items":      {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType)))},
+			"total":      {Type: graphql.NewNonNull(graphql.Int)},
+			"limit":      {Type: graphql.NewNonNull(graphql.Int)},
+			"offset":     {Type: graphql.NewNonNull(graphql.Int)},
+			"nextCursor": {Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"book": {
+				Type: bookType,
+				Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					book, err := bh.service.GetBookByID(p.Context, p.Args["id"].(int))
+					if err != nil {
+						return nil, newGraphQLError(err)
+					}
+					return book, nil
+				},
+			},
+			"books": {
+				Type: graphql.NewNonNull(pageType),
+				Args: graphql.FieldConfigArgument{
+					"filter": {Type: graphql.NewList(graphql.NewNonNull(filterInput))},
+					"sort":   {Type: graphql.NewList(graphql.NewNonNull(sortInput))},
+					"page":   {Type: pageInput},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					q, err := graphQLBookQuery(p.Args).Normalize()
+					if err != nil {
+						return nil, newGraphQLError(err)
+					}
+					page, err := bh.service.QueryBooks(p.Context, q)
+					if err != nil {
+						return nil, newGraphQLError(err)
+					}
+					result := map[string]interface{}{
+						"items":  page.Books,
+						"total":  page.Total,
+						"limit":  q.Limit,
+						"offset": q.Offset,
+					}
+					if page.NextCursor != "" {
+						result["nextCursor"] = page.NextCursor
+					}
+					return result, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createBook": {
+				Type: graphql.NewNonNull(bookType),
+				Args: graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(bookInput)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					book := graphQLBookInput(p.Args["input"])
+					if err := book.Validate(); err != nil {
+						return nil, newGraphQLError(err)
+					}
+					created, err := bh.service.AddBook(p.Context, book)
+					if err != nil {
+						return nil, newGraphQLError(err)
+					}
+					return created, nil
+				},
+			},
+			"updateBook": {
+				Type: graphql.NewNonNull(bookType),
+				Args: graphql.FieldConfigArgument{
+					"id":      {Type: graphql.NewNonNull(graphql.Int)},
+					"input":   {Type: graphql.NewNonNull(bookInput)},
+					"version": {Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					version, err := bh.graphQLVersion(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					book := graphQLBookInput(p.Args["input"])
+					if err := book.Validate(); err != nil {
+						return nil, newGraphQLError(err)
+					}
+					book.Version = version
+					updated, err := bh.service.UpdateBook(p.Context, p.Args["id"].(int), book)
+					if err != nil {
+						return nil, newGraphQLError(err)
+					}
+					return updated, nil
+				},
+			},
+			"deleteBook": {
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id":      {Type: graphql.NewNonNull(graphql.Int)},
+					"version": {Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					version, err := bh.graphQLVersion(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					if err := bh.service.DeleteBook(p.Context, p.Args["id"].(int), version); err != nil {
+						return nil, newGraphQLError(err)
+					}
+					return true, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// bookField membuat field GraphQL yang membaca nilai dari model.Book.
+func bookField(typ graphql.Output, get func(model.Book) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(model.Book)), nil
+		},
+	}
+}
+
+// graphQLBookInput mengubah argumen BookInput menjadi model.Book.
+func graphQLBookInput(arg interface{}) model.Book {
+	input, _ := arg.(map[string]interface{})
+	book := model.Book{}
+	book.Title, _ = input["title"].(string)
+	book.Author, _ = input["author"].(string)
+	book.PublishedYear, _ = input["publishedYear"].(int)
+	book.ISBN, _ = input["isbn"].(string)
+	return book
+}
+
+// graphQLBookQuery mengubah argumen filter, sort, dan page menjadi model.BookQuery
+// (belum dinormalisasi).
+func graphQLBookQuery(args map[string]interface{}) model.BookQuery {
+	var q model.BookQuery
+	filters, _ := args["filter"].([]interface{})
+	for _, f := range filters {
+		f := f.(map[string]interface{})
+		q.Filters = append(q.Filters, model.Filter{
+			Field: f["field"].(string),
+			Op:    f["op"].(model.FilterOp),
+			Value: f["value"].(string),
+		})
+	}
+	sorts, _ := args["sort"].([]interface{})
+	for _, s := range sorts {
+		s := s.(map[string]interface{})
+		q.Sort = append(q.Sort, model.SortField{Field: s["field"].(string), Desc: s["desc"].(bool)})
+	}
+	if page, ok := args["page"].(map[string]interface{}); ok {
+		q.Limit, _ = page["limit"].(int)
+		q.Offset, _ = page["offset"].(int)
+		q.Cursor, _ = page["cursor"].(string)
+	}
+	return q
+}
+
+// graphQLVersion membaca argumen version untuk updateBook dan deleteBook; perannya sama
+// seperti header If-Match di REST, termasuk saat WithRequireIfMatch aktif.
+func (bh *bookHandler) graphQLVersion(args map[string]interface{}) (int, error) {
+	version, ok := args["version"].(int)
+	if !ok && bh.requireIfMatch {
+		return 0, &graphQLError{status: http.StatusPreconditionRequired, message: "version is required"}
+	}
+	return version, nil
+}
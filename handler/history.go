@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"book-api/model"
+	"book-api/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// revisionResponse adalah Revision beserta perubahan field yang dibuatnya.
+type revisionResponse struct {
+	model.Revision
+	Changes []model.FieldChange `json:"changes"`
+}
+
+func newRevisionResponse(r model.Revision) revisionResponse {
+	return revisionResponse{Revision: r, Changes: model.DiffBooks(r.Before, r.After)}
+}
+
+// revisionDiff adalah perbedaan field antara kondisi buku setelah revision From dan setelah revision To.
+type revisionDiff struct {
+	BookID  int                 `json:"book_id"`
+	From    int                 `json:"from"`
+	To      int                 `json:"to"`
+	Changes []model.FieldChange `json:"changes"`
+}
+
+// GetBookHistoryHandler menangani permintaan GET /books/{id}/history untuk melihat
+// semua revision sebuah buku, termasuk buku yang sudah dihapus.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id".
+//
+// Response:
+//   - 200 OK berisi revision dari yang terlama, masing-masing dengan "changes"
+//   - 400 Bad Request jika ID tidak valid
+//   - 404 Not Found jika buku tidak pernah ada
+//   - 5xx jika store gagal
+func (bh *bookHandler) GetBookHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid book ID")
+		return
+	}
+
+	revs, err := bh.service.BookHistory(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	m := mapperFor(r)
+	out := make([]interface{}, len(revs))
+	for i, rev := range revs {
+		out[i] = m.revision(rev)
+	}
+	utils.WriteJSON(w, r, http.StatusOK, out)
+}
+
+// GetBookRevisionHandler menangani permintaan GET /books/{id}/history/{rev} untuk
+// melihat satu revision buku.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id" dan "rev".
+//
+// Response:
+//   - 200 OK berisi revision beserta "changes"
+//   - 400 Bad Request jika ID atau nomor revision tidak valid
+//   - 404 Not Found jika buku atau revision tidak ada
+//   - 5xx jika store gagal
+func (bh *bookHandler) GetBookRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid book ID")
+		return
+	}
+	rev, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid revision number")
+		return
+	}
+
+	revision, err := bh.service.BookRevision(r.Context(), id, rev)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	utils.WriteJSON(w, r, http.StatusOK, mapperFor(r).revision(revision))
+}
+
+// DiffBookRevisionsHandler menangani permintaan GET /books/{id}/history/diff?from=&to=
+// untuk membandingkan kondisi buku setelah dua revision. Tanpa "to", revision terbaru
+// dipakai; tanpa "from", revision tepat sebelum "to" dipakai.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id" dan query "from"/"to".
+//
+// Response:
+//   - 200 OK berisi daftar field yang berubah
+//   - 400 Bad Request jika ID atau nomor revision tidak valid
+//   - 404 Not Found jika buku atau salah satu revision tidak ada
+//   - 5xx jika store gagal
+func (bh *bookHandler) DiffBookRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid book ID")
+		return
+	}
+	from, err := optionalInt(r.URL.Query().Get("from"))
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "from must be a revision number")
+		return
+	}
+	to, err := optionalInt(r.URL.Query().Get("to"))
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "to must be a revision number")
+		return
+	}
+
+	revs, err := bh.service.BookHistory(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	if to == 0 {
+		to = len(revs)
+	}
+	if from == 0 {
+		from = max(to-1, 1)
+	}
+	if from < 1 || from > len(revs) || to < 1 || to > len(revs) {
+		utils.WriteError(w, r, http.StatusNotFound, "revision not found")
+		return
+	}
+
+	utils.WriteJSON(w, r, http.StatusOK, revisionDiff{
+		BookID:  id,
+		From:    from,
+		To:      to,
+		Changes: mapFieldChanges(mapperFor(r), model.DiffBooks(revs[from-1].After, revs[to-1].After)),
+	})
+}
+
+// optionalInt mengubah parameter query menjadi angka; string kosong menjadi 0.
+func optionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
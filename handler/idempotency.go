@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"book-api/utils"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyReplayedHeader ditambahkan pada response yang diputar ulang dari cache.
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+
+	// DefaultIdempotencyTTL adalah lama response disimpan untuk sebuah Idempotency-Key.
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyMaxEntries adalah jumlah Idempotency-Key maksimum yang disimpan.
+	DefaultIdempotencyMaxEntries = 10000
+)
+
+// idempotencyEntry menyimpan hasil request pertama untuk sebuah Idempotency-Key.
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte
+	// done ditutup ketika request pertama selesai; sebelum itu entry masih in-flight.
+	done chan struct{}
+	// ok bernilai true jika response berhasil disimpan dan boleh diputar ulang.
+	ok      bool
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+	// elem adalah posisi entry pada idempotencyCache.order.
+	elem *list.Element
+}
+
+// idempotencyCache menyimpan response per Idempotency-Key selama ttl, paling banyak
+// maxEntries entry. Key pada cache sudah di-scope oleh idempotencyScope.
+type idempotencyCache struct {
+	mu         sync.Mutex
+	entries    map[string]*idempotencyEntry
+	order      *list.List // key dalam urutan dibuat, yang tertua di depan
+	ttl        time.Duration
+	maxEntries int
+	nextSweep  time.Time
+}
+
+func newIdempotencyCache(ttl time.Duration, maxEntries int) *idempotencyCache {
+	return &idempotencyCache{
+		entries:    make(map[string]*idempotencyEntry),
+		order:      list.New(),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// begin mencari entry untuk key. Jika belum ada (atau sudah kedaluwarsa), entry baru
+// dibuat dan pemanggil menjadi owner yang wajib memanggil finish.
+func (c *idempotencyCache) begin(key string, fingerprint [sha256.Size]byte) (*idempotencyEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.After(c.nextSweep) {
+		c.sweep(now)
+	}
+
+	if e, ok := c.entries[key]; ok {
+		if !e.ok || now.Before(e.expires) {
+			return e, false
+		}
+		c.remove(key, e)
+	}
+	if len(c.entries) >= c.maxEntries {
+		c.sweep(now)
+	}
+	for len(c.entries) >= c.maxEntries && c.evictOldest() {
+	}
+	e := &idempotencyEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	e.elem = c.order.PushBack(key)
+	c.entries[key] = e
+	return e, true
+}
+
+// evictOldest menghapus entry selesai yang paling lama dibuat. Entry in-flight tidak
+// pernah dihapus karena owner dan request yang menunggu masih memakainya.
+func (c *idempotencyCache) evictOldest() bool {
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		key := el.Value.(string)
+		if e := c.entries[key]; e.ok {
+			c.remove(key, e)
+			return true
+		}
+	}
+	return false
+}
+
+// remove menghapus entry key dari cache.
+func (c *idempotencyCache) remove(key string, e *idempotencyEntry) {
+	delete(c.entries, key)
+	c.order.Remove(e.elem)
+}
+
+// finish menyimpan response owner. Hanya response 2xx yang disimpan; selain itu key
+// dilepas agar client bisa mencoba lagi.
+func (c *idempotencyCache) finish(key string, e *idempotencyEntry, rec *responseRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rec.status >= 200 && rec.status < 300 {
+		e.ok = true
+		e.status = rec.status
+		e.header = rec.Header().Clone()
+		e.body = rec.body.Bytes()
+		e.expires = time.Now().Add(c.ttl)
+	} else if c.entries[key] == e {
+		c.remove(key, e)
+	}
+	close(e.done)
+}
+
+// sweep menghapus entry yang sudah kedaluwarsa. Dijalankan paling sering sekali per menit.
+func (c *idempotencyCache) sweep(now time.Time) {
+	for key, e := range c.entries {
+		if e.ok && !now.Before(e.expires) {
+			c.remove(key, e)
+		}
+	}
+	c.nextSweep = now.Add(time.Minute)
+}
+
+// responseRecorder meneruskan response ke client sambil menyimpan salinannya.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotencyScope mengembalikan key cache untuk Idempotency-Key. Key yang sama pada
+// method, path (setelah prefix versi ditambahkan), versi API, atau header Authorization
+// yang berbeda mendapat entry sendiri sehingga tidak saling memutar ulang response.
+func idempotencyScope(r *http.Request, key string) string {
+	caller := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+	return r.Method + " " + r.URL.Path + " " + apiVersionFrom(r.Context()).Prefix() + " " + hex.EncodeToString(caller[:8]) + " " + key
+}
+
+// idempotencyFingerprint menghitung sidik request: Content-Type, query string, dan body.
+func idempotencyFingerprint(r *http.Request, body []byte) [sha256.Size]byte {
+	h := sha256.New()
+	io.WriteString(h, r.Header.Get("Content-Type"))
+	h.Write([]byte{0})
+	io.WriteString(h, r.URL.RawQuery)
+	h.Write([]byte{0})
+	h.Write(body)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// withIdempotency menjalankan next sekali untuk setiap Idempotency-Key pada endpoint dan
+// versi API yang sama. Request ulang yang identik menerima salinan response pertama;
+// query atau body berbeda dijawab 422. Request duplikat yang datang saat request pertama
+// masih berjalan menunggu hasilnya.
+func (bh *bookHandler) withIdempotency(w http.ResponseWriter, r *http.Request, key string, next http.HandlerFunc) {
+	if len(key) > maxIdempotencyKeyLength {
+		utils.WriteError(w, r, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+		return
+	}
+
+	body, err := utils.ReadBody(w, r, bh.maxBodyBytes)
+	if err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	fingerprint := idempotencyFingerprint(r, body)
+	key = idempotencyScope(r, key)
+
+	for {
+		entry, owner := bh.idempotency.begin(key, fingerprint)
+		if owner {
+			rec := &responseRecorder{ResponseWriter: w}
+			defer func() {
+				// Pastikan request lain yang menunggu tidak macet jika next panic.
+				if rec.status == 0 {
+					rec.status = http.StatusInternalServerError
+				}
+				bh.idempotency.finish(key, entry, rec)
+			}()
+			next(rec, r)
+			return
+		}
+
+		if entry.fingerprint != fingerprint {
+			utils.WriteError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+			return
+		}
+
+		select {
+		case <-entry.done:
+		case <-r.Context().Done():
+			writeStoreError(w, r, r.Context().Err())
+			return
+		}
+		if entry.ok {
+			for k, v := range entry.header {
+				w.Header()[k] = append([]string(nil), v...)
+			}
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(entry.status)
+			w.Write(entry.body)
+			return
+		}
+		// Request pertama gagal dan key dilepas; coba lagi sebagai owner baru.
+	}
+}
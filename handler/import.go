@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"book-api/model"
+	"book-api/utils"
+)
+
+// DefaultMaxImportBytes adalah batas ukuran file POST /books/import jika tidak dikonfigurasi (32 MiB).
+const DefaultMaxImportBytes int64 = 32 << 20
+
+// Nilai importDuplicate.Match.
+const (
+	matchISBN            = "isbn"
+	matchTitleAuthorYear = "title_author_year"
+)
+
+// importReport adalah hasil POST /books/import.
+type importReport struct {
+	DryRun     bool              `json:"dry_run"`
+	Total      int               `json:"total"`
+	Accepted   []importAccepted  `json:"accepted"`
+	Rejected   []importRejected  `json:"rejected"`
+	Duplicates []importDuplicate `json:"duplicates"`
+}
+
+// importAccepted adalah baris yang lolos validasi. ID kosong pada dry-run.
+type importAccepted struct {
+	Line int `json:"line"`
+	ID   int `json:"id,omitempty"`
+}
+
+// importRejected adalah baris yang tidak bisa dibaca atau gagal validasi.
+type importRejected struct {
+	Line    int         `json:"line"`
+	Reason  string      `json:"reason"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// importDuplicate adalah baris yang sama dengan buku yang sudah ada atau dengan
+// baris sebelumnya di file yang sama.
+type importDuplicate struct {
+	Line            int    `json:"line"`
+	Match           string `json:"match"`
+	ExistingID      int    `json:"existing_id,omitempty"`
+	DuplicateOfLine int    `json:"duplicate_of_line,omitempty"`
+}
+
+// importRow adalah satu baris file import; err berisi alasan jika baris tidak bisa dibaca.
+type importRow struct {
+	line int
+	book model.Book
+	err  *importRejected
+}
+
+// ImportBooksHandler menangani permintaan POST /books/import untuk menambahkan banyak
+// buku dari file CSV (text/csv, dengan baris header) atau JSON Lines
+// (application/x-ndjson). Setiap baris divalidasi seperti POST /books; baris yang
+// sama dengan buku yang sudah ada (ISBN, atau judul+penulis+tahun) dilaporkan sebagai
+// duplikat. Baris yang diterima disimpan sekaligus secara atomik kecuali ?dry_run=true.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request berisi file; format dari Content-Type atau parameter query "format".
+//
+// Response:
+//   - 200 OK berisi laporan baris accepted, rejected (dengan nomor baris dan alasan), dan duplicates
+//   - 400 Bad Request jika header CSV atau parameter tidak valid
+//   - 413 Request Entity Too Large jika file melebihi batas ukuran
+//   - 415 Unsupported Media Type jika format tidak dikenali
+//   - 5xx jika store gagal
+func (bh *bookHandler) ImportBooksHandler(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			utils.WriteError(w, r, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	format := importFormat(r)
+	if format == "" {
+		utils.WriteError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be text/csv or application/x-ndjson")
+		return
+	}
+
+	body, err := utils.ReadBody(w, r, bh.maxImportBytes)
+	if err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	m := mapperFor(r)
+	var rows []importRow
+	if format == formatCSV {
+		rows, err = parseCSVRows(body, m)
+	} else {
+		rows = parseJSONLRows(body, m)
+	}
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing := make(map[string]int)
+	err = bh.service.EachBook(r.Context(), func(b model.Book) error {
+		for _, key := range duplicateKeys(b) {
+			if _, ok := existing[key]; !ok {
+				existing[key] = b.ID
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	report := importReport{
+		DryRun:     dryRun,
+		Total:      len(rows),
+		Accepted:   []importAccepted{},
+		Rejected:   []importRejected{},
+		Duplicates: []importDuplicate{},
+	}
+	seen := make(map[string]int)
+	var ops []model.BatchOp
+	for _, row := range rows {
+		if row.err != nil {
+			report.Rejected = append(report.Rejected, *row.err)
+			continue
+		}
+		if err := row.book.Validate(); err != nil {
+			report.Rejected = append(report.Rejected, importRejected{
+				Line:    row.line,
+				Reason:  model.ErrValidation.Error(),
+				Details: mapFieldErrors(m, err.(*model.ValidationError).Fields),
+			})
+			continue
+		}
+		if dup, ok := findDuplicate(row, existing, seen); ok {
+			report.Duplicates = append(report.Duplicates, dup)
+			continue
+		}
+		for _, key := range duplicateKeys(row.book) {
+			seen[key] = row.line
+		}
+		book := row.book
+		ops = append(ops, model.BatchOp{Op: model.BatchCreate, Book: &book})
+		report.Accepted = append(report.Accepted, importAccepted{Line: row.line})
+	}
+
+	if !dryRun && len(ops) > 0 {
+		created, err := bh.service.ApplyBatch(r.Context(), ops)
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+		for i := range report.Accepted {
+			report.Accepted[i].ID = created[i].ID
+		}
+	}
+
+	utils.WriteJSON(w, r, http.StatusOK, report)
+}
+
+// importFormat menentukan format file dari parameter query "format" atau Content-Type.
+// String kosong berarti format tidak dikenali.
+func importFormat(r *http.Request) string {
+	switch format := r.URL.Query().Get("format"); format {
+	case formatCSV, formatJSONL:
+		return format
+	case "":
+	default:
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case contentTypeCSV:
+		return formatCSV
+	case contentTypeJSONL, "application/jsonl", "application/x-jsonlines":
+		return formatJSONL
+	}
+	return ""
+}
+
+// parseCSVRows membaca file CSV dengan baris header berisi nama field representasi m.
+// Kolom title, author, dan published_year wajib ada; id, version, dan updated_at
+// diabaikan dan awalan ' dari utils.EscapeCSVCell dibuang agar hasil export bisa
+// langsung di-import.
+//
+// Returns:
+//   - baris data beserta nomor barisnya di file
+//   - error jika header tidak valid
+func parseCSVRows(body []byte, m bookMapper) ([]importRow, error) {
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\ufeff"))))
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("CSV header row is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		field := m.queryField(name)
+		if !slices.Contains(csvColumns, field) {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		if _, dup := columns[field]; dup {
+			return nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		columns[field] = i
+	}
+	for _, name := range []string{"title", "author", "published_year"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV column %q is required", m.field(name))
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rows = append(rows, importRow{line: parseErr.StartLine, err: &importRejected{Line: parseErr.StartLine, Reason: err.Error()}})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+
+		row := importRow{line: line}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return utils.UnescapeCSVCell(record[i])
+			}
+			return ""
+		}
+		row.book.Title = field("title")
+		row.book.Author = field("author")
+		row.book.ISBN = field("isbn")
+		if year := field("published_year"); year != "" {
+			if row.book.PublishedYear, err = strconv.Atoi(year); err != nil {
+				row.err = &importRejected{
+					Line:   line,
+					Reason: model.ErrValidation.Error(),
+					Details: []model.FieldError{{
+						Field:   m.field("published_year"),
+						Code:    utils.DecodeTypeMismatch,
+						Message: fmt.Sprintf("%s must be an integer, got %q", m.field("published_year"), year),
+					}},
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseJSONLRows membaca file JSON Lines; setiap baris yang tidak kosong berisi satu
+// objek buku dalam representasi m. Field id, version, dan updated_at diabaikan.
+func parseJSONLRows(body []byte, m bookMapper) []importRow {
+	var rows []importRow
+	br := bufio.NewReader(bytes.NewReader(body))
+	for line := 1; ; line++ {
+		text, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(text)) > 0 {
+			row := importRow{line: line}
+			input := m.newInput()
+			if err := utils.DecodeStrict(text, input); err == nil {
+				row.book = input.toBook()
+			} else {
+				row.err = &importRejected{Line: line, Reason: err.Error()}
+				var decErr *utils.DecodeError
+				if errors.As(err, &decErr) {
+					row.err.Details = []*utils.DecodeError{decErr}
+				}
+			}
+			rows = append(rows, row)
+		}
+		if err != nil {
+			return rows
+		}
+	}
+}
+
+// duplicateKeys mengembalikan kunci pembanding duplikat sebuah buku: ISBN yang
+// dinormalisasi (jika ada) dan gabungan judul, penulis, dan tahun tanpa membedakan huruf.
+func duplicateKeys(b model.Book) []string {
+	keys := make([]string, 0, 2)
+	if isbn := normalizeISBN(b.ISBN); isbn != "" {
+		keys = append(keys, matchISBN+":"+isbn)
+	}
+	keys = append(keys, fmt.Sprintf("%s:%s\x00%s\x00%d", matchTitleAuthorYear,
+		strings.ToLower(strings.TrimSpace(b.Title)), strings.ToLower(strings.TrimSpace(b.Author)), b.PublishedYear))
+	return keys
+}
+
+// normalizeISBN menghapus tanda hubung dan spasi serta menyeragamkan huruf X.
+func normalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+}
+
+// findDuplicate mencari buku yang sudah ada atau baris sebelumnya yang sama dengan row.
+func findDuplicate(row importRow, existing, seen map[string]int) (importDuplicate, bool) {
+	for _, key := range duplicateKeys(row.book) {
+		match, _, _ := strings.Cut(key, ":")
+		if id, ok := existing[key]; ok {
+			return importDuplicate{Line: row.line, Match: match, ExistingID: id}, true
+		}
+		if line, ok := seen[key]; ok {
+			return importDuplicate{Line: row.line, Match: match, DuplicateOfLine: line}, true
+		}
+	}
+	return importDuplicate{}, false
+}
@@ -0,0 +1,829 @@
+package handler
+
+import (
+	_ "embed"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"book-api/model"
+	"book-api/openapi"
+	"book-api/utils"
+)
+
+// docsPage adalah halaman dokumentasi interaktif (Swagger UI) untuk /openapi.json.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// OpenAPISpec mengembalikan dokumen OpenAPI 3.1 untuk semua route di router.SetupRouter.
+// Dokumen dibuat sekali dan dipakai bersama, sehingga tidak boleh diubah oleh pemanggil.
+func OpenAPISpec() *openapi.Document {
+	return openAPISpec()
+}
+
+var openAPISpec = sync.OnceValue(buildOpenAPISpec)
+
+var openAPIJSON = sync.OnceValues(func() ([]byte, error) {
+	return json.MarshalIndent(OpenAPISpec(), "", "  ")
+})
+
+// OpenAPIHandler menangani permintaan GET /openapi.json untuk mengunduh dokumen OpenAPI API ini.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang berisi informasi request dari client.
+//
+// Response:
+//   - 200 OK berisi dokumen OpenAPI 3.1 (application/json)
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := openAPIJSON()
+	if err != nil {
+		utils.WriteError(w, r, http.StatusInternalServerError, "failed to encode OpenAPI document")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// DocsHandler menangani permintaan GET /docs untuk menampilkan dokumentasi interaktif
+// dari /openapi.json.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang berisi informasi request dari client.
+//
+// Response:
+//   - 200 OK berisi halaman HTML
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
+
+// Media type yang dipakai di dokumen OpenAPI.
+const (
+	mediaTypeJSON    = "application/json"
+	mediaTypeProblem = "application/problem+json"
+)
+
+// specBuilder membantu menyusun dokumen OpenAPI.
+type specBuilder struct {
+	doc *openapi.Document
+	gen *openapi.Generator
+}
+
+// add mendaftarkan operasi untuk method dan path (dalam format OpenAPI, contoh "/books/{id}").
+func (b *specBuilder) add(method, path string, op *openapi.Operation) {
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &openapi.PathItem{}
+		b.doc.Paths[path] = item
+	}
+	item.SetOperation(method, op)
+}
+
+// specVersion berisi perbedaan dokumentasi operasi antar versi API.
+type specVersion struct {
+	version APIVersion
+	// suffix ditambahkan ke operationId agar unik di seluruh dokumen.
+	suffix string
+	// Nama schema buku untuk versi ini.
+	book, bookInput, trashedBook, searchHit string
+	// Nama schema lain yang berisi buku.
+	revision, changeEvent, batchRequest, batchResult string
+	// mapper memberi nama field filter dan sort untuk versi ini.
+	mapper bookMapper
+}
+
+var specVersions = []specVersion{
+	{
+		version: APIVersion1, book: "Book", bookInput: "BookInput", trashedBook: "TrashedBook", searchHit: "SearchHit",
+		revision: "Revision", changeEvent: "ChangeEvent", batchRequest: "BatchRequest", batchResult: "BatchResult",
+		mapper: v1Mapper{},
+	},
+	{
+		version: APIVersion2, suffix: "V2", book: "BookV2", bookInput: "BookV2Input", trashedBook: "TrashedBookV2", searchHit: "SearchHitV2",
+		revision: "RevisionV2", changeEvent: "ChangeEventV2", batchRequest: "BatchRequestV2", batchResult: "BatchResultV2",
+		mapper: v2Mapper{},
+	},
+}
+
+// addVersioned mendaftarkan operasi di bawah prefix versi v. Operasi v1 ditandai deprecated.
+func (b *specBuilder) addVersioned(v specVersion, method, path string, op *openapi.Operation) {
+	op.OperationID += v.suffix
+	op.Deprecated = v.version == APIVersion1
+	b.add(method, v.version.Prefix()+path, op)
+}
+
+// envelope membuat schema response sukses {"data": data, "meta": meta}. meta boleh nil.
+func envelope(data, meta *openapi.Schema) *openapi.Schema {
+	body := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{"data": data}, Required: []string{"data"}}
+	if meta != nil {
+		body.Properties["meta"] = meta
+		body.Required = append(body.Required, "meta")
+	}
+	return &openapi.Schema{AllOf: []*openapi.Schema{openapi.Ref("APIResponse"), body}}
+}
+
+// jsonContent membuat content application/json dengan schema s.
+func jsonContent(s *openapi.Schema) map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{mediaTypeJSON: {Schema: s}}
+}
+
+// negotiatedContent membuat content dengan schema s untuk setiap format di
+// utils.Formats (hanya yang bisa di-decode jika decodable). CSV berupa tabel teks
+// sehingga schema-nya string.
+func negotiatedContent(s *openapi.Schema, decodable bool) map[string]*openapi.MediaType {
+	content := make(map[string]*openapi.MediaType)
+	for _, f := range utils.Formats() {
+		if decodable && f.Decode == nil {
+			continue
+		}
+		schema := s
+		if f.MediaType == utils.MediaTypeCSV {
+			schema = &openapi.Schema{Type: "string", Description: "Header berisi nama field (bertitik untuk objek bertingkat), satu baris per item"}
+		}
+		content[f.MediaType] = &openapi.MediaType{Schema: schema}
+	}
+	return content
+}
+
+// okResponse membuat response sukses dengan envelope APIResponse dalam semua format
+// yang bisa dipilih lewat header Accept.
+func okResponse(description string, data, meta *openapi.Schema) *openapi.Response {
+	return &openapi.Response{Description: description, Content: negotiatedContent(envelope(data, meta), false)}
+}
+
+// withHeaders menambahkan header ke response.
+func withHeaders(resp *openapi.Response, names ...string) *openapi.Response {
+	resp.Headers = make(map[string]*openapi.Header, len(names))
+	for _, name := range names {
+		resp.Headers[name] = responseHeaders[name]
+	}
+	return resp
+}
+
+// responseHeaders adalah header response yang dipakai beberapa operasi.
+var responseHeaders = map[string]*openapi.Header{
+	"ETag":                    {Description: "Versi buku (atau koleksi) saat ini, ditambah penanda format dan versi API selain JSON v1", Schema: &openapi.Schema{Type: "string"}},
+	"Last-Modified":           {Description: "Waktu perubahan terakhir", Schema: &openapi.Schema{Type: "string"}},
+	idempotencyReplayedHeader: {Description: "true jika response adalah salinan untuk Idempotency-Key yang sama", Schema: &openapi.Schema{Type: "string", Enum: []interface{}{"true"}}},
+}
+
+// responses melengkapi response sukses dengan response error untuk setiap kode status di
+// errs. Body error bisa berupa APIResponse biasa (dalam format hasil negosiasi Accept)
+// atau application/problem+json. Operasi yang response suksesnya dibuat okResponse juga
+// mendapat 406, karena route-nya melalui ContentNegotiationMiddleware.
+func responses(success map[string]*openapi.Response, errs map[int]string) map[string]*openapi.Response {
+	for _, resp := range success {
+		if _, ok := resp.Content[utils.MediaTypeXML]; ok {
+			errs = maps.Clone(errs)
+			if errs == nil {
+				errs = make(map[int]string)
+			}
+			errs[http.StatusNotAcceptable] = "Header Accept tidak menerima format response yang didukung"
+			break
+		}
+	}
+	for status, desc := range errs {
+		content := negotiatedContent(openapi.Ref("ErrorResponse"), false)
+		content[mediaTypeProblem] = &openapi.MediaType{Schema: openapi.Ref("Problem")}
+		success[strconv.Itoa(status)] = &openapi.Response{Description: desc, Content: content}
+	}
+	return success
+}
+
+// Parameter yang dipakai beberapa operasi.
+func pathID(what string) *openapi.Parameter {
+	return &openapi.Parameter{Name: "id", In: "path", Required: true, Description: "ID " + what, Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Float(1)}}
+}
+
+func queryParam(name, description string, schema *openapi.Schema) *openapi.Parameter {
+	return &openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func headerParam(name, description string) *openapi.Parameter {
+	return &openapi.Parameter{Name: name, In: "header", Description: description, Schema: &openapi.Schema{Type: "string"}}
+}
+
+func boolParam(name, description string) *openapi.Parameter {
+	return queryParam(name, description, &openapi.Schema{Type: "boolean"})
+}
+
+var (
+	ifMatchParam         = headerParam("If-Match", "ETag versi buku yang diharapkan; request gagal dengan 412 jika berbeda")
+	ifNoneMatchParam     = headerParam("If-None-Match", "ETag dari response sebelumnya; dijawab 304 jika belum berubah")
+	ifModifiedSinceParam = headerParam("If-Modified-Since", "Last-Modified dari response sebelumnya; dijawab 304 jika belum berubah")
+	idempotencyKeyParam  = headerParam(idempotencyKeyHeader, "Kunci unik (maks 255 karakter) agar retry tidak membuat data ganda")
+)
+
+// changeFilterParams adalah filter untuk change feed.
+var changeFilterParams = []*openapi.Parameter{
+	queryParam("author", "Hanya event buku dengan penulis ini (tanpa membedakan huruf besar/kecil); boleh berulang", &openapi.Schema{Type: "string"}),
+	queryParam("id", "Hanya event buku dengan ID ini; boleh berulang atau dipisah koma", &openapi.Schema{Type: "string", Pattern: `^[0-9]+(,[0-9]+)*$`}),
+	queryParam("last_event_id", "ID event terakhir yang sudah diterima (<epoch>-<seq>), untuk melanjutkan langganan", &openapi.Schema{Type: "string"}),
+}
+
+// apiDescription menjelaskan API dan cara memilih versinya.
+const apiDescription = "RESTful API untuk mengelola data buku. Route REST ada di bawah /v1 (deprecated) dan /v2. " +
+	"Path tanpa prefix versi (contoh /books) dilayani versi dari header API-Version (1 atau 2, default 1). " +
+	"Setiap response berisi header API-Version; response v1 juga berisi header Deprecation, Sunset, dan Link ke v2."
+
+// buildOpenAPISpec menyusun dokumen OpenAPI dari operasi yang didaftarkan di router.
+func buildOpenAPISpec() *openapi.Document {
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Book API",
+			Version:     "2.0.0",
+			Description: apiDescription,
+		},
+		Paths: make(map[string]*openapi.PathItem),
+		Components: openapi.Components{
+			Schemas: make(map[string]*openapi.Schema),
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"adminToken": {Type: "http", Scheme: "bearer", Description: "Token admin dari -admin-token"},
+			},
+		},
+	}
+	b := &specBuilder{doc: doc, gen: openapi.NewGenerator(doc.Components.Schemas)}
+	addSchemas(b)
+	for _, v := range specVersions {
+		addBookOperations(b, v)
+		addWebhookOperations(b, v)
+	}
+	addGraphQLOperation(b)
+
+	b.add("GET", "/openapi.json", &openapi.Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "Dokumen OpenAPI API ini",
+		Tags:        []string{"docs"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Dokumen OpenAPI 3.1", Content: jsonContent(&openapi.Schema{Type: "object"})},
+		},
+	})
+	b.add("GET", "/docs", &openapi.Operation{
+		OperationID: "getDocs",
+		Summary:     "Dokumentasi interaktif",
+		Tags:        []string{"docs"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Halaman HTML", Content: map[string]*openapi.MediaType{"text/html": {Schema: &openapi.Schema{Type: "string"}}}},
+		},
+	})
+	return doc
+}
+
+// addSchemas mendaftarkan schema di components.schemas. Schema untuk response dibuat dari
+// tipe Go-nya; schema untuk body request ditulis manual agar aturan validasinya ikut terdokumentasi.
+func addSchemas(b *specBuilder) {
+	schemas := b.doc.Components.Schemas
+	b.gen.Schema(utils.APIResponse{})
+	b.gen.Schema(model.Book{})
+	b.gen.Schema(model.TrashedBook{})
+	b.gen.Schema(model.SearchHit{})
+	b.gen.Named("BookV2", bookV2{})
+	b.gen.Named("TrashedBookV2", trashedBookV2{})
+	b.gen.Named("SearchHitV2", searchHitV2{})
+	b.gen.Schema(model.FieldError{})
+	b.gen.Schema(model.ChangeEvent{})
+	b.gen.Schema(model.Webhook{})
+	b.gen.Schema(model.WebhookDelivery{})
+	b.gen.Schema(model.WebhookDeadLetter{})
+	b.gen.Named("ChangeEventV2", changeEventV2{})
+	b.gen.Named("Revision", revisionResponse{})
+	b.gen.Named("RevisionV2", revisionV2{})
+	b.gen.Named("RevisionDiff", revisionDiff{})
+	b.gen.Named("BatchResult", batchResult{})
+	b.gen.Named("BatchMeta", batchMeta{})
+	b.gen.Named("ImportReport", importReport{})
+	b.gen.Named("PageMeta", pageMeta{})
+
+	schemas["Book"].Properties["isbn"].Description = "ISBN-10 atau ISBN-13"
+	// data pada BatchResult berisi buku dalam representasi versi request.
+	batchResultV2 := *schemas["BatchResult"]
+	batchResultV2.Properties = maps.Clone(batchResultV2.Properties)
+	schemas["BatchResult"].Properties["data"] = openapi.Ref("Book")
+	batchResultV2.Properties["data"] = openapi.Ref("BookV2")
+	schemas["BatchResultV2"] = &batchResultV2
+	schemas["TotalMeta"] = &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"total": {Type: "integer"}},
+		Required:   []string{"total"},
+	}
+	schemas["Message"] = &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"message": {Type: "string"}},
+		Required:   []string{"message"},
+	}
+	schemas["ErrorResponse"] = &openapi.Schema{
+		Type:        "object",
+		Description: "Error dalam format JSON standar (jika client tidak meminta application/problem+json)",
+		Properties: map[string]*openapi.Schema{
+			"error":   {Type: "string"},
+			"details": {Description: "Rincian error, misalnya daftar FieldError"},
+		},
+		Required: []string{"error"},
+	}
+	schemas["Problem"] = &openapi.Schema{
+		Type:        "object",
+		Description: "Error RFC 7807 (application/problem+json)",
+		Properties: map[string]*openapi.Schema{
+			"type":     {Type: "string"},
+			"title":    {Type: "string"},
+			"status":   {Type: "integer"},
+			"detail":   {Type: "string"},
+			"instance": {Type: "string", Description: "Request ID"},
+			"details":  {Type: "array", Items: openapi.Ref("FieldError")},
+		},
+		Required: []string{"type", "title", "status"},
+	}
+
+	schemas["BookInput"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"title":          {Type: "string", MinLength: openapi.Int(1), MaxLength: openapi.Int(model.MaxTitleLength)},
+			"author":         {Type: "string", MinLength: openapi.Int(1), MaxLength: openapi.Int(model.MaxAuthorLength)},
+			"published_year": {Type: "integer", Minimum: openapi.Float(1), Description: "Tidak boleh lebih dari tahun ini"},
+			"isbn":           {Type: "string", Description: "ISBN-10 atau ISBN-13 dengan checksum yang valid"},
+			"version":        {Type: "integer", Minimum: openapi.Float(0), Description: "Versi yang diharapkan (PUT); 0 berarti tanpa syarat"},
+		},
+		Required: []string{"title", "author", "published_year"},
+	}
+	schemas["BookV2Input"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"title": {Type: "string", MinLength: openapi.Int(1), MaxLength: openapi.Int(model.MaxTitleLength)},
+			"author": {
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"name": {Type: "string", MinLength: openapi.Int(1), MaxLength: openapi.Int(model.MaxAuthorLength)}},
+				Required:   []string{"name"},
+			},
+			"publication": {
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"year": {Type: "integer", Minimum: openapi.Float(1), Description: "Tidak boleh lebih dari tahun ini"}},
+				Required:   []string{"year"},
+			},
+			"isbn":       {Type: "string", Nullable: true, Description: "ISBN-10 atau ISBN-13 dengan checksum yang valid; null jika tidak ada"},
+			"version":    {Type: "integer", Minimum: openapi.Float(0), Description: "Versi yang diharapkan (PUT); 0 berarti tanpa syarat"},
+			"id":         {Type: "integer", Description: "Diabaikan"},
+			"updated_at": {Type: "string", Description: "Diabaikan"},
+		},
+		Required: []string{"title", "author", "publication"},
+	}
+	schemas["BatchOp"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"op":      {Type: "string", Enum: []interface{}{model.BatchCreate, model.BatchUpdate, model.BatchDelete}},
+			"id":      {Type: "integer", Minimum: openapi.Float(1), Description: "Wajib untuk update dan delete"},
+			"version": {Type: "integer", Minimum: openapi.Float(0)},
+			"book":    {AllOf: []*openapi.Schema{openapi.Ref("BookInput")}, Description: "Wajib untuk create dan update"},
+		},
+		Required: []string{"op"},
+	}
+	schemas["BatchOpV2"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"op":      {Type: "string", Enum: []interface{}{model.BatchCreate, model.BatchUpdate, model.BatchDelete}},
+			"id":      {Type: "integer", Minimum: openapi.Float(1), Description: "Wajib untuk update dan delete"},
+			"version": {Type: "integer", Minimum: openapi.Float(0)},
+			"book":    {AllOf: []*openapi.Schema{openapi.Ref("BookV2Input")}, Description: "Wajib untuk create dan update"},
+		},
+		Required: []string{"op"},
+	}
+	for name, op := range map[string]string{"BatchRequest": "BatchOp", "BatchRequestV2": "BatchOpV2"} {
+		schemas[name] = &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"operations": {Type: "array", Items: openapi.Ref(op), MinItems: openapi.Int(1), MaxItems: openapi.Int(maxBatchOperations)},
+			},
+			Required: []string{"operations"},
+		}
+	}
+	schemas["MergePatch"] = &openapi.Schema{
+		Type:        "object",
+		Description: "JSON Merge Patch (RFC 7396) terhadap Book; id, version, dan updated_at tidak boleh diubah",
+	}
+	schemas["JSONPatch"] = &openapi.Schema{
+		Type:        "array",
+		Description: "JSON Patch (RFC 6902) terhadap Book",
+		Items: &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"op":    {Type: "string", Enum: []interface{}{"add", "remove", "replace", "move", "copy", "test"}},
+				"path":  {Type: "string"},
+				"from":  {Type: "string"},
+				"value": {},
+			},
+			Required: []string{"op", "path"},
+		},
+	}
+	events := make([]interface{}, 0, 5)
+	for _, typ := range []string{model.ChangeCreated, model.ChangeUpdated, model.ChangeDeleted, model.ChangeRestored, model.ChangePurged} {
+		events = append(events, typ)
+	}
+	schemas["ChangeEvent"].Properties["type"].Enum = events
+	schemas["ChangeEventV2"].Properties["type"].Enum = events
+	schemas["WebhookInput"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"url":    {Type: "string", Format: "uri", MaxLength: openapi.Int(model.MaxWebhookURLLength)},
+			"secret": {Type: "string", MaxLength: openapi.Int(model.MaxWebhookSecretLength), Description: "Minimal 16 karakter; dibuat acak jika kosong"},
+			"events": {Type: "array", Items: &openapi.Schema{Type: "string", Enum: events}, Description: "Kosong berarti semua jenis event"},
+			"active": {Type: "boolean", Description: "Default true"},
+		},
+		Required: []string{"url"},
+	}
+}
+
+// addBookOperations mendaftarkan operasi /books untuk versi v. Semua operasi ada di
+// setiap versi; hanya schema buku dan nama field-nya yang berbeda.
+func addBookOperations(b *specBuilder, v specVersion) {
+	book := openapi.Ref(v.book)
+	books := &openapi.Schema{Type: "array", Items: book}
+	bookBody := &openapi.RequestBody{Required: true, Content: negotiatedContent(openapi.Ref(v.bookInput), true)}
+	year := v.mapper.field("published_year")
+
+	b.addVersioned(v, "GET", "/books", &openapi.Operation{
+		OperationID: "listBooks",
+		Summary:     "Daftar buku dengan filter, sort, dan paginasi",
+		Description: "Filter ditulis sebagai <field>=nilai atau <field>[op]=nilai dengan op eq, ne, gt, gte, lt, lte, atau contains.",
+		Tags:        []string{"books"},
+		Parameters: []*openapi.Parameter{
+			queryParam("limit", "Jumlah buku per halaman", &openapi.Schema{Type: "integer", Minimum: openapi.Float(1)}),
+			queryParam("offset", "Jumlah buku yang dilewati", &openapi.Schema{Type: "integer", Minimum: openapi.Float(0)}),
+			queryParam("cursor", "Cursor dari meta.next_cursor", &openapi.Schema{Type: "string"}),
+			queryParam("sort", "Field dipisah koma, awalan - untuk urutan menurun (contoh: title,-"+year+")", &openapi.Schema{Type: "string"}),
+			queryParam("title", "Filter judul", &openapi.Schema{Type: "string"}),
+			queryParam(v.mapper.field("author"), "Filter penulis", &openapi.Schema{Type: "string"}),
+			queryParam(year, "Filter tahun terbit", &openapi.Schema{Type: "integer"}),
+			queryParam("isbn", "Filter ISBN", &openapi.Schema{Type: "string"}),
+			ifNoneMatchParam,
+			ifModifiedSinceParam,
+		},
+		Responses: responses(map[string]*openapi.Response{
+			"200": withHeaders(okResponse("Daftar buku", books, openapi.Ref("PageMeta")), "ETag", "Last-Modified"),
+			"304": {Description: "Koleksi belum berubah"},
+		}, map[int]string{400: "Parameter query tidak valid"}),
+	})
+	b.addVersioned(v, "POST", "/books", &openapi.Operation{
+		OperationID: "createBook",
+		Summary:     "Tambah buku",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{idempotencyKeyParam},
+		RequestBody: bookBody,
+		Responses: responses(map[string]*openapi.Response{
+			"201": withHeaders(okResponse("Buku dibuat", book, nil), "ETag", idempotencyReplayedHeader),
+		}, map[int]string{
+			400: "Body tidak valid atau gagal validasi",
+			413: "Body terlalu besar",
+			415: "Content-Type bukan format yang didukung",
+			422: "Idempotency-Key sudah dipakai dengan body berbeda",
+		}),
+	})
+	b.addVersioned(v, "GET", "/books/search", &openapi.Operation{
+		OperationID: "searchBooks",
+		Summary:     "Pencarian teks penuh pada judul dan penulis",
+		Tags:        []string{"books"},
+		Parameters: []*openapi.Parameter{
+			{Name: "q", In: "query", Required: true, Description: "Kata kunci", Schema: &openapi.Schema{Type: "string", MinLength: openapi.Int(1)}},
+			queryParam("limit", "Jumlah hasil maksimum", &openapi.Schema{Type: "integer", Minimum: openapi.Float(1)}),
+		},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Hasil pencarian dengan skor dan highlight", &openapi.Schema{Type: "array", Items: openapi.Ref(v.searchHit)}, nil),
+		}, map[int]string{400: "q kosong atau limit tidak valid", 501: "Store tidak mendukung pencarian"}),
+	})
+	b.addVersioned(v, "GET", "/books/trash", &openapi.Operation{
+		OperationID: "listTrash",
+		Summary:     "Daftar buku di trash",
+		Tags:        []string{"trash"},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Buku di trash, yang paling baru dihapus lebih dulu", &openapi.Schema{Type: "array", Items: openapi.Ref(v.trashedBook)}, openapi.Ref("TotalMeta")),
+		}, nil),
+	})
+	id := pathID("buku")
+	b.addVersioned(v, "GET", "/books/{id}", &openapi.Operation{
+		OperationID: "getBook",
+		Summary:     "Ambil satu buku",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{id, ifNoneMatchParam, ifModifiedSinceParam},
+		Responses: responses(map[string]*openapi.Response{
+			"200": withHeaders(okResponse("Buku", book, nil), "ETag", "Last-Modified"),
+			"304": {Description: "Buku belum berubah"},
+		}, map[int]string{400: "ID tidak valid", 404: "Buku tidak ditemukan"}),
+	})
+	b.addVersioned(v, "PUT", "/books/{id}", &openapi.Operation{
+		OperationID: "updateBook",
+		Summary:     "Ganti data buku",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{id, ifMatchParam},
+		RequestBody: bookBody,
+		Responses: responses(map[string]*openapi.Response{
+			"200": withHeaders(okResponse("Buku yang sudah diperbarui", book, nil), "ETag"),
+		}, map[int]string{
+			400: "ID atau body tidak valid",
+			404: "Buku tidak ditemukan",
+			412: "Versi tidak cocok",
+			413: "Body terlalu besar",
+			415: "Content-Type bukan format yang didukung",
+			428: "If-Match diwajibkan",
+		}),
+	})
+	b.addVersioned(v, "PATCH", "/books/{id}", &openapi.Operation{
+		OperationID: "patchBook",
+		Summary:     "Ubah sebagian field buku",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{id, ifMatchParam},
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+			mediaTypeMergePatch: {Schema: openapi.Ref("MergePatch")},
+			mediaTypeJSONPatch:  {Schema: openapi.Ref("JSONPatch")},
+		}},
+		Responses: responses(map[string]*openapi.Response{
+			"200": withHeaders(okResponse("Buku hasil patch", book, nil), "ETag"),
+		}, map[int]string{
+			400: "ID, dokumen patch, atau hasil patch tidak valid",
+			404: "Buku tidak ditemukan",
+			409: "Operasi test JSON Patch gagal",
+			412: "Versi tidak cocok",
+			413: "Body terlalu besar",
+			415: "Format patch tidak didukung",
+			428: "If-Match diwajibkan",
+		}),
+	})
+	b.addVersioned(v, "DELETE", "/books/{id}", &openapi.Operation{
+		OperationID: "deleteBook",
+		Summary:     "Pindahkan buku ke trash, atau hapus permanen dengan hard=true",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{id, boolParam("hard", "Hapus permanen (admin)"), ifMatchParam},
+		Security:    []map[string][]string{{}, {"adminToken": {}}},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Buku dihapus", openapi.Ref("Message"), nil),
+		}, map[int]string{
+			400: "ID atau parameter hard tidak valid",
+			401: "Token admin tidak dikirim",
+			403: "Token admin salah atau hard delete dinonaktifkan",
+			404: "Buku tidak ditemukan",
+			412: "Versi tidak cocok",
+			428: "If-Match diwajibkan",
+		}),
+	})
+	b.addVersioned(v, "POST", "/books/{id}/restore", &openapi.Operation{
+		OperationID: "restoreBook",
+		Summary:     "Kembalikan buku dari trash",
+		Tags:        []string{"trash"},
+		Parameters:  []*openapi.Parameter{id},
+		Responses: responses(map[string]*openapi.Response{
+			"200": withHeaders(okResponse("Buku yang dipulihkan", book, nil), "ETag"),
+		}, map[int]string{400: "ID tidak valid", 404: "Buku tidak ada di trash"}),
+	})
+	b.addVersioned(v, "GET", "/books/export", &openapi.Operation{
+		OperationID: "exportBooks",
+		Summary:     "Export seluruh katalog",
+		Description: "Header CSV: " + strings.Join(csvHeader(v.mapper), ",") + ". Setiap baris JSON Lines berisi satu " + v.book + ".",
+		Tags:        []string{"import-export"},
+		Parameters: []*openapi.Parameter{
+			{Name: "format", In: "query", Required: true, Schema: &openapi.Schema{Type: "string", Enum: []interface{}{formatCSV, formatJSONL}}},
+		},
+		Responses: responses(map[string]*openapi.Response{
+			"200": {Description: "File katalog", Content: map[string]*openapi.MediaType{
+				contentTypeCSV:   {Schema: &openapi.Schema{Type: "string"}},
+				contentTypeJSONL: {Schema: &openapi.Schema{Type: "string"}},
+			}},
+		}, map[int]string{400: "Format tidak didukung"}),
+	})
+	b.addVersioned(v, "POST", "/books/import", &openapi.Operation{
+		OperationID: "importBooks",
+		Summary:     "Import buku dari CSV atau JSON Lines",
+		Description: "Kolom CSV sama dengan hasil export (" + strings.Join(csvHeader(v.mapper), ",") + "); id, version, dan updated_at diabaikan. Setiap baris JSON Lines berisi satu " + v.bookInput + ".",
+		Tags:        []string{"import-export"},
+		Parameters: []*openapi.Parameter{
+			queryParam("format", "Format file; default dari Content-Type", &openapi.Schema{Type: "string", Enum: []interface{}{formatCSV, formatJSONL}}),
+			boolParam("dry_run", "Hanya laporkan hasil tanpa menyimpan"),
+		},
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+			contentTypeCSV:   {Schema: &openapi.Schema{Type: "string"}},
+			contentTypeJSONL: {Schema: &openapi.Schema{Type: "string"}},
+		}},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Laporan import", openapi.Ref("ImportReport"), nil),
+		}, map[int]string{
+			400: "Header CSV atau parameter tidak valid",
+			413: "File terlalu besar",
+			415: "Format tidak dikenali",
+		}),
+	})
+	b.addVersioned(v, "POST", "/books/batch", &openapi.Operation{
+		OperationID: "batchBooks",
+		Summary:     "Create, update, dan delete banyak buku sekaligus",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{boolParam("atomic", "Terapkan semua operasi secara all-or-nothing"), idempotencyKeyParam},
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent(openapi.Ref(v.batchRequest))},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Hasil setiap operasi (atomic)", &openapi.Schema{Type: "array", Items: openapi.Ref(v.batchResult)}, nil),
+			"207": okResponse("Status setiap operasi (best-effort)", &openapi.Schema{Type: "array", Items: openapi.Ref(v.batchResult)}, openapi.Ref("BatchMeta")),
+		}, map[int]string{
+			400: "Body atau salah satu operasi tidak valid",
+			404: "Buku pada salah satu operasi tidak ditemukan (atomic)",
+			409: "Salah satu operasi bertabrakan (atomic)",
+			412: "Versi pada salah satu operasi tidak cocok (atomic)",
+			413: "Body terlalu besar",
+			415: "Content-Type bukan application/json",
+			422: "Idempotency-Key sudah dipakai dengan body berbeda",
+		}),
+	})
+	b.addVersioned(v, "GET", "/books/events", &openapi.Operation{
+		OperationID: "streamBookEvents",
+		Summary:     "Change feed lewat Server-Sent Events",
+		Description: "Setiap event SSE memiliki id = id event (<epoch>-<seq>), event = type, dan data = " + v.changeEvent + ". Event \"reset\" dikirim jika event setelah Last-Event-ID sudah tidak tersedia atau berasal dari sebelum server dijalankan ulang.",
+		Tags:        []string{"events"},
+		Parameters:  append([]*openapi.Parameter{headerParam("Last-Event-ID", "ID event terakhir yang sudah diterima")}, changeFilterParams...),
+		Responses: responses(map[string]*openapi.Response{
+			"200": {Description: "Stream event", Content: map[string]*openapi.MediaType{"text/event-stream": {Schema: &openapi.Schema{Type: "string"}}}},
+		}, map[int]string{400: "Filter atau Last-Event-ID tidak valid", 501: "Store tidak menerbitkan event"}),
+	})
+	b.addVersioned(v, "GET", "/books/events/ws", &openapi.Operation{
+		OperationID: "streamBookEventsWebSocket",
+		Summary:     "Change feed lewat WebSocket",
+		Description: "Setelah upgrade, setiap pesan teks berisi satu " + v.changeEvent + " JSON.",
+		Tags:        []string{"events"},
+		Parameters:  changeFilterParams,
+		Responses: responses(map[string]*openapi.Response{
+			"101": {Description: "Koneksi di-upgrade ke WebSocket"},
+		}, map[int]string{400: "Filter tidak valid atau bukan request WebSocket", 501: "Store tidak menerbitkan event"}),
+	})
+
+	b.addVersioned(v, "GET", "/books/{id}/history", &openapi.Operation{
+		OperationID: "getBookHistory",
+		Summary:     "Semua revision buku",
+		Tags:        []string{"history"},
+		Parameters:  []*openapi.Parameter{id},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Revision dari yang terlama", &openapi.Schema{Type: "array", Items: openapi.Ref(v.revision)}, nil),
+		}, map[int]string{400: "ID tidak valid", 404: "Buku tidak pernah ada"}),
+	})
+	revNumber := &openapi.Schema{Type: "integer", Minimum: openapi.Float(1)}
+	b.addVersioned(v, "GET", "/books/{id}/history/diff", &openapi.Operation{
+		OperationID: "diffBookRevisions",
+		Summary:     "Perbedaan field antara dua revision",
+		Tags:        []string{"history"},
+		Parameters: []*openapi.Parameter{
+			id,
+			queryParam("from", "Revision awal; default revision sebelum to", revNumber),
+			queryParam("to", "Revision akhir; default revision terbaru", revNumber),
+		},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Field yang berubah", openapi.Ref("RevisionDiff"), nil),
+		}, map[int]string{400: "ID atau nomor revision tidak valid", 404: "Buku atau revision tidak ada"}),
+	})
+	b.addVersioned(v, "GET", "/books/{id}/history/{rev}", &openapi.Operation{
+		OperationID: "getBookRevision",
+		Summary:     "Satu revision buku",
+		Tags:        []string{"history"},
+		Parameters:  []*openapi.Parameter{id, {Name: "rev", In: "path", Required: true, Schema: revNumber}},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Revision", openapi.Ref(v.revision), nil),
+		}, map[int]string{400: "ID atau nomor revision tidak valid", 404: "Buku atau revision tidak ada"}),
+	})
+}
+
+// addWebhookOperations mendaftarkan operasi /webhooks untuk versi v. Bentuknya sama di
+// semua versi.
+func addWebhookOperations(b *specBuilder, v specVersion) {
+	webhook := openapi.Ref("Webhook")
+	body := &openapi.RequestBody{Required: true, Content: jsonContent(openapi.Ref("WebhookInput"))}
+	id := pathID("webhook")
+	notConfigured := "Webhook tidak dikonfigurasi"
+
+	b.addVersioned(v, "GET", "/webhooks", &openapi.Operation{
+		OperationID: "listWebhooks",
+		Summary:     "Daftar webhook (tanpa secret)",
+		Tags:        []string{"webhooks"},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Webhook berurutan menurut ID", &openapi.Schema{Type: "array", Items: webhook}, openapi.Ref("TotalMeta")),
+		}, map[int]string{501: notConfigured}),
+	})
+	b.addVersioned(v, "POST", "/webhooks", &openapi.Operation{
+		OperationID: "createWebhook",
+		Summary:     "Daftarkan webhook",
+		Tags:        []string{"webhooks"},
+		RequestBody: body,
+		Responses: responses(map[string]*openapi.Response{
+			"201": okResponse("Webhook beserta secret-nya", webhook, nil),
+		}, map[int]string{
+			400: "Body tidak valid",
+			413: "Body terlalu besar",
+			415: "Content-Type bukan application/json",
+			501: notConfigured,
+		}),
+	})
+	b.addVersioned(v, "GET", "/webhooks/{id}", &openapi.Operation{
+		OperationID: "getWebhook",
+		Summary:     "Ambil satu webhook (tanpa secret)",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{id},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Webhook", webhook, nil),
+		}, map[int]string{400: "ID tidak valid", 404: "Webhook tidak ditemukan", 501: notConfigured}),
+	})
+	b.addVersioned(v, "PUT", "/webhooks/{id}", &openapi.Operation{
+		OperationID: "updateWebhook",
+		Summary:     "Ganti data webhook",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{id},
+		RequestBody: body,
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Webhook yang sudah diperbarui (tanpa secret)", webhook, nil),
+		}, map[int]string{
+			400: "ID atau body tidak valid",
+			404: "Webhook tidak ditemukan",
+			413: "Body terlalu besar",
+			415: "Content-Type bukan application/json",
+			501: notConfigured,
+		}),
+	})
+	b.addVersioned(v, "DELETE", "/webhooks/{id}", &openapi.Operation{
+		OperationID: "deleteWebhook",
+		Summary:     "Hapus webhook",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{id},
+		Responses: responses(map[string]*openapi.Response{
+			"204": {Description: "Webhook dihapus"},
+		}, map[int]string{400: "ID tidak valid", 404: "Webhook tidak ditemukan", 501: notConfigured}),
+	})
+	b.addVersioned(v, "GET", "/webhooks/{id}/deliveries", &openapi.Operation{
+		OperationID: "listWebhookDeliveries",
+		Summary:     "Delivery log webhook",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{id},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Attempt terakhir, yang terbaru lebih dulu", &openapi.Schema{Type: "array", Items: openapi.Ref("WebhookDelivery")}, openapi.Ref("TotalMeta")),
+		}, map[int]string{400: "ID tidak valid", 404: "Webhook tidak ditemukan", 501: notConfigured}),
+	})
+	b.addVersioned(v, "GET", "/webhooks/{id}/dead-letters", &openapi.Operation{
+		OperationID: "listWebhookDeadLetters",
+		Summary:     "Event yang gagal dikirim setelah semua retry",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{id},
+		Responses: responses(map[string]*openapi.Response{
+			"200": okResponse("Dead letter, yang terbaru lebih dulu", &openapi.Schema{Type: "array", Items: openapi.Ref("WebhookDeadLetter")}, openapi.Ref("TotalMeta")),
+		}, map[int]string{400: "ID tidak valid", 404: "Webhook tidak ditemukan", 501: notConfigured}),
+	})
+}
+
+// addGraphQLOperation mendaftarkan POST /graphql. Schema GraphQL-nya sendiri bisa dibaca
+// lewat introspeksi.
+func addGraphQLOperation(b *specBuilder) {
+	schemas := b.doc.Components.Schemas
+	schemas["GraphQLRequest"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"query":         {Type: "string", MinLength: openapi.Int(1)},
+			"operationName": {Type: "string"},
+			"variables":     {Type: "object", Nullable: true},
+			"extensions":    {Type: "object", Nullable: true},
+		},
+		Required: []string{"query"},
+	}
+	schemas["GraphQLResponse"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"data": {Description: "Hasil query; null jika query tidak dieksekusi"},
+			"errors": {Type: "array", Items: &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"message":    {Type: "string"},
+					"locations":  {Type: "array"},
+					"path":       {Type: "array"},
+					"extensions": {Type: "object", Description: "code dan status HTTP yang setara di REST, serta details untuk error validasi"},
+				},
+				Required: []string{"message"},
+			}},
+		},
+	}
+	response := func(desc string) *openapi.Response {
+		return &openapi.Response{Description: desc, Content: jsonContent(openapi.Ref("GraphQLResponse"))}
+	}
+
+	b.add("POST", "/graphql", &openapi.Operation{
+		OperationID: "graphql",
+		Summary:     "Query dan mutation GraphQL untuk buku",
+		Description: "Query: book(id), books(filter, sort, page). Mutation: createBook, updateBook, deleteBook.",
+		Tags:        []string{"graphql"},
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent(openapi.Ref("GraphQLRequest"))},
+		Responses: responses(map[string]*openapi.Response{
+			"200": response("Hasil eksekusi; error resolver ada di errors"),
+			"400": {
+				Description: "Body bukan JSON valid, atau query gagal di-parse, tidak valid, atau melebihi batas kedalaman/kompleksitas",
+				Content: map[string]*openapi.MediaType{
+					mediaTypeJSON:    {Schema: &openapi.Schema{OneOf: []*openapi.Schema{openapi.Ref("GraphQLResponse"), openapi.Ref("ErrorResponse")}}},
+					mediaTypeProblem: {Schema: openapi.Ref("Problem")},
+				},
+			},
+		}, map[int]string{
+			413: "Body terlalu besar",
+			415: "Content-Type bukan application/json",
+		}),
+	})
+}
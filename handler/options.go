@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"time"
+
+	"book-api/model"
+	"book-api/utils"
+)
+
+// Option mengatur perilaku opsional BookHandler.
+type Option func(*bookHandler)
+
+// WithRequireIfMatch mewajibkan header If-Match pada PUT, PATCH, dan DELETE /books/{id}.
+// Request tanpa If-Match dijawab 428 Precondition Required.
+func WithRequireIfMatch(required bool) Option {
+	return func(bh *bookHandler) {
+		bh.requireIfMatch = required
+	}
+}
+
+// WithMaxBodyBytes membatasi ukuran body request POST, PUT, dan PATCH.
+// Body yang lebih besar dijawab 413 Request Entity Too Large; nilai 0 atau negatif
+// berarti utils.DefaultMaxBodyBytes.
+func WithMaxBodyBytes(n int64) Option {
+	return func(bh *bookHandler) {
+		if n <= 0 {
+			n = utils.DefaultMaxBodyBytes
+		}
+		bh.maxBodyBytes = n
+	}
+}
+
+// WithMaxImportBytes membatasi ukuran file POST /books/import. File yang lebih besar
+// dijawab 413 Request Entity Too Large; nilai 0 atau negatif berarti DefaultMaxImportBytes.
+func WithMaxImportBytes(n int64) Option {
+	return func(bh *bookHandler) {
+		if n <= 0 {
+			n = DefaultMaxImportBytes
+		}
+		bh.maxImportBytes = n
+	}
+}
+
+// WithAdminToken mengatur token admin yang wajib dikirim sebagai
+// "Authorization: Bearer <token>" untuk hard delete (DELETE /books/{id}?hard=true).
+// Token kosong (default) menonaktifkan hard delete.
+func WithAdminToken(token string) Option {
+	return func(bh *bookHandler) {
+		bh.adminToken = token
+	}
+}
+
+// WithIdempotencyTTL mengatur berapa lama response POST /books disimpan untuk
+// sebuah Idempotency-Key; nilai 0 atau negatif berarti DefaultIdempotencyTTL.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(bh *bookHandler) {
+		if ttl <= 0 {
+			ttl = DefaultIdempotencyTTL
+		}
+		bh.idempotency.ttl = ttl
+	}
+}
+
+// WithIdempotencyMaxEntries membatasi jumlah Idempotency-Key yang disimpan. Jika penuh,
+// entry yang paling lama dibuat dihapus lebih dulu; nilai 0 atau negatif berarti
+// DefaultIdempotencyMaxEntries.
+func WithIdempotencyMaxEntries(n int) Option {
+	return func(bh *bookHandler) {
+		if n <= 0 {
+			n = DefaultIdempotencyMaxEntries
+		}
+		bh.idempotency.maxEntries = n
+	}
+}
+
+// WithWebhookStore mengaktifkan endpoint /webhooks dengan WebhookStore yang juga dipakai
+// model.RunWebhookDispatcher. Tanpa opsi ini endpoint tersebut dijawab 501 Not Implemented.
+func WithWebhookStore(ws model.WebhookStore) Option {
+	return func(bh *bookHandler) {
+		bh.webhooks = ws
+	}
+}
+
+// WithGraphQLLimits membatasi kedalaman dan kompleksitas query POST /graphql. Query yang
+// melebihinya ditolak dengan 400 Bad Request sebelum dieksekusi; nilai 0 atau negatif
+// berarti DefaultGraphQLMaxDepth dan DefaultGraphQLMaxComplexity.
+func WithGraphQLLimits(maxDepth, maxComplexity int) Option {
+	return func(bh *bookHandler) {
+		if maxDepth <= 0 {
+			maxDepth = DefaultGraphQLMaxDepth
+		}
+		if maxComplexity <= 0 {
+			maxComplexity = DefaultGraphQLMaxComplexity
+		}
+		bh.graphqlMaxDepth = maxDepth
+		bh.graphqlMaxComplexity = maxComplexity
+	}
+}
+
+// WithShutdown menutup stream GET /books/events dan /books/events/ws ketika done ditutup,
+// misalnya dari http.Server.RegisterOnShutdown. http.Server.Shutdown tidak membatalkan
+// context request yang sedang berjalan, sehingga tanpa opsi ini stream baru berakhir
+// saat batas waktu shutdown habis.
+func WithShutdown(done <-chan struct{}) Option {
+	return func(bh *bookHandler) {
+		bh.shutdown = done
+	}
+}
+
+// WithV1Sunset mengatur waktu pada header Sunset di response API v1, yaitu kapan v1
+// berhenti dilayani. Nilai nol berarti DefaultV1Sunset.
+func WithV1Sunset(t time.Time) Option {
+	return func(bh *bookHandler) {
+		if t.IsZero() {
+			t = DefaultV1Sunset
+		}
+		bh.v1Sunset = t
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"book-api/model"
+	"book-api/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	mediaTypeMergePatch = "application/merge-patch+json"
+	mediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// acceptPatch adalah nilai header Accept-Patch untuk format patch yang didukung.
+var acceptPatch = mediaTypeMergePatch + ", " + mediaTypeJSONPatch
+
+// PatchBookHandler menangani permintaan PATCH /books/{id} untuk mengubah sebagian field buku.
+// Body berupa JSON Merge Patch (application/merge-patch+json, RFC 7396) atau
+// JSON Patch (application/json-patch+json, RFC 6902). Patch diterapkan ke data buku
+// saat ini di dalam store secara atomik, lalu hasilnya divalidasi seperti create.
+// Field id, version, dan updated_at tidak boleh diubah.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id" dan dokumen patch.
+//
+// Response:
+//   - 200 OK berisi buku hasil patch, dengan ETag versi baru
+//   - 400 Bad Request jika ID, dokumen patch, atau buku hasil patch tidak valid
+//   - 404 Not Found jika ID buku tidak ditemukan
+//   - 409 Conflict jika operasi "test" pada JSON Patch gagal
+//   - 412 Precondition Failed jika versi tidak cocok dengan If-Match
+//   - 413 Request Entity Too Large jika body melebihi batas ukuran
+//   - 415 Unsupported Media Type jika Content-Type bukan format patch yang didukung
+//   - 428 Precondition Required jika If-Match diwajibkan tetapi tidak dikirim
+//   - 5xx jika store gagal
+func (bh *bookHandler) PatchBookHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid book ID")
+		return
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case mediaTypeMergePatch:
+		apply = utils.MergePatch
+	case mediaTypeJSONPatch:
+		apply = utils.ApplyJSONPatch
+	default:
+		w.Header().Set("Accept-Patch", acceptPatch)
+		utils.WriteError(w, r, http.StatusUnsupportedMediaType, "unsupported patch format")
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" && bh.requireIfMatch {
+		utils.WriteError(w, r, http.StatusPreconditionRequired, "If-Match header is required")
+		return
+	}
+
+	patch, err := utils.ReadBody(w, r, bh.maxBodyBytes)
+	if err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	expectedVersion := 0
+	if ifMatch != "" {
+		expectedVersion, err = bh.resolveIfMatch(r.Context(), id, ifMatch)
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+	}
+
+	m := mapperFor(r)
+	patched, err := bh.service.PatchBook(r.Context(), id, func(current model.Book) (model.Book, error) {
+		if expectedVersion != 0 && current.Version != expectedVersion {
+			return model.Book{}, fmt.Errorf("%w: current version is %d", model.ErrPreconditionFailed, current.Version)
+		}
+		return patchBook(m, current, patch, apply)
+	})
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", bookETag(r, patched))
+	utils.WriteJSON(w, r, http.StatusOK, m.book(patched))
+}
+
+// patchBook menerapkan dokumen patch ke representasi JSON buku (sesuai versi API m) dan
+// memvalidasi hasilnya.
+//
+// Returns:
+//   - Book hasil patch
+//   - error yang membungkus model.ErrConflict jika operasi test gagal, atau
+//     model.ErrValidation jika patch atau buku hasilnya tidak valid
+func patchBook(m bookMapper, current model.Book, patch []byte, apply func(doc, patch []byte) ([]byte, error)) (model.Book, error) {
+	doc, err := json.Marshal(m.book(current))
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	out, err := apply(doc, patch)
+	if err != nil {
+		if errors.Is(err, utils.ErrPatchTestFailed) {
+			return model.Book{}, fmt.Errorf("%w: %v", model.ErrConflict, err)
+		}
+		return model.Book{}, fmt.Errorf("%w: %v", model.ErrValidation, err)
+	}
+
+	input := m.newInput()
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(input); err != nil {
+		return model.Book{}, fmt.Errorf("%w: %v", model.ErrValidation, err)
+	}
+	updated := input.toBook()
+
+	verr := &model.ValidationError{}
+	if err := updated.Validate(); err != nil {
+		verr = err.(*model.ValidationError)
+	}
+	readOnly := func(field string) {
+		verr.Fields = append(verr.Fields, model.FieldError{Field: field, Code: model.RuleReadOnly, Message: field + " is read-only"})
+	}
+	if updated.ID != current.ID {
+		readOnly("id")
+	}
+	if updated.Version != current.Version {
+		readOnly("version")
+	}
+	if !updated.UpdatedAt.Equal(current.UpdatedAt) {
+		readOnly("updated_at")
+	}
+	if len(verr.Fields) > 0 {
+		return model.Book{}, verr
+	}
+	return updated, nil
+}
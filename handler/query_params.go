@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"book-api/model"
+)
+
+// pageMeta adalah informasi paginasi yang dikirim di field "meta" pada GET /books.
+type pageMeta struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// parseBookQuery membaca query string GET /books menjadi model.BookQuery.
+//
+// Parameter yang didukung:
+//   - limit, offset: paginasi berbasis posisi
+//   - cursor: paginasi berbasis cursor opaque dari meta.next_cursor
+//   - sort: daftar field dipisah koma, awalan "-" untuk urutan menurun (contoh: title,-published_year)
+//   - <field>=nilai atau <field>[op]=nilai: filter dengan op eq, ne, gt, gte, lt, lte, contains
+//
+// Returns:
+//   - model.BookQuery hasil parsing (belum dinormalisasi)
+//   - error yang membungkus model.ErrValidation jika parameter tidak valid
+func parseBookQuery(values url.Values) (model.BookQuery, error) {
+	var q model.BookQuery
+
+	for key, vals := range values {
+		value := vals[len(vals)-1]
+		switch key {
+		case "limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return q, fmt.Errorf("%w: limit must be a positive integer", model.ErrValidation)
+			}
+			q.Limit = n
+		case "offset":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return q, fmt.Errorf("%w: offset must be a non-negative integer", model.ErrValidation)
+			}
+			q.Offset = n
+		case "cursor":
+			q.Cursor = value
+		case "sort":
+			for _, part := range strings.Split(value, ",") {
+				part = strings.TrimSpace(part)
+				if part == "" {
+					continue
+				}
+				s := model.SortField{Field: part}
+				if strings.HasPrefix(part, "-") {
+					s = model.SortField{Field: part[1:], Desc: true}
+				}
+				q.Sort = append(q.Sort, s)
+			}
+		default:
+			field, op, err := parseFilterKey(key)
+			if err != nil {
+				return q, err
+			}
+			for _, v := range vals {
+				q.Filters = append(q.Filters, model.Filter{Field: field, Op: op, Value: v})
+			}
+		}
+	}
+
+	return q, nil
+}
+
+// parseFilterKey memecah kunci "field" atau "field[op]".
+func parseFilterKey(key string) (string, model.FilterOp, error) {
+	field, rest, hasOp := strings.Cut(key, "[")
+	if !hasOp {
+		return field, model.OpEq, nil
+	}
+	op, ok := strings.CutSuffix(rest, "]")
+	if !ok || op == "" || field == "" {
+		return "", "", fmt.Errorf("%w: malformed query parameter %q", model.ErrValidation, key)
+	}
+	return field, model.FilterOp(op), nil
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"book-api/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListTrashHandler menangani permintaan GET /books/trash untuk melihat buku yang sudah
+// dihapus beserta waktu penghapusannya.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang berisi informasi request dari client.
+//
+// Response:
+//   - 200 OK berisi buku di trash, yang paling baru dihapus lebih dulu
+//   - 5xx jika store gagal
+func (bh *bookHandler) ListTrashHandler(w http.ResponseWriter, r *http.Request) {
+	trash, err := bh.service.ListTrash(r.Context())
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	m := mapperFor(r)
+	out := make([]interface{}, len(trash))
+	for i, t := range trash {
+		out[i] = m.trashedBook(t)
+	}
+	utils.WriteJSONWithMeta(w, r, http.StatusOK, out, map[string]int{"total": len(trash)})
+}
+
+// RestoreBookHandler menangani permintaan POST /books/{id}/restore untuk mengembalikan
+// buku dari trash. Versi buku naik sehingga ETag lama tidak lagi berlaku.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id".
+//
+// Response:
+//   - 200 OK berisi buku yang dipulihkan, dengan header ETag versi barunya
+//   - 400 Bad Request jika ID tidak valid
+//   - 404 Not Found jika buku tidak ada di trash
+//   - 5xx jika store gagal
+func (bh *bookHandler) RestoreBookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid book ID")
+		return
+	}
+
+	restored, err := bh.service.RestoreBook(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", bookETag(r, restored))
+	utils.WriteJSON(w, r, http.StatusOK, mapperFor(r).book(restored))
+}
+
+// authorizeAdmin memastikan request membawa token admin. Jika tidak, response error
+// sudah ditulis dan false dikembalikan.
+func (bh *bookHandler) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if bh.adminToken == "" {
+		utils.WriteError(w, r, http.StatusForbidden, "hard delete is disabled")
+		return false
+	}
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="book-api"`)
+		utils.WriteError(w, r, http.StatusUnauthorized, "admin token is required")
+		return false
+	}
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(bh.adminToken)) != 1 {
+		utils.WriteError(w, r, http.StatusForbidden, "invalid admin token")
+		return false
+	}
+	return true
+}
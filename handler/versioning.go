@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"book-api/model"
+)
+
+// APIVersion adalah versi representasi REST API, dipakai sebagai prefix path (/v1, /v2)
+// dan nilai header API-Version.
+type APIVersion int
+
+const (
+	// APIVersion1 adalah representasi awal Book (field datar, sama dengan model.Book).
+	// Sudah deprecated sejak v2 dirilis.
+	APIVersion1 APIVersion = 1
+	// APIVersion2 adalah representasi Book dengan author dan publication sebagai objek.
+	APIVersion2 APIVersion = 2
+)
+
+// APIVersions adalah semua versi yang didukung, dari yang terlama.
+var APIVersions = []APIVersion{APIVersion1, APIVersion2}
+
+// APIVersionHeader dikirim client untuk memilih versi pada path tanpa prefix versi
+// (contoh /books), dan dikirim balik di setiap response berisi versi yang dipakai.
+const APIVersionHeader = "API-Version"
+
+var (
+	// v1DeprecatedAt adalah waktu v1 dinyatakan deprecated, yaitu saat v2 dirilis.
+	v1DeprecatedAt = time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	// DefaultV1Sunset adalah waktu default v1 berhenti dilayani (header Sunset).
+	DefaultV1Sunset = v1DeprecatedAt.AddDate(1, 0, 0)
+)
+
+// Prefix mengembalikan prefix path versi, contoh "/v2".
+func (v APIVersion) Prefix() string {
+	return "/v" + strconv.Itoa(int(v))
+}
+
+// ParseAPIVersion membaca nilai header API-Version seperti "2" atau "v2".
+func ParseAPIVersion(s string) (APIVersion, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "v"))
+	if err != nil {
+		return 0, false
+	}
+	for _, v := range APIVersions {
+		if int(v) == n {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+type apiVersionKey struct{}
+
+// apiVersionFrom mengembalikan versi API request; APIVersion1 jika handler dipanggil
+// tanpa VersionMiddleware.
+func apiVersionFrom(ctx context.Context) APIVersion {
+	if v, ok := ctx.Value(apiVersionKey{}).(APIVersion); ok {
+		return v
+	}
+	return APIVersion1
+}
+
+// VersionMiddleware menandai request sebagai versi v sehingga handler memakai
+// representasi versi tersebut, dan menambahkan header API-Version pada response.
+// Response v1 juga berisi header Deprecation (RFC 9745), Sunset (RFC 8594, lihat
+// WithV1Sunset), dan Link ke versi penggantinya.
+func (bh *bookHandler) VersionMiddleware(v APIVersion) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set(APIVersionHeader, strconv.Itoa(int(v)))
+			if v == APIVersion1 {
+				h.Set("Deprecation", "@"+strconv.FormatInt(v1DeprecatedAt.Unix(), 10))
+				h.Set("Sunset", bh.v1Sunset.UTC().Format(http.TimeFormat))
+				h.Add("Link", "<"+APIVersion2.Prefix()+"/books>; rel=\"successor-version\"")
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, v)))
+		})
+	}
+}
+
+// bookMapper mengubah data buku ke dan dari representasi JSON satu versi API, sehingga
+// handler yang sama bisa melayani semua versi.
+type bookMapper interface {
+	book(b model.Book) interface{}
+	trashedBook(t model.TrashedBook) interface{}
+	searchHit(h model.SearchHit) interface{}
+	// revision mengubah revision beserta perubahan field-nya.
+	revision(r model.Revision) interface{}
+	changeEvent(ev model.ChangeEvent) interface{}
+	// newInput mengembalikan DTO kosong untuk body POST dan PUT.
+	newInput() bookInput
+	// newBatchRequest mengembalikan DTO kosong untuk body POST /books/batch.
+	newBatchRequest() batchInput
+	// field mengubah nama field model.Book (seperti pada FieldError) menjadi path field
+	// di representasi versi ini.
+	field(name string) string
+	// queryField kebalikan dari field, untuk nama field pada filter dan sort.
+	queryField(name string) string
+}
+
+// bookInput adalah DTO body request yang bisa diubah menjadi model.Book.
+type bookInput interface {
+	toBook() model.Book
+}
+
+// mapperFor mengembalikan bookMapper untuk versi API request.
+func mapperFor(r *http.Request) bookMapper {
+	if apiVersionFrom(r.Context()) == APIVersion2 {
+		return v2Mapper{}
+	}
+	return v1Mapper{}
+}
+
+// mapFieldErrors mengganti nama field pada rincian validasi sesuai representasi m.
+// Hanya segmen terakhir path yang dipetakan, sehingga path bertingkat seperti
+// "operations[0].book.published_year" ikut berubah.
+func mapFieldErrors(m bookMapper, fields []model.FieldError) []model.FieldError {
+	out := make([]model.FieldError, len(fields))
+	for i, f := range fields {
+		prefix, name := "", f.Field
+		if dot := strings.LastIndex(f.Field, "."); dot >= 0 {
+			prefix, name = f.Field[:dot+1], f.Field[dot+1:]
+		}
+		if mapped := m.field(name); mapped != name {
+			if strings.HasPrefix(f.Message, f.Field) {
+				f.Message = prefix + mapped + strings.TrimPrefix(f.Message, f.Field)
+			} else if strings.HasPrefix(f.Message, name) {
+				f.Message = mapped + strings.TrimPrefix(f.Message, name)
+			}
+			f.Field = prefix + mapped
+		}
+		out[i] = f
+	}
+	return out
+}
+
+// mapFieldChanges mengganti nama field pada daftar perubahan sesuai representasi m.
+func mapFieldChanges(m bookMapper, changes []model.FieldChange) []model.FieldChange {
+	out := make([]model.FieldChange, len(changes))
+	for i, c := range changes {
+		c.Field = m.field(c.Field)
+		out[i] = c
+	}
+	return out
+}
+
+// mapQueryFields mengubah nama field filter dan sort dari representasi m ke nama field model.
+func mapQueryFields(m bookMapper, q model.BookQuery) model.BookQuery {
+	for i := range q.Filters {
+		q.Filters[i].Field = m.queryField(q.Filters[i].Field)
+	}
+	for i := range q.Sort {
+		q.Sort[i].Field = m.queryField(q.Sort[i].Field)
+	}
+	return q
+}
+
+// v1Mapper memakai model.Book apa adanya.
+type v1Mapper struct{}
+
+// bookV1 adalah body request v1; sama dengan model.Book.
+type bookV1 model.Book
+
+func (b *bookV1) toBook() model.Book { return model.Book(*b) }
+
+func (v1Mapper) book(b model.Book) interface{}                { return b }
+func (v1Mapper) trashedBook(t model.TrashedBook) interface{}  { return t }
+func (v1Mapper) searchHit(h model.SearchHit) interface{}      { return h }
+func (v1Mapper) revision(r model.Revision) interface{}        { return newRevisionResponse(r) }
+func (v1Mapper) changeEvent(ev model.ChangeEvent) interface{} { return ev }
+func (v1Mapper) newInput() bookInput                          { return &bookV1{} }
+func (v1Mapper) newBatchRequest() batchInput                  { return &batchRequest{} }
+func (v1Mapper) field(name string) string                     { return name }
+func (v1Mapper) queryField(name string) string                { return name }
+
+// v2Mapper memakai bookV2.
+type v2Mapper struct{}
+
+// bookV2 adalah representasi Book pada API v2. Pada body request, id dan updated_at
+// diabaikan dan version berarti versi yang diharapkan seperti pada v1, sehingga response
+// GET bisa dikirim ulang lewat PUT.
+type bookV2 struct {
+	ID          int           `json:"id"`
+	Title       string        `json:"title"`
+	Author      authorV2      `json:"author"`
+	Publication publicationV2 `json:"publication"`
+	// ISBN bernilai null jika buku tidak memiliki ISBN.
+	ISBN      *string   `json:"isbn"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type authorV2 struct {
+	Name string `json:"name"`
+}
+
+type publicationV2 struct {
+	Year int `json:"year"`
+}
+
+// trashedBookV2 adalah buku di trash pada API v2.
+type trashedBookV2 struct {
+	bookV2
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// searchHitV2 adalah hasil pencarian pada API v2; kunci highlights memakai path field v2.
+type searchHitV2 struct {
+	Book       bookV2            `json:"book"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// revisionV2 adalah revision pada API v2; before, after, dan nama field pada changes
+// memakai representasi v2.
+type revisionV2 struct {
+	BookID    int                 `json:"book_id"`
+	Rev       int                 `json:"rev"`
+	Action    string              `json:"action"`
+	Actor     string              `json:"actor,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+	At        time.Time           `json:"at"`
+	Before    *bookV2             `json:"before"`
+	After     *bookV2             `json:"after"`
+	Changes   []model.FieldChange `json:"changes"`
+}
+
+// changeEventV2 adalah event change feed pada API v2.
+type changeEventV2 struct {
+	ID     string    `json:"id"`
+	Seq    uint64    `json:"seq"`
+	Type   string    `json:"type"`
+	BookID int       `json:"book_id"`
+	Book   *bookV2   `json:"book"`
+	At     time.Time `json:"at"`
+}
+
+// batchRequestV2 adalah body POST /v2/books/batch.
+type batchRequestV2 struct {
+	Operations []batchOpV2 `json:"operations"`
+}
+
+// batchOpV2 adalah model.BatchOp dengan buku dalam representasi v2.
+type batchOpV2 struct {
+	Op      string  `json:"op"`
+	ID      int     `json:"id,omitempty"`
+	Version int     `json:"version,omitempty"`
+	Book    *bookV2 `json:"book,omitempty"`
+}
+
+func (req *batchRequestV2) ops() []model.BatchOp {
+	ops := make([]model.BatchOp, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = model.BatchOp{Op: op.Op, ID: op.ID, Version: op.Version}
+		if op.Book != nil {
+			book := op.Book.toBook()
+			ops[i].Book = &book
+		}
+	}
+	return ops
+}
+
+// v2Fields memetakan nama field model.Book ke path field bookV2 yang berbeda.
+var v2Fields = map[string]string{
+	"author":         "author.name",
+	"published_year": "publication.year",
+}
+
+func newBookV2(b model.Book) bookV2 {
+	dto := bookV2{
+		ID:          b.ID,
+		Title:       b.Title,
+		Author:      authorV2{Name: b.Author},
+		Publication: publicationV2{Year: b.PublishedYear},
+		Version:     b.Version,
+		UpdatedAt:   b.UpdatedAt,
+	}
+	if b.ISBN != "" {
+		isbn := b.ISBN
+		dto.ISBN = &isbn
+	}
+	return dto
+}
+
+// optionalBookV2 mengubah b menjadi bookV2; nil tetap nil.
+func optionalBookV2(b *model.Book) *bookV2 {
+	if b == nil {
+		return nil
+	}
+	dto := newBookV2(*b)
+	return &dto
+}
+
+func (b *bookV2) toBook() model.Book {
+	book := model.Book{
+		ID:            b.ID,
+		Title:         b.Title,
+		Author:        b.Author.Name,
+		PublishedYear: b.Publication.Year,
+		Version:       b.Version,
+		UpdatedAt:     b.UpdatedAt,
+	}
+	if b.ISBN != nil {
+		book.ISBN = *b.ISBN
+	}
+	return book
+}
+
+func (v2Mapper) book(b model.Book) interface{} { return newBookV2(b) }
+
+func (v2Mapper) trashedBook(t model.TrashedBook) interface{} {
+	return trashedBookV2{bookV2: newBookV2(t.Book), DeletedAt: t.DeletedAt}
+}
+
+func (m v2Mapper) searchHit(h model.SearchHit) interface{} {
+	highlights := make(map[string]string, len(h.Highlights))
+	for field, text := range h.Highlights {
+		highlights[m.field(field)] = text
+	}
+	return searchHitV2{Book: newBookV2(h.Book), Score: h.Score, Highlights: highlights}
+}
+
+func (m v2Mapper) revision(r model.Revision) interface{} {
+	return revisionV2{
+		BookID:    r.BookID,
+		Rev:       r.Rev,
+		Action:    r.Action,
+		Actor:     r.Actor,
+		RequestID: r.RequestID,
+		At:        r.At,
+		Before:    optionalBookV2(r.Before),
+		After:     optionalBookV2(r.After),
+		Changes:   mapFieldChanges(m, model.DiffBooks(r.Before, r.After)),
+	}
+}
+
+func (v2Mapper) changeEvent(ev model.ChangeEvent) interface{} {
+	return changeEventV2{ID: ev.ID, Seq: ev.Seq, Type: ev.Type, BookID: ev.BookID, Book: optionalBookV2(ev.Book), At: ev.At}
+}
+
+func (v2Mapper) newInput() bookInput { return &bookV2{} }
+
+func (v2Mapper) newBatchRequest() batchInput { return &batchRequestV2{} }
+
+func (v2Mapper) field(name string) string {
+	if mapped, ok := v2Fields[name]; ok {
+		return mapped
+	}
+	return name
+}
+
+func (v2Mapper) queryField(name string) string {
+	for field, mapped := range v2Fields {
+		if mapped == name {
+			return field
+		}
+	}
+	return name
+}
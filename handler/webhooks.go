@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"book-api/model"
+	"book-api/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// webhookRequest adalah body POST dan PUT /webhooks/{id}.
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	// Active bernilai true jika tidak diisi.
+	Active *bool `json:"active"`
+}
+
+// webhook mengubah request menjadi model.Webhook.
+func (req webhookRequest) webhook() model.Webhook {
+	wh := model.Webhook{URL: req.URL, Secret: req.Secret, Events: req.Events, Active: true}
+	if req.Active != nil {
+		wh.Active = *req.Active
+	}
+	return wh
+}
+
+// CreateWebhookHandler menangani permintaan POST /webhooks untuk mendaftarkan webhook.
+// Secret dibuat acak jika tidak diisi, dan hanya dikirim di response ini.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request dengan body {"url", "secret", "events", "active"}.
+//
+// Response:
+//   - 201 Created berisi webhook beserta secret-nya
+//   - 400 Bad Request jika body tidak valid (rincian per field di "details")
+//   - 413 Request Entity Too Large jika body melebihi batas ukuran
+//   - 415 Unsupported Media Type jika Content-Type bukan application/json
+//   - 501 Not Implemented jika webhook tidak dikonfigurasi
+func (bh *bookHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !bh.webhooksEnabled(w, r) {
+		return
+	}
+	var req webhookRequest
+	if err := utils.DecodeJSON(w, r, &req, bh.maxBodyBytes); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	created, err := bh.webhooks.AddWebhook(r.Context(), req.webhook())
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	utils.WriteJSON(w, r, http.StatusCreated, created)
+}
+
+// ListWebhooksHandler menangani permintaan GET /webhooks. Secret tidak ikut dikirim.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang berisi informasi request dari client.
+//
+// Response:
+//   - 200 OK berisi semua webhook berurutan menurut ID
+//   - 501 Not Implemented jika webhook tidak dikonfigurasi
+func (bh *bookHandler) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if !bh.webhooksEnabled(w, r) {
+		return
+	}
+	hooks, err := bh.webhooks.ListWebhooks(r.Context())
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	for i := range hooks {
+		hooks[i] = hooks[i].Redacted()
+	}
+	utils.WriteJSONWithMeta(w, r, http.StatusOK, hooks, map[string]int{"total": len(hooks)})
+}
+
+// GetWebhookHandler menangani permintaan GET /webhooks/{id}. Secret tidak ikut dikirim.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id".
+//
+// Response:
+//   - 200 OK berisi webhook
+//   - 400 Bad Request jika ID tidak valid
+//   - 404 Not Found jika webhook tidak ditemukan
+//   - 501 Not Implemented jika webhook tidak dikonfigurasi
+func (bh *bookHandler) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := bh.webhookID(w, r)
+	if !ok {
+		return
+	}
+	wh, err := bh.webhooks.GetWebhook(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	utils.WriteJSON(w, r, http.StatusOK, wh.Redacted())
+}
+
+// UpdateWebhookHandler menangani permintaan PUT /webhooks/{id} untuk mengganti URL,
+// jenis event, status aktif, dan (opsional) secret webhook. Secret kosong berarti
+// secret lama tetap dipakai.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id" dan body seperti POST /webhooks.
+//
+// Response:
+//   - 200 OK berisi webhook yang sudah diperbarui, tanpa secret
+//   - 400 Bad Request jika ID atau body tidak valid
+//   - 404 Not Found jika webhook tidak ditemukan
+//   - 413 Request Entity Too Large jika body melebihi batas ukuran
+//   - 415 Unsupported Media Type jika Content-Type bukan application/json
+//   - 501 Not Implemented jika webhook tidak dikonfigurasi
+func (bh *bookHandler) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := bh.webhookID(w, r)
+	if !ok {
+		return
+	}
+	var req webhookRequest
+	if err := utils.DecodeJSON(w, r, &req, bh.maxBodyBytes); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	updated, err := bh.webhooks.UpdateWebhook(r.Context(), id, req.webhook())
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	utils.WriteJSON(w, r, http.StatusOK, updated.Redacted())
+}
+
+// DeleteWebhookHandler menangani permintaan DELETE /webhooks/{id}. Event yang masih
+// antre atau sedang di-retry untuk webhook ini tidak dikirim lagi.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id".
+//
+// Response:
+//   - 204 No Content jika berhasil
+//   - 400 Bad Request jika ID tidak valid
+//   - 404 Not Found jika webhook tidak ditemukan
+//   - 501 Not Implemented jika webhook tidak dikonfigurasi
+func (bh *bookHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := bh.webhookID(w, r)
+	if !ok {
+		return
+	}
+	if err := bh.webhooks.DeleteWebhook(r.Context(), id); err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveriesHandler menangani permintaan GET /webhooks/{id}/deliveries untuk
+// melihat delivery log: setiap attempt pengiriman beserta status HTTP, error, dan durasinya.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id".
+//
+// Response:
+//   - 200 OK berisi attempt terakhir, yang terbaru lebih dulu
+//   - 400 Bad Request jika ID tidak valid
+//   - 404 Not Found jika webhook tidak ditemukan
+//   - 501 Not Implemented jika webhook tidak dikonfigurasi
+func (bh *bookHandler) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := bh.webhookID(w, r)
+	if !ok {
+		return
+	}
+	deliveries, err := bh.webhooks.ListDeliveries(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	utils.WriteJSONWithMeta(w, r, http.StatusOK, deliveries, map[string]int{"total": len(deliveries)})
+}
+
+// ListWebhookDeadLettersHandler menangani permintaan GET /webhooks/{id}/dead-letters untuk
+// melihat event yang gagal dikirim setelah semua retry habis.
+//
+// Params:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: *http.Request yang mengandung parameter URL "id".
+//
+// Response:
+//   - 200 OK berisi dead letter beserta event lengkapnya, yang terbaru lebih dulu
+//   - 400 Bad Request jika ID tidak valid
+//   - 404 Not Found jika webhook tidak ditemukan
+//   - 501 Not Implemented jika webhook tidak dikonfigurasi
+func (bh *bookHandler) ListWebhookDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := bh.webhookID(w, r)
+	if !ok {
+		return
+	}
+	dead, err := bh.webhooks.ListDeadLetters(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	utils.WriteJSONWithMeta(w, r, http.StatusOK, dead, map[string]int{"total": len(dead)})
+}
+
+// webhooksEnabled memastikan WebhookStore dikonfigurasi. Jika tidak, response error
+// sudah ditulis dan false dikembalikan.
+func (bh *bookHandler) webhooksEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if bh.webhooks == nil {
+		utils.WriteError(w, r, http.StatusNotImplemented, "webhooks are not configured")
+		return false
+	}
+	return true
+}
+
+// webhookID membaca parameter URL "id" untuk endpoint /webhooks/{id}. Jika gagal,
+// response error sudah ditulis dan ok bernilai false.
+func (bh *bookHandler) webhookID(w http.ResponseWriter, r *http.Request) (id int, ok bool) {
+	if !bh.webhooksEnabled(w, r) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, r, http.StatusBadRequest, "invalid webhook ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeWebhookError memetakan error dari WebhookStore ke status HTTP yang sesuai.
+func writeWebhookError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *model.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		p := utils.NewProblem(r, http.StatusBadRequest, "invalid webhook")
+		p.Type = problemTypeValidation
+		p.Title = "Validation Failed"
+		p.Extensions = map[string]interface{}{"details": verr.Fields}
+		utils.WriteErrorResponse(w, r, p)
+	case errors.Is(err, model.ErrWebhookNotFound):
+		utils.WriteError(w, r, http.StatusNotFound, err.Error())
+	default:
+		writeStoreError(w, r, err)
+	}
+}
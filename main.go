@@ -1,18 +1,200 @@
 package main
 
 import (
+	"book-api/handler"
+	"book-api/model"
 	"book-api/router"
+	"book-api/rpc"
+	"book-api/utils"
+	"context"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 )
 
 func main() {
-	r := router.SetupRouter()
+	if err := run(); err != nil {
+		fmt.Printf("Failed to start server: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run menjalankan server HTTP dan gRPC sampai dimatikan oleh sinyal. Semua error
+// dikembalikan ke main agar defer (misalnya menutup store) tetap berjalan sebelum os.Exit.
+func run() error {
+	storeKind := flag.String("store", envOr("BOOK_STORE", "memory"), "jenis store: memory, file, atau sqlite")
+	dataDir := flag.String("data-dir", envOr("BOOK_DATA_DIR", "data"), "direktori data untuk store file")
+	syncMode := flag.String("sync", envOr("BOOK_SYNC_MODE", "always"), "mode fsync store file: always, interval, atau none")
+	dbPath := flag.String("db", envOr("BOOK_DB_PATH", "books.db"), "path database untuk store sqlite")
+	requireIfMatch := flag.Bool("require-if-match", envOr("BOOK_REQUIRE_IF_MATCH", "false") == "true", "wajibkan header If-Match pada PUT, PATCH, dan DELETE")
+	maxBodyBytes := flag.Int64("max-body-bytes", envInt64("BOOK_MAX_BODY_BYTES", utils.DefaultMaxBodyBytes), "batas ukuran body request dalam byte")
+	maxImportBytes := flag.Int64("max-import-bytes", envInt64("BOOK_MAX_IMPORT_BYTES", handler.DefaultMaxImportBytes), "batas ukuran file POST /books/import dalam byte")
+	idempotencyTTL := flag.Duration("idempotency-ttl", envDuration("BOOK_IDEMPOTENCY_TTL", handler.DefaultIdempotencyTTL), "lama response POST /books disimpan per Idempotency-Key")
+	idempotencyMaxKeys := flag.Int("idempotency-max-keys", int(envInt64("BOOK_IDEMPOTENCY_MAX_KEYS", handler.DefaultIdempotencyMaxEntries)), "jumlah Idempotency-Key maksimum yang disimpan")
+	adminToken := flag.String("admin-token", os.Getenv("BOOK_ADMIN_TOKEN"), "token Bearer untuk hard delete (DELETE /books/{id}?hard=true); kosong untuk menonaktifkan")
+	trashRetention := flag.Duration("trash-retention", envDuration("BOOK_TRASH_RETENTION", 30*24*time.Hour), "lama buku disimpan di trash sebelum dihapus permanen; 0 untuk menonaktifkan purger")
+	trashPurgeInterval := flag.Duration("trash-purge-interval", envDuration("BOOK_TRASH_PURGE_INTERVAL", time.Hour), "jeda antar pembersihan trash")
+	webhookMaxAttempts := flag.Int("webhook-max-attempts", int(envInt64("BOOK_WEBHOOK_MAX_ATTEMPTS", 5)), "jumlah attempt pengiriman webhook sebelum event masuk dead letter")
+	webhookTimeout := flag.Duration("webhook-timeout", envDuration("BOOK_WEBHOOK_TIMEOUT", 10*time.Second), "batas waktu satu request webhook")
+	graphqlMaxDepth := flag.Int("graphql-max-depth", int(envInt64("BOOK_GRAPHQL_MAX_DEPTH", handler.DefaultGraphQLMaxDepth)), "kedalaman field maksimum query GraphQL")
+	graphqlMaxComplexity := flag.Int("graphql-max-complexity", int(envInt64("BOOK_GRAPHQL_MAX_COMPLEXITY", handler.DefaultGraphQLMaxComplexity)), "kompleksitas maksimum query GraphQL")
+	grpcAddr := flag.String("grpc-addr", envOr("BOOK_GRPC_ADDR", ":9090"), "alamat server gRPC; \"off\" untuk menonaktifkan")
+	v1Sunset := flag.String("v1-sunset", envOr("BOOK_V1_SUNSET", handler.DefaultV1Sunset.Format(time.DateOnly)), "tanggal (YYYY-MM-DD) pada header Sunset response API v1")
+	shutdownTimeout := flag.Duration("shutdown-timeout", envDuration("BOOK_SHUTDOWN_TIMEOUT", 15*time.Second), "batas waktu menunggu request berjalan selesai saat server dimatikan")
+	dev := flag.Bool("dev", envOr("BOOK_DEV", "false") == "true", "mode development: catat response yang tidak sesuai dokumen OpenAPI")
+	flag.Parse()
+
+	sunset, err := time.Parse(time.DateOnly, *v1Sunset)
+	if err != nil {
+		return fmt.Errorf("invalid -v1-sunset: %w", err)
+	}
+
+	store, err := openStore(*storeKind, *dataDir, *syncMode, *dbPath)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	if closer, ok := store.(model.ClosableBookStore); ok {
+		defer closer.Close()
+	}
+
+	// Store file dan sqlite menyimpan webhook di storage yang sama agar langganan dan
+	// riwayat pengirimannya tidak hilang saat restart.
+	webhooks := model.NewWebhookStore()
+	if provider, ok := store.(model.WebhookProvider); ok {
+		webhooks = provider.Webhooks()
+	}
+	// shuttingDown ditutup saat Shutdown dimulai agar stream event berakhir tanpa menunggu
+	// batas waktu; Shutdown sendiri tidak membatalkan context request yang berjalan.
+	shuttingDown := make(chan struct{})
+	setupRouter := router.SetupRouterWithStore
+	if *dev {
+		setupRouter = router.SetupDevRouterWithStore
+	}
+	r := setupRouter(store,
+		handler.WithRequireIfMatch(*requireIfMatch),
+		handler.WithMaxBodyBytes(*maxBodyBytes),
+		handler.WithMaxImportBytes(*maxImportBytes),
+		handler.WithIdempotencyTTL(*idempotencyTTL),
+		handler.WithIdempotencyMaxEntries(*idempotencyMaxKeys),
+		handler.WithAdminToken(*adminToken),
+		handler.WithWebhookStore(webhooks),
+		handler.WithGraphQLLimits(*graphqlMaxDepth, *graphqlMaxComplexity),
+		handler.WithV1Sunset(sunset),
+		handler.WithShutdown(shuttingDown),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *trashRetention > 0 && *trashPurgeInterval > 0 {
+		go model.RunTrashPurger(ctx, store, *trashRetention, *trashPurgeInterval)
+	}
+	if notifier, ok := store.(model.ChangeNotifier); ok {
+		go model.RunWebhookDispatcher(ctx, notifier.Changes(), webhooks, model.WebhookDispatcherOptions{
+			Client:      &http.Client{Timeout: *webhookTimeout},
+			MaxAttempts: *webhookMaxAttempts,
+		})
+	}
 
 	port := ":8080"
+	srv := &http.Server{Addr: port, Handler: r}
+	srv.RegisterOnShutdown(func() { close(shuttingDown) })
+
+	// Server gRPC memakai store yang sama di port terpisah.
+	grpcServer := rpc.NewGRPCServer(store, rpc.WithRequireVersion(*requireIfMatch), rpc.WithShutdown(shuttingDown))
+	if *grpcAddr != "off" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return fmt.Errorf("listen for gRPC: %w", err)
+		}
+		fmt.Printf("gRPC server running at %s\n", lis.Addr())
+		go grpcServer.Serve(lis)
+	}
+
+	// Saat menerima sinyal, server berhenti menerima koneksi baru dan menunggu request
+	// yang sedang berjalan selesai (paling lama -shutdown-timeout); setelah itu store
+	// ditutup oleh defer di atas sehingga sempat di-flush.
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+
+		ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer cancel()
+		grpcStopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+		if err := srv.Shutdown(ctx); err != nil {
+			// Request yang belum selesai dalam batas waktu diputus paksa.
+			fmt.Printf("Graceful shutdown incomplete: %v\n", err)
+			srv.Close()
+		}
+		select {
+		case <-grpcStopped:
+		case <-ctx.Done():
+			// RPC yang belum selesai dalam batas waktu diputus paksa.
+			grpcServer.Stop()
+			<-grpcStopped
+		}
+	}()
+
 	fmt.Printf("Server running at http://localhost%s\n", port)
 
-	if err := http.ListenAndServe(port, r); err != nil {
-		fmt.Printf("Failed to start server: %v\n", err)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	<-stopped
+	return nil
+}
+
+// openStore membuat BookStore sesuai konfigurasi.
+func openStore(kind, dataDir, syncMode, dbPath string) (model.BookStore, error) {
+	switch kind {
+	case "memory":
+		return model.NewBookStore(), nil
+	case "file":
+		mode, err := model.ParseSyncMode(syncMode)
+		if err != nil {
+			return nil, err
+		}
+		return model.NewFileBookStore(model.FileStoreOptions{Dir: dataDir, SyncMode: mode})
+	case "sqlite":
+		return model.OpenSQLiteBookStore(dbPath)
+	}
+	return nil, fmt.Errorf("unknown store %q", kind)
+}
+
+// envOr mengembalikan nilai environment variable key, atau fallback jika kosong.
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// envInt64 mengembalikan nilai environment variable key sebagai int64, atau fallback
+// jika kosong atau bukan angka.
+func envInt64(key string, fallback int64) int64 {
+	if v, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil {
+		return v
+	}
+	return fallback
+}
+
+// envDuration mengembalikan nilai environment variable key sebagai time.Duration
+// (contoh "12h"), atau fallback jika kosong atau tidak valid.
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return v
 	}
+	return fallback
 }
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"book-api/model"
+	"book-api/utils"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// ActorHeader berisi identitas pengguna yang melakukan perubahan, biasanya diisi
+	// oleh gateway atau proxy autentikasi di depan API.
+	ActorHeader = "X-Actor"
+	// AnonymousActor dicatat sebagai actor jika header ActorHeader tidak dikirim.
+	AnonymousActor = "anonymous"
+
+	maxActorLength = 100
+)
+
+// AuditMiddleware menyimpan actor (dari header X-Actor) dan request ID ke context
+// agar store bisa mencatatnya pada setiap revision buku. Harus dipasang setelah
+// middleware RequestID milik chi. Actor yang terlalu panjang atau berisi karakter
+// kontrol ditolak dengan 400 Bad Request.
+func AuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor, err := ParseActor(r.Header.Get(ActorHeader))
+		if err != nil {
+			utils.WriteError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ctx := model.WithAudit(r.Context(), model.Audit{
+			Actor:     actor,
+			RequestID: chimiddleware.GetReqID(r.Context()),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ParseActor merapikan nilai header X-Actor (juga metadata x-actor pada gRPC). Nilai
+// kosong menjadi AnonymousActor; nilai yang terlalu panjang atau berisi karakter kontrol
+// dikembalikan sebagai error.
+func ParseActor(value string) (string, error) {
+	actor := strings.TrimSpace(value)
+	if utf8.RuneCountInString(actor) > maxActorLength || strings.ContainsFunc(actor, unicode.IsControl) {
+		return "", fmt.Errorf("%s must be at most %d printable characters", ActorHeader, maxActorLength)
+	}
+	if actor == "" {
+		actor = AnonymousActor
+	}
+	return actor, nil
+}
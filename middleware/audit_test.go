@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"book-api/model"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	var got model.Audit
+	handler := chimiddleware.RequestID(AuditMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = model.AuditFromContext(r.Context())
+	})))
+
+	tests := []struct {
+		name       string
+		actor      string
+		wantStatus int
+		wantActor  string
+	}{
+		{"actor header", "  alice ", http.StatusOK, "alice"},
+		{"anonymous", "", http.StatusOK, AnonymousActor},
+		{"too long", strings.Repeat("a", maxActorLength+1), http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = model.Audit{}
+			req := httptest.NewRequest(http.MethodPost, "/books", nil)
+			req.Header.Set(ActorHeader, tt.actor)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if got.Actor != tt.wantActor {
+				t.Errorf("Expected actor %q, got %q", tt.wantActor, got.Actor)
+			}
+			if tt.wantStatus == http.StatusOK && got.RequestID == "" {
+				t.Error("Expected request ID in audit context")
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"book-api/utils"
+)
+
+// ContentNegotiationMiddleware menolak request dengan 406 Not Acceptable jika header
+// Accept tidak menerima satu pun format response yang terdaftar (lihat
+// utils.NegotiateFormat). Penolakan dilakukan sebelum handler berjalan agar request
+// yang mengubah data tidak diproses lalu gagal dikirim.
+func ContentNegotiationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := utils.NegotiateFormat(r); !ok {
+			var types []string
+			for _, f := range utils.Formats() {
+				types = append(types, f.MediaType)
+			}
+			utils.WriteError(w, r, http.StatusNotAcceptable, "Accept must allow one of "+strings.Join(types, ", "))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"book-api/model"
+	"book-api/openapi"
+	"book-api/utils"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// problemTypeRequestValidation adalah type problem+json untuk request yang tidak sesuai
+// dokumen OpenAPI; sama dengan type untuk data buku yang gagal validasi di handler.
+const problemTypeRequestValidation = utils.ProblemTypeBase + "validation-error"
+
+// maxValidatedBodyBytes adalah ukuran body maksimum yang diperiksa. Body yang lebih besar
+// diteruskan apa adanya sehingga batas ukuran tetap ditangani handler.
+const maxValidatedBodyBytes = utils.DefaultMaxBodyBytes
+
+// RequestValidationMiddleware memeriksa parameter path, query, dan header serta body JSON
+// setiap request terhadap operasi yang cocok di dokumen OpenAPI. Request yang tidak sesuai
+// ditolak dengan 400 Bad Request beserta semua pelanggarannya di "details". Request ke path
+// yang tidak ada di dokumen, body yang bukan JSON valid, dan Content-Type yang tidak
+// dideklarasikan diteruskan ke handler agar ditangani dengan error yang biasa.
+func RequestValidationMiddleware(doc *openapi.Document) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, _, pathParams := doc.FindOperation(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			errs := validateParameters(doc, op, r, pathParams)
+			bodyErrs, err := validateBody(doc, op, r)
+			if err != nil {
+				utils.WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			errs = append(errs, bodyErrs...)
+			if len(errs) > 0 {
+				p := utils.NewProblem(r, http.StatusBadRequest, "request does not match the API specification")
+				p.Type = problemTypeRequestValidation
+				p.Title = "Validation Failed"
+				p.Extensions = map[string]interface{}{"details": errs}
+				utils.WriteErrorResponse(w, r, p)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validateParameters memeriksa parameter path, query, dan header. Nama field pada
+// pelanggaran diawali lokasinya, contoh "query.limit" atau "path.id".
+func validateParameters(doc *openapi.Document, op *openapi.Operation, r *http.Request, pathParams map[string]string) []model.FieldError {
+	var errs []model.FieldError
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		var values []string
+		switch param.In {
+		case "path":
+			if v, ok := pathParams[param.Name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = query[param.Name]
+		case "header":
+			values = r.Header.Values(param.Name)
+		}
+
+		field := param.In + "." + param.Name
+		if len(values) == 0 {
+			if param.Required {
+				errs = append(errs, model.FieldError{Field: field, Code: model.RuleRequired, Message: field + " is required"})
+			}
+			continue
+		}
+		for _, raw := range values {
+			v, ok := openapi.ParseParameter(param.Schema, raw)
+			if !ok {
+				errs = append(errs, model.FieldError{Field: field, Code: model.RuleType, Message: field + " must be " + param.Schema.Type})
+				continue
+			}
+			errs = append(errs, doc.ValidateValue(param.Schema, v, field)...)
+		}
+	}
+	return errs
+}
+
+// validateBody memeriksa body JSON terhadap schema untuk Content-Type request. Body tetap
+// bisa dibaca handler setelahnya. Error dikembalikan hanya jika body gagal dibaca.
+func validateBody(doc *openapi.Document, op *openapi.Operation, r *http.Request) ([]model.FieldError, error) {
+	if op.RequestBody == nil || r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	content, ok := op.RequestBody.Content[mt]
+	if err != nil || !ok || !openapi.IsJSON(mt) {
+		return nil, nil
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if int64(len(buf)) > maxValidatedBodyBytes {
+		return nil, nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return nil, nil
+	}
+	return doc.ValidateValue(content.Schema, v, ""), nil
+}
+
+// readCloser membaca dari Reader dan menutup Closer body asli.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ResponseValidationMiddleware memeriksa response terhadap dokumen OpenAPI: kode status harus
+// dideklarasikan untuk operasinya, Content-Type harus salah satu content response tersebut,
+// dan body JSON harus sesuai schema-nya. Pelanggaran hanya dicatat ke log, response tetap
+// dikirim apa adanya. Dipakai di mode development karena setiap body JSON di-parse ulang.
+func ResponseValidationMiddleware(doc *openapi.Document) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, template, _ := doc.FindOperation(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			var body limitedBuffer
+			if hasJSONResponse(op) {
+				ww.Tee(&body)
+			}
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			for _, violation := range responseViolations(doc, op, status, ww.Header().Get("Content-Type"), &body) {
+				log.Printf("openapi: %s %s (%s) -> %d: %s", r.Method, r.URL.Path, template, status, violation)
+			}
+		})
+	}
+}
+
+// responseViolations mengembalikan pelanggaran kontrak pada response dengan status dan
+// Content-Type yang diberikan.
+func responseViolations(doc *openapi.Document, op *openapi.Operation, status int, contentType string, body *limitedBuffer) []string {
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return []string{"status is not documented"}
+	}
+	if body.Len() == 0 && !body.truncated {
+		return nil
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	content, ok := resp.Content[mt]
+	if err != nil || !ok {
+		return []string{"content type " + strconv.Quote(contentType) + " is not documented"}
+	}
+	if !openapi.IsJSON(mt) || body.truncated {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body.Bytes(), &v); err != nil {
+		return []string{"body is not valid JSON: " + err.Error()}
+	}
+	var violations []string
+	for _, fe := range doc.ValidateValue(content.Schema, v, "") {
+		violations = append(violations, fe.Message)
+	}
+	return violations
+}
+
+// hasJSONResponse melaporkan apakah salah satu response operasi berisi JSON. Response
+// lain (misalnya stream SSE) tidak perlu disalin untuk diperiksa.
+func hasJSONResponse(op *openapi.Operation) bool {
+	for _, resp := range op.Responses {
+		for mt := range resp.Content {
+			if openapi.IsJSON(mt) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// limitedBuffer menyimpan sampai maxValidatedBodyBytes byte; sisanya dibuang dan
+// truncated bernilai true.
+type limitedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := int(maxValidatedBodyBytes) - b.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"book-api/model"
+	"book-api/openapi"
+)
+
+// testSpec adalah dokumen OpenAPI kecil untuk test: GET dan PUT /items/{id}.
+func testSpec() *openapi.Document {
+	item := &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"name": {Type: "string", MaxLength: openapi.Int(5)}},
+		Required:   []string{"name"},
+	}
+	id := &openapi.Parameter{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Float(1)}}
+	ok := map[string]*openapi.Response{"200": {Description: "OK", Content: map[string]*openapi.MediaType{"application/json": {Schema: item}}}}
+	return &openapi.Document{
+		Paths: map[string]*openapi.PathItem{
+			"/items/{id}": {
+				Get: &openapi.Operation{Parameters: []*openapi.Parameter{id}, Responses: ok},
+				Put: &openapi.Operation{
+					Parameters:  []*openapi.Parameter{id},
+					RequestBody: &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{"application/json": {Schema: item}}},
+					Responses:   ok,
+				},
+			},
+		},
+	}
+}
+
+func TestRequestValidationMiddleware(t *testing.T) {
+	var gotBody string
+	handler := RequestValidationMiddleware(testSpec())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		wantStatus  int
+		wantDetails []model.FieldError
+	}{
+		{"valid", http.MethodPut, "/items/1", `{"name":"pen"}`, http.StatusOK, nil},
+		{"undocumented path", http.MethodGet, "/other", "", http.StatusOK, nil},
+		{"invalid JSON passes through", http.MethodPut, "/items/1", `{`, http.StatusOK, nil},
+		{"path param type", http.MethodGet, "/items/abc", "", http.StatusBadRequest, []model.FieldError{{Field: "path.id", Code: model.RuleType}}},
+		{"path param minimum", http.MethodGet, "/items/0", "", http.StatusBadRequest, []model.FieldError{{Field: "path.id", Code: model.RuleMin}}},
+		{"body violations", http.MethodPut, "/items/1", `{"name":"notebook"}`, http.StatusBadRequest, []model.FieldError{{Field: "name", Code: model.RuleMaxLength}}},
+		{"missing property", http.MethodPut, "/items/1", `{}`, http.StatusBadRequest, []model.FieldError{{Field: "name", Code: model.RuleRequired}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBody = ""
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				if gotBody != tt.body {
+					t.Errorf("Expected handler to read body %q, got %q", tt.body, gotBody)
+				}
+				return
+			}
+			var resp struct {
+				Details []model.FieldError `json:"details"`
+			}
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if len(resp.Details) != len(tt.wantDetails) {
+				t.Fatalf("Expected details %+v, got %+v", tt.wantDetails, resp.Details)
+			}
+			for i, want := range tt.wantDetails {
+				if got := resp.Details[i]; got.Field != want.Field || got.Code != want.Code {
+					t.Errorf("Detail %d: expected %+v, got %+v", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseValidationMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantLog string
+	}{
+		{"valid", http.StatusOK, `{"name":"pen"}`, ""},
+		{"schema violation", http.StatusOK, `{"name":7}`, "name must be a string"},
+		{"undocumented status", http.StatusTeapot, `{}`, "status is not documented"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			handler := ResponseValidationMiddleware(testSpec())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+			if rr.Code != tt.status || rr.Body.String() != tt.body {
+				t.Errorf("Expected response to be passed through, got %d %q", rr.Code, rr.Body.String())
+			}
+			if tt.wantLog == "" && buf.Len() > 0 {
+				t.Errorf("Expected no log, got %q", buf.String())
+			}
+			if !strings.Contains(buf.String(), tt.wantLog) {
+				t.Errorf("Expected log to contain %q, got %q", tt.wantLog, buf.String())
+			}
+		})
+	}
+}
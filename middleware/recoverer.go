@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"book-api/utils"
+)
+
+// RecovererMiddleware menangkap panic dari handler, mencatat stack trace-nya, dan
+// mengirim 500 Internal Server Error dengan format error yang sama seperti handler lain
+// (application/problem+json jika diminta client). Panic http.ErrAbortHandler diteruskan
+// agar koneksi tetap dibatalkan seperti seharusnya.
+func RecovererMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			log.Printf("panic: %v\n%s", rec, debug.Stack())
+			if r.Header.Get("Connection") != "Upgrade" {
+				utils.WriteError(w, r, http.StatusInternalServerError, "internal server error")
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Jenis operasi pada BatchOp.Op.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// RuleOneOf dipakai ketika nilai field bukan salah satu pilihan yang diizinkan.
+const RuleOneOf = "one_of"
+
+// BatchOp adalah satu operasi di dalam batch.
+type BatchOp struct {
+	// Op adalah jenis operasi: BatchCreate, BatchUpdate, atau BatchDelete.
+	Op string `json:"op"`
+	// ID adalah ID buku untuk update dan delete.
+	ID int `json:"id,omitempty"`
+	// Version adalah versi yang diharapkan untuk update dan delete; 0 berarti tanpa syarat.
+	Version int `json:"version,omitempty"`
+	// Book adalah data buku untuk create dan update.
+	Book *Book `json:"book,omitempty"`
+}
+
+// Validate memeriksa bentuk operasi dan, untuk create/update, data bukunya dengan
+// aturan yang sama seperti Book.Validate. Path field pada FieldError relatif terhadap
+// operasi, misalnya "id" atau "book.title".
+//
+// Returns:
+//   - nil jika operasi valid
+//   - *ValidationError berisi semua pelanggaran jika tidak valid
+func (op BatchOp) Validate() error {
+	verr := &ValidationError{}
+	switch op.Op {
+	case BatchCreate:
+		if op.ID != 0 {
+			verr.add("id", RuleReadOnly, "id must not be set for create")
+		}
+	case BatchUpdate, BatchDelete:
+		if op.ID <= 0 {
+			verr.add("id", RuleRequired, "id is required for %s", op.Op)
+		}
+	case "":
+		verr.add("op", RuleRequired, "op is required")
+	default:
+		verr.add("op", RuleOneOf, "op must be one of create, update, delete")
+	}
+	if op.Version < 0 {
+		verr.add("version", RuleMin, "version must be positive")
+	}
+
+	switch op.Op {
+	case BatchCreate, BatchUpdate:
+		if op.Book == nil {
+			verr.add("book", RuleRequired, "book is required for %s", op.Op)
+		} else if err := op.Book.Validate(); err != nil {
+			for _, f := range err.(*ValidationError).Fields {
+				f.Field = "book." + f.Field
+				verr.Fields = append(verr.Fields, f)
+			}
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// BatchError menandai operasi batch yang gagal. Karena batch atomik, tidak ada
+// operasi lain yang diterapkan. errors.Is/As diteruskan ke Err.
+type BatchError struct {
+	// Index adalah posisi operasi yang gagal di dalam batch.
+	Index int
+	// Err adalah penyebab kegagalan.
+	Err error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// batchChange adalah perubahan hasil perencanaan batch; Book nil berarti hapus.
+type batchChange struct {
+	ID   int
+	Book *Book
+}
+
+// planBatch menjalankan ops terhadap state saat ini tanpa mengubahnya, termasuk
+// operasi yang bergantung pada operasi sebelumnya di batch yang sama.
+//
+// Parameters:
+//   - ops: operasi yang akan direncanakan
+//   - lastID: ID terakhir yang sudah dipakai; create mendapat ID berikutnya
+//   - at: waktu perubahan untuk UpdatedAt
+//   - lookup: fungsi pembaca buku dari state saat ini
+//
+// Returns:
+//   - hasil setiap operasi (buku yang dibuat/diubah, atau buku yang dihapus)
+//   - daftar perubahan sesuai urutan ops
+//   - lastID yang baru
+//   - *BatchError jika salah satu operasi gagal
+func planBatch(ops []BatchOp, lastID int, at time.Time, lookup func(id int) (Book, bool)) ([]Book, []batchChange, int, error) {
+	staged := make(map[int]*Book)
+	get := func(id int) (Book, bool) {
+		if b, ok := staged[id]; ok {
+			if b == nil {
+				return Book{}, false
+			}
+			return *b, true
+		}
+		return lookup(id)
+	}
+
+	results := make([]Book, len(ops))
+	changes := make([]batchChange, 0, len(ops))
+	for i, op := range ops {
+		var book Book
+		switch op.Op {
+		case BatchCreate:
+			if op.Book == nil {
+				return nil, nil, 0, &BatchError{Index: i, Err: fmt.Errorf("%w: book is required", ErrValidation)}
+			}
+			lastID++
+			book = *op.Book
+			book.ID = lastID
+			book.Version = 1
+			book.UpdatedAt = at
+			staged[book.ID] = &book
+		case BatchUpdate:
+			if op.Book == nil {
+				return nil, nil, 0, &BatchError{Index: i, Err: fmt.Errorf("%w: book is required", ErrValidation)}
+			}
+			current, ok := get(op.ID)
+			if !ok {
+				return nil, nil, 0, &BatchError{Index: i, Err: ErrNotFound}
+			}
+			if err := checkVersion(current, op.Version); err != nil {
+				return nil, nil, 0, &BatchError{Index: i, Err: err}
+			}
+			book = *op.Book
+			book.ID = op.ID
+			book.Version = current.Version + 1
+			book.UpdatedAt = at
+			staged[book.ID] = &book
+		case BatchDelete:
+			current, ok := get(op.ID)
+			if !ok {
+				return nil, nil, 0, &BatchError{Index: i, Err: ErrNotFound}
+			}
+			if err := checkVersion(current, op.Version); err != nil {
+				return nil, nil, 0, &BatchError{Index: i, Err: err}
+			}
+			book = current
+			staged[op.ID] = nil
+		default:
+			return nil, nil, 0, &BatchError{Index: i, Err: fmt.Errorf("%w: unknown op %q", ErrValidation, op.Op)}
+		}
+		results[i] = book
+		changes = append(changes, batchChange{ID: book.ID, Book: staged[book.ID]})
+	}
+	return results, changes, lastID, nil
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyBatch(t *testing.T) {
+	runBatchTests(t, setupStore())
+}
+
+// runBatchTests memastikan ApplyBatch menerapkan semua operasi, termasuk yang bergantung
+// pada operasi sebelumnya, dan tidak mengubah apa pun jika satu operasi gagal.
+func runBatchTests(t *testing.T, store BookStore) {
+	t.Helper()
+	existing := defaultBook(store)
+	doomed := createBook(store, "Doomed", "Go Dev", 2020)
+
+	results, err := store.ApplyBatch(ctx, []BatchOp{
+		{Op: BatchCreate, Book: &Book{Title: "Batch One", Author: "A", PublishedYear: 2001}},
+		{Op: BatchUpdate, ID: existing.ID, Version: existing.Version, Book: &Book{Title: "Updated", Author: "B", PublishedYear: 2002}},
+		{Op: BatchDelete, ID: doomed.ID},
+		{Op: BatchUpdate, ID: existing.ID, Version: existing.Version + 1, Book: &Book{Title: "Updated Twice", Author: "B", PublishedYear: 2002}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to apply batch: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	created := results[0]
+	if created.ID == 0 || created.Version != 1 || created.Title != "Batch One" {
+		t.Errorf("Unexpected created book: %+v", created)
+	}
+	if results[2].ID != doomed.ID {
+		t.Errorf("Expected deleted book to be returned, got %+v", results[2])
+	}
+	if got, _ := store.GetBookByID(ctx, existing.ID); got.Title != "Updated Twice" || got.Version != existing.Version+2 {
+		t.Errorf("Unexpected updated book: %+v", got)
+	}
+	if _, err := store.GetBookByID(ctx, doomed.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected deleted book to be gone, got %v", err)
+	}
+
+	before, _ := store.CollectionStamp(ctx)
+	_, err = store.ApplyBatch(ctx, []BatchOp{
+		{Op: BatchCreate, Book: &Book{Title: "Never Stored", Author: "C", PublishedYear: 2003}},
+		{Op: BatchDelete, ID: created.ID},
+		{Op: BatchUpdate, ID: created.ID, Book: &Book{Title: "Gone", Author: "C", PublishedYear: 2003}},
+	})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 2 || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound at operation 2, got %v", err)
+	}
+	if got := countBooks(store); got != 2 {
+		t.Errorf("Expected failed batch to leave 2 books, got %d", got)
+	}
+	if _, err := store.GetBookByID(ctx, created.ID); err != nil {
+		t.Errorf("Expected failed batch to keep book %d, got %v", created.ID, err)
+	}
+	if after, _ := store.CollectionStamp(ctx); after.Seq != before.Seq {
+		t.Errorf("Expected failed batch to keep stamp %d, got %d", before.Seq, after.Seq)
+	}
+
+	_, err = store.ApplyBatch(ctx, []BatchOp{{Op: BatchDelete, ID: created.ID, Version: created.Version + 5}})
+	if !errors.As(err, &batchErr) || batchErr.Index != 0 || !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("Expected ErrPreconditionFailed at operation 0, got %v", err)
+	}
+}
+
+func TestBatchOpValidate(t *testing.T) {
+	book := &Book{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", PublishedYear: 1980}
+
+	tests := []struct {
+		name string
+		op   BatchOp
+		want []FieldError
+	}{
+		{"create", BatchOp{Op: BatchCreate, Book: book}, nil},
+		{"update", BatchOp{Op: BatchUpdate, ID: 1, Version: 2, Book: book}, nil},
+		{"delete", BatchOp{Op: BatchDelete, ID: 1}, nil},
+		{"missing op", BatchOp{Book: book}, []FieldError{{Field: "op", Code: RuleRequired}}},
+		{"unknown op", BatchOp{Op: "upsert", ID: 1}, []FieldError{{Field: "op", Code: RuleOneOf}}},
+		{"create with id", BatchOp{Op: BatchCreate, ID: 3, Book: book}, []FieldError{{Field: "id", Code: RuleReadOnly}}},
+		{"update without id", BatchOp{Op: BatchUpdate, Book: book}, []FieldError{{Field: "id", Code: RuleRequired}}},
+		{"delete without id", BatchOp{Op: BatchDelete}, []FieldError{{Field: "id", Code: RuleRequired}}},
+		{"missing book", BatchOp{Op: BatchCreate}, []FieldError{{Field: "book", Code: RuleRequired}}},
+		{"negative version", BatchOp{Op: BatchDelete, ID: 1, Version: -1}, []FieldError{{Field: "version", Code: RuleMin}}},
+		{"invalid book", BatchOp{Op: BatchUpdate, ID: 1, Book: &Book{Title: "T", Author: "A"}}, []FieldError{
+			{Field: "book.published_year", Code: RuleRequired},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if len(verr.Fields) != len(tt.want) {
+				t.Fatalf("Expected %d field errors, got %+v", len(tt.want), verr.Fields)
+			}
+			for i, want := range tt.want {
+				if got := verr.Fields[i]; got.Field != want.Field || got.Code != want.Code {
+					t.Errorf("Field error %d: expected %s/%s, got %s/%s", i, want.Field, want.Code, got.Field, got.Code)
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package model
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
 type Book struct {
@@ -10,111 +13,596 @@ type Book struct {
 	Title         string `json:"title"`
 	Author        string `json:"author"`
 	PublishedYear int    `json:"published_year"`
+	// ISBN bersifat opsional; jika diisi harus ISBN-10 atau ISBN-13 yang valid.
+	ISBN string `json:"isbn,omitempty"`
+	// Version naik setiap kali buku diubah; dipakai untuk optimistic concurrency.
+	Version int `json:"version"`
+	// UpdatedAt adalah waktu (UTC) buku terakhir dibuat atau diubah.
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// CollectionStamp menandai kondisi seluruh koleksi buku. Seq naik pada setiap
+// perubahan (tambah, ubah, hapus) dan ModifiedAt adalah waktu perubahan terakhir.
+type CollectionStamp struct {
+	Seq        uint64
+	ModifiedAt time.Time
+}
+
+// now mengembalikan waktu saat ini dalam UTC; variabel agar bisa diganti di test.
+var now = func() time.Time {
+	return time.Now().UTC()
+}
+
+// BookStore adalah kontrak penyimpanan buku. Setiap method menerima context agar
+// pekerjaan store berhenti ketika request dibatalkan atau melewati batas waktu.
+// Error yang dikembalikan dapat dicek dengan errors.Is terhadap ErrNotFound,
+// ErrConflict, ErrValidation, ErrUnavailable, ErrPreconditionFailed, atau error dari context.
+//
+// UpdateBook memperlakukan updated.Version yang tidak nol sebagai versi yang diharapkan,
+// begitu juga expectedVersion pada DeleteBook; jika versi saat ini berbeda, store
+// mengembalikan ErrPreconditionFailed tanpa mengubah data. Nilai 0 berarti tanpa syarat.
+//
+// PatchBook menjalankan read-modify-write secara atomik: fungsi patch menerima buku
+// saat ini dan mengembalikan buku baru, dan tidak ada perubahan lain yang bisa
+// menyela di antaranya. Jika patch mengembalikan error, data tidak diubah.
+//
+// ApplyBatch menerapkan sekumpulan operasi secara all-or-nothing: jika satu operasi
+// gagal, store mengembalikan *BatchError untuk operasi tersebut dan tidak ada
+// perubahan yang disimpan. Operasi boleh bergantung pada operasi sebelumnya di batch yang sama.
+//
+// EachBook memanggil fn untuk setiap buku berurutan menurut ID tanpa memuat seluruh
+// koleksi sekaligus. Perubahan yang terjadi selama iterasi boleh terlihat sebagian.
+// Iterasi berhenti dan error fn dikembalikan jika fn gagal.
+//
+// StreamBooks menjalankan query yang sama seperti QueryBooks, tetapi memanggil fn untuk
+// setiap buku pada halaman hasil alih-alih mengembalikannya sebagai slice; BookPage yang
+// dikembalikan hanya berisi Total dan NextCursor. Seperti EachBook, jika fn gagal
+// iterasi berhenti dan error-nya dikembalikan.
+//
+// Setiap perubahan (termasuk lewat PatchBook dan ApplyBatch) dicatat sebagai Revision
+// yang tidak bisa diubah, beserta Audit dari context (lihat WithAudit). BookHistory dan
+// BookRevision tetap bisa membaca revision buku yang sudah dihapus.
+//
+// DeleteBook (dan operasi delete pada ApplyBatch) tidak menghapus buku secara permanen,
+// melainkan memindahkannya ke trash: buku tidak lagi terlihat lewat method baca lain,
+// tetapi bisa dilihat dengan ListTrash dan dikembalikan dengan RestoreBook (versinya
+// naik). PurgeBook menghapus buku secara permanen, baik yang masih aktif maupun yang
+// ada di trash, dan PurgeTrash menghapus permanen buku yang masuk trash sebelum cutoff.
 type BookStore interface {
-	AddBook(book Book) Book
-	GetAllBooks() []Book
-	GetBookByID(id int) (Book, error)
-	UpdateBook(id int, updated Book) (Book, error)
-	DeleteBook(id int) error
+	AddBook(ctx context.Context, book Book) (Book, error)
+	GetAllBooks(ctx context.Context) ([]Book, error)
+	QueryBooks(ctx context.Context, q BookQuery) (BookPage, error)
+	StreamBooks(ctx context.Context, q BookQuery, fn func(Book) error) (BookPage, error)
+	CollectionStamp(ctx context.Context) (CollectionStamp, error)
+	GetBookByID(ctx context.Context, id int) (Book, error)
+	UpdateBook(ctx context.Context, id int, updated Book) (Book, error)
+	PatchBook(ctx context.Context, id int, patch func(current Book) (Book, error)) (Book, error)
+	DeleteBook(ctx context.Context, id int, expectedVersion int) error
+	ApplyBatch(ctx context.Context, ops []BatchOp) ([]Book, error)
+	EachBook(ctx context.Context, fn func(Book) error) error
+	BookHistory(ctx context.Context, id int) ([]Revision, error)
+	BookRevision(ctx context.Context, id int, rev int) (Revision, error)
+	ListTrash(ctx context.Context) ([]TrashedBook, error)
+	RestoreBook(ctx context.Context, id int) (Book, error)
+	PurgeBook(ctx context.Context, id int, expectedVersion int) error
+	PurgeTrash(ctx context.Context, cutoff time.Time) (int, error)
 }
 
 type bookStore struct {
-	mu     sync.RWMutex
-	books  map[int]Book
-	lastID int
+	mu      sync.RWMutex
+	books   map[int]Book
+	trash   map[int]TrashedBook
+	lastID  int
+	index   *searchIndex
+	stamp   CollectionStamp
+	history historyLog
+	feed    *ChangeFeed
 }
 
 // NewBookStore membuat instance BookStore baru dengan inisialisasi map dan ID terakhir.
 func NewBookStore() BookStore {
 	return &bookStore{
-		books:  make(map[int]Book),
-		lastID: 0,
+		books:   make(map[int]Book),
+		trash:   make(map[int]TrashedBook),
+		lastID:  0,
+		index:   newSearchIndex(),
+		history: make(historyLog),
+		feed:    NewChangeFeed(DefaultChangeBufferSize),
 	}
 }
 
 // AddBook menambahkan buku baru ke dalam store dan memberikan ID secara otomatis.
 //
 // Parameters:
+//   - ctx: context request
 //   - book: Book tanpa ID (akan diisi otomatis)
 //
 // Returns:
 //   - Book yang sudah memiliki ID
-func (bs *bookStore) AddBook(book Book) Book {
+//   - error jika context sudah dibatalkan
+func (bs *bookStore) AddBook(ctx context.Context, book Book) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 	bs.lastID++
 	book.ID = bs.lastID
+	book.Version = 1
+	book.UpdatedAt = bs.touch()
 	bs.books[book.ID] = book
-	return book
+	bs.index.put(book)
+	bs.feed.publish(bs.history.record(AuditFromContext(ctx), ActionCreate, nil, &book, book.UpdatedAt))
+	return book, nil
 }
 
 // GetAllBooks mengembalikan semua buku dalam bentuk slice.
 //
+// Parameters:
+//   - ctx: context request
+//
 // Returns:
 //   - Slice dari semua Book yang tersimpan
-func (bs *bookStore) GetAllBooks() []Book {
+//   - error jika context sudah dibatalkan
+func (bs *bookStore) GetAllBooks(ctx context.Context) ([]Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	bs.mu.RLock()
 	defer bs.mu.RUnlock()
 	books := []Book{}
 	for _, b := range bs.books {
 		books = append(books, b)
 	}
-	return books
+	return books, nil
+}
+
+// QueryBooks mengembalikan satu halaman buku sesuai filter, urutan, dan paginasi.
+//
+// Parameters:
+//   - ctx: context request
+//   - q: filter, sort, limit/offset atau cursor
+//
+// Returns:
+//   - BookPage berisi buku, total hasil filter, dan cursor halaman berikutnya
+//   - error yang membungkus ErrValidation jika query tidak valid
+func (bs *bookStore) QueryBooks(ctx context.Context, q BookQuery) (BookPage, error) {
+	return collectQuery(func(fn func(Book) error) (BookPage, error) {
+		return bs.StreamBooks(ctx, q, fn)
+	})
+}
+
+// StreamBooks memanggil fn untuk setiap buku pada halaman hasil query tanpa menyalin
+// seluruh map. Dengan urutan default (id ascending) hanya daftar ID yang disalin.
+//
+// Parameters:
+//   - ctx: context request
+//   - q: filter, sort, limit/offset atau cursor
+//   - fn: fungsi yang dipanggil untuk setiap buku secara berurutan
+//
+// Returns:
+//   - BookPage berisi total hasil filter dan cursor halaman berikutnya (Books kosong)
+//   - error yang membungkus ErrValidation jika query tidak valid, error dari fn, atau
+//     error context jika request dibatalkan
+func (bs *bookStore) StreamBooks(ctx context.Context, q BookQuery, fn func(Book) error) (BookPage, error) {
+	return streamQuery(q, func(fn func(Book) error) error {
+		return bs.EachBook(ctx, fn)
+	}, fn)
+}
+
+// EachBook memanggil fn untuk setiap buku berurutan menurut ID.
+//
+// Parameters:
+//   - ctx: context request
+//   - fn: fungsi yang dipanggil untuk setiap buku
+//
+// Returns:
+//   - error dari fn, atau error context jika request dibatalkan
+func (bs *bookStore) EachBook(ctx context.Context, fn func(Book) error) error {
+	return eachBook(ctx, &bs.mu, bs.books, fn)
+}
+
+// eachBook mengiterasi map buku berurutan menurut ID. Hanya daftar ID yang disalin;
+// setiap buku dibaca dengan lock singkat sehingga fn boleh lambat tanpa menahan penulis.
+// Buku yang dihapus selama iterasi dilewati.
+func eachBook(ctx context.Context, mu *sync.RWMutex, books map[int]Book, fn func(Book) error) error {
+	mu.RLock()
+	ids := make([]int, 0, len(books))
+	for id := range books {
+		ids = append(ids, id)
+	}
+	mu.RUnlock()
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		mu.RLock()
+		b, ok := books[id]
+		mu.RUnlock()
+		if !ok {
+			continue
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchBooks mencari buku lewat inverted index pada judul dan penulis.
+//
+// Parameters:
+//   - ctx: context request
+//   - query: kata kunci; setiap kata harus cocok secara utuh atau sebagai awalan
+//   - limit: jumlah hasil maksimum (0 untuk default)
+//
+// Returns:
+//   - Slice SearchHit terurut dari skor tertinggi
+//   - error yang membungkus ErrValidation jika query kosong
+func (bs *bookStore) SearchBooks(ctx context.Context, query string, limit int) ([]SearchHit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return bs.index.search(query, limit)
+}
+
+// CollectionStamp mengembalikan penanda perubahan terakhir pada koleksi buku.
+//
+// Parameters:
+//   - ctx: context request
+//
+// Returns:
+//   - CollectionStamp saat ini
+//   - error jika context sudah dibatalkan
+func (bs *bookStore) CollectionStamp(ctx context.Context) (CollectionStamp, error) {
+	if err := ctx.Err(); err != nil {
+		return CollectionStamp{}, err
+	}
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return bs.stamp, nil
+}
+
+// touch mencatat perubahan pada koleksi dan mengembalikan waktunya.
+// Harus dipanggil dengan bs.mu terkunci.
+func (bs *bookStore) touch() time.Time {
+	bs.stamp.Seq++
+	bs.stamp.ModifiedAt = now()
+	return bs.stamp.ModifiedAt
 }
 
 // GetBookByID mencari buku berdasarkan ID.
 //
 // Parameters:
+//   - ctx: context request
 //   - id: ID buku yang dicari
 //
 // Returns:
 //   - Book jika ditemukan
-//   - error jika tidak ditemukan
-func (bs *bookStore) GetBookByID(id int) (Book, error) {
+//   - ErrNotFound jika tidak ditemukan
+func (bs *bookStore) GetBookByID(ctx context.Context, id int) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
 	bs.mu.RLock()
 	defer bs.mu.RUnlock()
 	b, ok := bs.books[id]
 	if !ok {
-		return Book{}, errors.New("book not found")
+		return Book{}, ErrNotFound
 	}
 	return b, nil
 }
 
-// UpdateBook memperbarui data buku berdasarkan ID.
+// UpdateBook memperbarui data buku berdasarkan ID dan menaikkan versinya.
 //
 // Parameters:
+//   - ctx: context request
 //   - id: ID buku yang ingin diperbarui
-//   - updated: data baru untuk buku
+//   - updated: data baru untuk buku; Version yang tidak nol harus sama dengan versi saat ini
 //
 // Returns:
 //   - Book hasil update
-//   - error jika ID tidak ditemukan
-func (bs *bookStore) UpdateBook(id int, updated Book) (Book, error) {
+//   - ErrNotFound jika ID tidak ditemukan
+//   - ErrPreconditionFailed jika versi tidak cocok
+func (bs *bookStore) UpdateBook(ctx context.Context, id int, updated Book) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
-	if _, ok := bs.books[id]; !ok {
-		return Book{}, errors.New("book not found")
+	current, ok := bs.books[id]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+	if err := checkVersion(current, updated.Version); err != nil {
+		return Book{}, err
+	}
+	updated.ID = id
+	updated.Version = current.Version + 1
+	updated.UpdatedAt = bs.touch()
+	bs.books[id] = updated
+	bs.index.put(updated)
+	bs.feed.publish(bs.history.record(AuditFromContext(ctx), ActionUpdate, &current, &updated, updated.UpdatedAt))
+	return updated, nil
+}
+
+// PatchBook mengubah buku berdasarkan hasil fungsi patch terhadap data saat ini.
+// Fungsi patch dijalankan saat lock store dipegang sehingga tidak boleh memanggil store.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku yang ingin diubah
+//   - patch: fungsi yang menerima buku saat ini dan mengembalikan buku baru
+//
+// Returns:
+//   - Book hasil patch dengan versi yang dinaikkan
+//   - ErrNotFound jika ID tidak ditemukan, atau error dari fungsi patch
+func (bs *bookStore) PatchBook(ctx context.Context, id int, patch func(current Book) (Book, error)) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	current, ok := bs.books[id]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+	updated, err := patch(current)
+	if err != nil {
+		return Book{}, err
 	}
 	updated.ID = id
+	updated.Version = current.Version + 1
+	updated.UpdatedAt = bs.touch()
 	bs.books[id] = updated
+	bs.index.put(updated)
+	bs.feed.publish(bs.history.record(AuditFromContext(ctx), ActionUpdate, &current, &updated, updated.UpdatedAt))
 	return updated, nil
 }
 
-// DeleteBook menghapus buku berdasarkan ID.
+// DeleteBook memindahkan buku berdasarkan ID ke trash.
 //
 // Parameters:
+//   - ctx: context request
 //   - id: ID buku yang akan dihapus
+//   - expectedVersion: versi yang diharapkan, atau 0 untuk tanpa syarat
+//
+// Returns:
+//   - ErrNotFound jika ID tidak ditemukan
+//   - ErrPreconditionFailed jika versi tidak cocok
+func (bs *bookStore) DeleteBook(ctx context.Context, id int, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	current, ok := bs.books[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := checkVersion(current, expectedVersion); err != nil {
+		return err
+	}
+	at := bs.touch()
+	bs.moveToTrash(current, at)
+	bs.feed.publish(bs.history.record(AuditFromContext(ctx), ActionDelete, &current, nil, at))
+	return nil
+}
+
+// moveToTrash memindahkan buku aktif ke trash. Harus dipanggil dengan bs.mu terkunci.
+func (bs *bookStore) moveToTrash(book Book, at time.Time) {
+	delete(bs.books, book.ID)
+	bs.index.remove(book.ID)
+	bs.trash[book.ID] = TrashedBook{Book: book, DeletedAt: at}
+}
+
+// ApplyBatch menerapkan semua operasi batch secara atomik.
+//
+// Parameters:
+//   - ctx: context request
+//   - ops: operasi create, update, atau delete sesuai urutan
+//
+// Returns:
+//   - hasil setiap operasi (buku yang dibuat/diubah, atau buku yang dihapus)
+//   - *BatchError jika salah satu operasi gagal; tidak ada perubahan yang disimpan
+func (bs *bookStore) ApplyBatch(ctx context.Context, ops []BatchOp) ([]Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	lookup := func(id int) (Book, bool) {
+		b, ok := bs.books[id]
+		return b, ok
+	}
+	at := now()
+	results, changes, lastID, err := planBatch(ops, bs.lastID, at, lookup)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return results, nil
+	}
+	audit := AuditFromContext(ctx)
+	for _, c := range changes {
+		var before *Book
+		if b, ok := bs.books[c.ID]; ok {
+			before = &b
+		}
+		if c.Book == nil {
+			bs.feed.publish(bs.history.record(audit, ActionDelete, before, nil, at))
+			bs.moveToTrash(*before, at)
+			continue
+		}
+		bs.feed.publish(bs.history.record(audit, revisionAction(before, c.Book), before, c.Book, at))
+		bs.books[c.ID] = *c.Book
+		bs.index.put(*c.Book)
+	}
+	bs.lastID = lastID
+	bs.stamp.Seq++
+	bs.stamp.ModifiedAt = at
+	return results, nil
+}
+
+// BookHistory mengembalikan semua revision buku berurutan dari yang terlama.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku, termasuk buku yang sudah dihapus
+//
+// Returns:
+//   - slice Revision
+//   - ErrNotFound jika buku tidak pernah ada
+func (bs *bookStore) BookHistory(ctx context.Context, id int) ([]Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return bs.history.list(id)
+}
+
+// BookRevision mengembalikan satu revision buku.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku
+//   - rev: nomor revision, mulai dari 1
+//
+// Returns:
+//   - Revision yang diminta
+//   - ErrNotFound jika buku atau revision tidak ada
+func (bs *bookStore) BookRevision(ctx context.Context, id int, rev int) (Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return Revision{}, err
+	}
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return bs.history.get(id, rev)
+}
+
+// ListTrash mengembalikan semua buku di trash, yang paling baru dihapus lebih dulu.
+//
+// Parameters:
+//   - ctx: context request
 //
 // Returns:
-//   - error jika ID tidak ditemukan
-func (bs *bookStore) DeleteBook(id int) error {
+//   - slice TrashedBook
+//   - error jika context sudah dibatalkan
+func (bs *bookStore) ListTrash(ctx context.Context) ([]TrashedBook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	return trashList(bs.trash), nil
+}
+
+// RestoreBook mengembalikan buku dari trash dan menaikkan versinya.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku di trash
+//
+// Returns:
+//   - Book yang sudah aktif kembali
+//   - ErrNotFound jika buku tidak ada di trash
+func (bs *bookStore) RestoreBook(ctx context.Context, id int) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
-	if _, ok := bs.books[id]; !ok {
-		return errors.New("book not found")
+	trashed, ok := bs.trash[id]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+	book := trashed.Book
+	book.Version++
+	book.UpdatedAt = bs.touch()
+	delete(bs.trash, id)
+	bs.books[id] = book
+	bs.index.put(book)
+	bs.feed.publish(bs.history.record(AuditFromContext(ctx), ActionRestore, nil, &book, book.UpdatedAt))
+	return book, nil
+}
+
+// PurgeBook menghapus buku secara permanen, baik yang masih aktif maupun yang ada di trash.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku yang akan dihapus permanen
+//   - expectedVersion: versi yang diharapkan, atau 0 untuk tanpa syarat
+//
+// Returns:
+//   - ErrNotFound jika ID tidak ditemukan di koleksi maupun di trash
+//   - ErrPreconditionFailed jika versi tidak cocok
+func (bs *bookStore) PurgeBook(ctx context.Context, id int, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	current, ok := bs.books[id]
+	if !ok {
+		trashed, inTrash := bs.trash[id]
+		if !inTrash {
+			return ErrNotFound
+		}
+		current = trashed.Book
+	}
+	if err := checkVersion(current, expectedVersion); err != nil {
+		return err
 	}
 	delete(bs.books, id)
+	delete(bs.trash, id)
+	bs.index.remove(id)
+	bs.feed.publish(bs.history.record(AuditFromContext(ctx), ActionPurge, &current, nil, bs.touch()))
+	return nil
+}
+
+// PurgeTrash menghapus permanen semua buku yang masuk trash sebelum cutoff.
+//
+// Parameters:
+//   - ctx: context request
+//   - cutoff: buku dengan DeletedAt sebelum waktu ini dihapus
+//
+// Returns:
+//   - jumlah buku yang dihapus
+//   - error jika context sudah dibatalkan
+func (bs *bookStore) PurgeTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	ids := expiredTrash(bs.trash, cutoff)
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	audit := AuditFromContext(ctx)
+	at := bs.touch()
+	for _, id := range ids {
+		book := bs.trash[id].Book
+		delete(bs.trash, id)
+		bs.feed.publish(bs.history.record(audit, ActionPurge, &book, nil, at))
+	}
+	return len(ids), nil
+}
+
+// Changes mengembalikan feed event perubahan buku.
+func (bs *bookStore) Changes() *ChangeFeed {
+	return bs.feed
+}
+
+// checkVersion memastikan versi buku sama dengan expected (0 berarti tanpa syarat).
+func checkVersion(current Book, expected int) error {
+	if expected != 0 && current.Version != expected {
+		return fmt.Errorf("%w: expected version %d, current version is %d", ErrPreconditionFailed, expected, current.Version)
+	}
 	return nil
 }
@@ -1,10 +1,14 @@
 package model
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"testing"
 )
 
+var ctx = context.Background()
+
 func setupStore() BookStore {
 	return NewBookStore()
 }
@@ -15,7 +19,13 @@ func createBook(store BookStore, title string, author string, year int) Book {
 		Author:        author,
 		PublishedYear: year,
 	}
-	return store.AddBook(book)
+	added, _ := store.AddBook(ctx, book)
+	return added
+}
+
+func countBooks(store BookStore) int {
+	books, _ := store.GetAllBooks(ctx)
+	return len(books)
 }
 
 func defaultBook(store BookStore) Book {
@@ -25,7 +35,7 @@ func defaultBook(store BookStore) Book {
 func TestGetBookStoreEmpty(t *testing.T) {
 	store := setupStore()
 
-	if len(store.GetAllBooks()) != 0 {
+	if countBooks(store) != 0 {
 		t.Error("Expected empty book list")
 	}
 }
@@ -35,7 +45,7 @@ func TestGetBookStore(t *testing.T) {
 
 	defaultBook(store)
 
-	if len(store.GetAllBooks()) == 0 {
+	if countBooks(store) == 0 {
 		t.Error("Expected non-empty book list")
 	}
 }
@@ -55,7 +65,7 @@ func TestGetBookByID(t *testing.T) {
 
 	added := defaultBook(store)
 
-	got, err := store.GetBookByID(added.ID)
+	got, err := store.GetBookByID(ctx, added.ID)
 	if err != nil {
 		t.Fatalf("Expected book to exist, got error: %v", err)
 	}
@@ -75,7 +85,7 @@ func TestUpdateBook(t *testing.T) {
 		PublishedYear: 2024,
 	}
 
-	updated, err := store.UpdateBook(added.ID, update)
+	updated, err := store.UpdateBook(ctx, added.ID, update)
 	if err != nil {
 		t.Fatalf("Failed to update book: %v", err)
 	}
@@ -94,10 +104,130 @@ func TestUpdateBookNotFoundId(t *testing.T) {
 		PublishedYear: 2024,
 	}
 
-	updated, err := store.UpdateBook(1, update)
+	updated, err := store.UpdateBook(ctx, 1, update)
 
-	if err == nil {
-		t.Errorf("got: %v, want: book not found", updated)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got: %v (%v), want: book not found", updated, err)
+	}
+}
+
+func TestUpdateBookVersion(t *testing.T) {
+	runVersionTests(t, setupStore())
+}
+
+// runVersionTests memastikan store menaikkan versi dan menolak versi yang tidak cocok.
+func runVersionTests(t *testing.T, store BookStore) {
+	t.Helper()
+	added := defaultBook(store)
+	if added.Version != 1 {
+		t.Fatalf("Expected new book to have version 1, got %d", added.Version)
+	}
+
+	update := Book{Title: "Updated Book", Author: "Tester", PublishedYear: 2024, Version: 1}
+	updated, err := store.UpdateBook(ctx, added.ID, update)
+	if err != nil {
+		t.Fatalf("Failed to update book: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("Expected version 2 after update, got %d", updated.Version)
+	}
+
+	if _, err := store.UpdateBook(ctx, added.ID, update); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("Expected ErrPreconditionFailed for stale version, got %v", err)
+	}
+	if err := store.DeleteBook(ctx, added.ID, 1); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("Expected ErrPreconditionFailed for stale delete, got %v", err)
+	}
+
+	got, _ := store.GetBookByID(ctx, added.ID)
+	if got.Title != "Updated Book" || got.Version != 2 {
+		t.Errorf("Expected rejected writes to leave book untouched, got %+v", got)
+	}
+
+	if err := store.DeleteBook(ctx, added.ID, 2); err != nil {
+		t.Errorf("Expected delete with current version to succeed, got %v", err)
+	}
+}
+
+func TestPatchBook(t *testing.T) {
+	runPatchTests(t, setupStore())
+}
+
+// runPatchTests memastikan PatchBook menerapkan perubahan dan tidak mengubah data jika patch gagal.
+func runPatchTests(t *testing.T, store BookStore) {
+	t.Helper()
+	added := defaultBook(store)
+
+	patched, err := store.PatchBook(ctx, added.ID, func(current Book) (Book, error) {
+		current.Title = "Patched"
+		return current, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to patch book: %v", err)
+	}
+	if patched.Title != "Patched" || patched.Author != added.Author || patched.Version != added.Version+1 {
+		t.Errorf("Unexpected patched book: %+v", patched)
+	}
+
+	if _, err := store.PatchBook(ctx, added.ID, func(current Book) (Book, error) {
+		return Book{}, ErrValidation
+	}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected patch error to be returned, got %v", err)
+	}
+	if got, _ := store.GetBookByID(ctx, added.ID); got.Title != "Patched" || got.Version != patched.Version {
+		t.Errorf("Expected failed patch to leave book untouched, got %+v", got)
+	}
+
+	if _, err := store.PatchBook(ctx, added.ID+100, func(current Book) (Book, error) {
+		return current, nil
+	}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCollectionStamp(t *testing.T) {
+	runStampTests(t, setupStore())
+}
+
+// runStampTests memastikan setiap perubahan menaikkan Seq koleksi dan UpdatedAt buku.
+func runStampTests(t *testing.T, store BookStore) {
+	t.Helper()
+	stamp := func() CollectionStamp {
+		s, err := store.CollectionStamp(ctx)
+		if err != nil {
+			t.Fatalf("CollectionStamp failed: %v", err)
+		}
+		return s
+	}
+
+	before := stamp()
+	added := defaultBook(store)
+	if added.UpdatedAt.IsZero() {
+		t.Error("Expected UpdatedAt to be set on add")
+	}
+	afterAdd := stamp()
+	if afterAdd.Seq <= before.Seq || afterAdd.ModifiedAt.IsZero() {
+		t.Errorf("Expected stamp to advance on add: %+v -> %+v", before, afterAdd)
+	}
+
+	updated, _ := store.UpdateBook(ctx, added.ID, Book{Title: "Updated", Author: "Tester", PublishedYear: 2024})
+	if updated.UpdatedAt.Before(added.UpdatedAt) {
+		t.Errorf("Expected UpdatedAt to move forward: %v -> %v", added.UpdatedAt, updated.UpdatedAt)
+	}
+	afterUpdate := stamp()
+	if afterUpdate.Seq <= afterAdd.Seq {
+		t.Errorf("Expected stamp to advance on update: %+v -> %+v", afterAdd, afterUpdate)
+	}
+
+	store.DeleteBook(ctx, added.ID, 0)
+	afterDelete := stamp()
+	if afterDelete.Seq <= afterUpdate.Seq {
+		t.Errorf("Expected stamp to advance on delete: %+v -> %+v", afterUpdate, afterDelete)
+	}
+
+	store.DeleteBook(ctx, added.ID, 0)
+	if again := stamp(); again.Seq != afterDelete.Seq {
+		t.Errorf("Expected failed delete to leave stamp untouched: %+v -> %+v", afterDelete, again)
 	}
 }
 
@@ -106,12 +236,12 @@ func TestDeleteBook(t *testing.T) {
 
 	added := defaultBook(store)
 
-	err := store.DeleteBook(added.ID)
+	err := store.DeleteBook(ctx, added.ID, 0)
 	if err != nil {
 		t.Fatalf("Failed to delete book: %v", err)
 	}
 
-	_, err = store.GetBookByID(added.ID)
+	_, err = store.GetBookByID(ctx, added.ID)
 	if err == nil {
 		t.Error("Expected error after deleting book, got none")
 	}
@@ -120,9 +250,36 @@ func TestDeleteBook(t *testing.T) {
 func TestDeleteBookNotFound(t *testing.T) {
 	store := setupStore()
 
-	err := store.DeleteBook(1)
-	if err == nil {
-		t.Error("got: success deleted, want: book not found")
+	err := store.DeleteBook(ctx, 1, 0)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got: %v, want: book not found", err)
+	}
+}
+
+func TestStoreHonoursCanceledContext(t *testing.T) {
+	store := setupStore()
+	added := defaultBook(store)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := store.AddBook(canceled, Book{Title: "A", Author: "B", PublishedYear: 2024}); !errors.Is(err, context.Canceled) {
+		t.Errorf("AddBook: expected context.Canceled, got %v", err)
+	}
+	if _, err := store.GetAllBooks(canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetAllBooks: expected context.Canceled, got %v", err)
+	}
+	if _, err := store.GetBookByID(canceled, added.ID); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetBookByID: expected context.Canceled, got %v", err)
+	}
+	if _, err := store.UpdateBook(canceled, added.ID, Book{Title: "A", Author: "B", PublishedYear: 2024}); !errors.Is(err, context.Canceled) {
+		t.Errorf("UpdateBook: expected context.Canceled, got %v", err)
+	}
+	if err := store.DeleteBook(canceled, added.ID, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("DeleteBook: expected context.Canceled, got %v", err)
+	}
+	if countBooks(store) != 1 {
+		t.Error("Expected canceled calls to leave the store untouched")
 	}
 }
 
@@ -130,7 +287,7 @@ func BenchmarkAddBooks(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		store := setupStore()
 		for j := 0; j < 1000; j++ {
-			store.AddBook(Book{
+			store.AddBook(ctx, Book{
 				Title:         "Book " + strconv.Itoa(j),
 				Author:        "Author",
 				PublishedYear: 2023,
@@ -142,7 +299,7 @@ func BenchmarkAddBooks(b *testing.B) {
 func BenchmarkGetBooks(b *testing.B) {
 	store := setupStore()
 	for i := 0; i < 1000; i++ {
-		store.AddBook(Book{
+		store.AddBook(ctx, Book{
 			Title:         "Book " + strconv.Itoa(i),
 			Author:        "Author",
 			PublishedYear: 2023,
@@ -153,7 +310,7 @@ func BenchmarkGetBooks(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		id := 1
 		for pb.Next() {
-			_, err := store.GetBookByID(id)
+			_, err := store.GetBookByID(ctx, id)
 			if err != nil {
 				b.Fatalf("Failed to get book ID %d: %v", id, err)
 			}
@@ -165,7 +322,7 @@ func BenchmarkGetBooks(b *testing.B) {
 func BenchmarkUpdateBooks(b *testing.B) {
 	store := setupStore()
 	for i := 0; i < 1000; i++ {
-		store.AddBook(Book{
+		store.AddBook(ctx, Book{
 			Title:         "Book " + strconv.Itoa(i),
 			Author:        "Author",
 			PublishedYear: 2023,
@@ -176,7 +333,7 @@ func BenchmarkUpdateBooks(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		id := 1
 		for pb.Next() {
-			_, err := store.UpdateBook(id, Book{
+			_, err := store.UpdateBook(ctx, id, Book{
 				Title:         "Updated Title",
 				Author:        "Updated Author",
 				PublishedYear: 2025,
@@ -194,7 +351,7 @@ func BenchmarkDeleteBooks(b *testing.B) {
 	ids := make([]int, 1000)
 
 	for i := 0; i < 1000; i++ {
-		book := store.AddBook(Book{
+		book, _ := store.AddBook(ctx, Book{
 			Title:         "Book " + strconv.Itoa(i),
 			Author:        "Author",
 			PublishedYear: 2023,
@@ -206,9 +363,65 @@ func BenchmarkDeleteBooks(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		id := ids[i%len(ids)]
-		err := store.DeleteBook(id)
+		err := store.DeleteBook(ctx, id, 0)
 		if err != nil {
 			continue
 		}
 	}
 }
+
+func TestEachBook(t *testing.T) {
+	runEachBookTests(t, setupStore())
+}
+
+// runEachBookTests memastikan EachBook mengunjungi semua buku berurutan menurut ID,
+// termasuk melewati batas halaman, dan berhenti ketika fn gagal.
+func runEachBookTests(t *testing.T, store BookStore) {
+	t.Helper()
+	const n = 501
+	ops := make([]BatchOp, n)
+	for i := range ops {
+		ops[i] = BatchOp{Op: BatchCreate, Book: &Book{Title: "Book " + strconv.Itoa(i), Author: "Go Dev", PublishedYear: 2000}}
+	}
+	if _, err := store.ApplyBatch(ctx, ops); err != nil {
+		t.Fatalf("Failed to seed books: %v", err)
+	}
+	if err := store.DeleteBook(ctx, 2, 0); err != nil {
+		t.Fatalf("Failed to delete book: %v", err)
+	}
+
+	var ids []int
+	if err := store.EachBook(ctx, func(b Book) error {
+		ids = append(ids, b.ID)
+		return nil
+	}); err != nil {
+		t.Fatalf("EachBook failed: %v", err)
+	}
+	if len(ids) != n-1 {
+		t.Fatalf("Expected %d books, got %d", n-1, len(ids))
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("Expected ascending IDs, got %d after %d", ids[i], ids[i-1])
+		}
+	}
+
+	stop := errors.New("stop")
+	visited := 0
+	err := store.EachBook(ctx, func(Book) error {
+		visited++
+		if visited == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || visited != 3 {
+		t.Errorf("Expected iteration to stop after 3 books with fn error, got %d visits and %v", visited, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := store.EachBook(canceled, func(Book) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package model
+
+import "errors"
+
+// Error standar yang dikembalikan oleh implementasi BookStore. Handler memetakan
+// error ini ke status HTTP dengan errors.Is; error lain dianggap kegagalan backend.
+var (
+	// ErrNotFound dikembalikan ketika buku dengan ID yang diminta tidak ada.
+	ErrNotFound = errors.New("book not found")
+	// ErrConflict dikembalikan ketika perubahan bertabrakan dengan data yang sudah ada.
+	ErrConflict = errors.New("book conflict")
+	// ErrValidation dikembalikan ketika data buku tidak valid.
+	ErrValidation = errors.New("invalid book")
+	// ErrPreconditionFailed dikembalikan ketika versi buku tidak sama dengan versi yang diharapkan.
+	ErrPreconditionFailed = errors.New("version mismatch")
+	// ErrUnavailable dikembalikan ketika backend store tidak dapat dipakai (misalnya sudah ditutup).
+	ErrUnavailable = errors.New("book store unavailable")
+)
@@ -0,0 +1,277 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultChangeBufferSize adalah jumlah event terakhir yang disimpan ChangeFeed untuk
+// resume lewat Last-Event-ID.
+const DefaultChangeBufferSize = 1024
+
+// changeSubscriberBuffer adalah kapasitas antrean event per subscriber. Subscriber yang
+// antreannya penuh diputus agar satu client lambat tidak menahan store.
+const changeSubscriberBuffer = 64
+
+// Jenis ChangeEvent.Type, sesuai Revision.Action.
+const (
+	ChangeCreated  = "created"
+	ChangeUpdated  = "updated"
+	ChangeDeleted  = "deleted"
+	ChangeRestored = "restored"
+	ChangePurged   = "purged"
+)
+
+// changeTypes memetakan Revision.Action ke ChangeEvent.Type.
+var changeTypes = map[string]string{
+	ActionCreate:  ChangeCreated,
+	ActionUpdate:  ChangeUpdated,
+	ActionDelete:  ChangeDeleted,
+	ActionRestore: ChangeRestored,
+	ActionPurge:   ChangePurged,
+}
+
+// ChangeEvent adalah satu perubahan buku yang diterbitkan store.
+type ChangeEvent struct {
+	// ID adalah ID event untuk resume, berisi epoch feed dan Seq (lihat FormatEventID).
+	ID string `json:"id"`
+	// Seq naik satu untuk setiap event. Nomor dimulai lagi dari 1 setiap kali server
+	// dijalankan, jadi hanya bermakna bersama epoch pada ID.
+	Seq    uint64 `json:"seq"`
+	Type   string `json:"type"`
+	BookID int    `json:"book_id"`
+	// Book adalah kondisi buku setelah perubahan; untuk deleted dan purged berisi
+	// kondisi terakhir sebelum buku dihapus.
+	Book *Book     `json:"book"`
+	At   time.Time `json:"at"`
+}
+
+// newChangeEvent membuat ChangeEvent (tanpa Seq) dari sebuah revision.
+func newChangeEvent(r Revision) ChangeEvent {
+	book := r.After
+	if book == nil {
+		book = r.Before
+	}
+	return ChangeEvent{Type: changeTypes[r.Action], BookID: r.BookID, Book: book, At: r.At}
+}
+
+// ChangeFilter membatasi event yang diterima subscriber. Filter kosong menerima semua event.
+type ChangeFilter struct {
+	// Authors berisi nama penulis (tanpa membedakan huruf besar/kecil).
+	Authors []string
+	// IDs berisi ID buku.
+	IDs []int
+}
+
+// Match melaporkan apakah event lolos filter: ID buku harus ada di IDs (jika diisi)
+// dan penulisnya harus ada di Authors (jika diisi).
+func (f ChangeFilter) Match(ev ChangeEvent) bool {
+	if len(f.IDs) > 0 {
+		found := false
+		for _, id := range f.IDs {
+			found = found || id == ev.BookID
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Authors) > 0 {
+		if ev.Book == nil {
+			return false
+		}
+		found := false
+		for _, a := range f.Authors {
+			found = found || strings.EqualFold(a, ev.Book.Author)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ChangeNotifier diimplementasikan oleh store yang menerbitkan ChangeEvent untuk
+// setiap perubahan yang berhasil disimpan, berurutan sesuai urutan perubahan.
+type ChangeNotifier interface {
+	Changes() *ChangeFeed
+}
+
+// FormatEventID membuat ID event "<epoch>-<seq>".
+func FormatEventID(epoch string, seq uint64) string {
+	return epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// ParseEventID membaca ID event dari FormatEventID. Angka saja (format sebelum ada
+// epoch) diterima dengan epoch kosong sehingga tidak pernah cocok dengan feed mana pun.
+func ParseEventID(id string) (epoch string, seq uint64, err error) {
+	seqPart := id
+	if i := strings.LastIndexByte(id, '-'); i >= 0 {
+		epoch, seqPart = id[:i], id[i+1:]
+	}
+	if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+		return "", 0, fmt.Errorf("invalid event ID %q", id)
+	}
+	return epoch, seq, nil
+}
+
+// ChangeFeed menyebarkan ChangeEvent ke subscriber dan menyimpan sejumlah event
+// terakhir agar subscriber yang terputus bisa melanjutkan dari Seq terakhir yang diterimanya.
+// Seq dimulai dari 1 setiap kali feed dibuat, sehingga setiap feed memiliki epoch acak
+// untuk membedakan Seq yang sama dari sebelum server dijalankan ulang.
+type ChangeFeed struct {
+	mu    sync.Mutex
+	epoch string
+	seq   uint64
+	// buffer adalah ring buffer berisi count event terakhir mulai dari indeks start.
+	buffer []ChangeEvent
+	start  int
+	count  int
+	subs   map[*Subscription]struct{}
+}
+
+// NewChangeFeed membuat ChangeFeed yang menyimpan paling banyak size event terakhir
+// (DefaultChangeBufferSize jika size tidak positif).
+func NewChangeFeed(size int) *ChangeFeed {
+	if size <= 0 {
+		size = DefaultChangeBufferSize
+	}
+	var b [8]byte
+	rand.Read(b[:])
+	return &ChangeFeed{epoch: hex.EncodeToString(b[:]), buffer: make([]ChangeEvent, size), subs: make(map[*Subscription]struct{})}
+}
+
+// Epoch mengembalikan ID acak feed ini. Seq dari epoch lain tidak bisa dipakai untuk resume.
+func (f *ChangeFeed) Epoch() string {
+	return f.epoch
+}
+
+// publish menerbitkan event untuk setiap revision. Aman dipanggil pada feed nil
+// (misalnya saat store memulihkan data dari disk).
+func (f *ChangeFeed) publish(revs ...Revision) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range revs {
+		f.seq++
+		ev := newChangeEvent(r.clone())
+		ev.Seq = f.seq
+		ev.ID = FormatEventID(f.epoch, f.seq)
+		f.buffer[(f.start+f.count)%len(f.buffer)] = ev
+		if f.count < len(f.buffer) {
+			f.count++
+		} else {
+			f.start = (f.start + 1) % len(f.buffer)
+		}
+
+		for sub := range f.subs {
+			if !sub.filter.Match(ev) {
+				continue
+			}
+			select {
+			case sub.ch <- ev:
+			default:
+				sub.lagged = true
+				f.closeLocked(sub)
+			}
+		}
+	}
+}
+
+// Subscribe mulai berlangganan event dengan Seq lebih besar dari lastSeq yang lolos filter.
+//
+// Parameters:
+//   - epoch: epoch dari ID event terakhir yang diterima client (lihat ParseEventID)
+//   - lastSeq: Seq event terakhir yang sudah diterima client, atau 0 untuk event baru saja
+//   - filter: batasan penulis atau ID buku
+//
+// Returns:
+//   - Subscription untuk event baru; panggil Close jika sudah tidak dipakai
+//   - event di buffer setelah lastSeq yang harus dikirim lebih dulu
+//   - false jika event setelah lastSeq sudah tidak lengkap di buffer (terlalu lama)
+//     atau epoch berbeda (dari sebelum server dijalankan ulang) sehingga client harus
+//     memuat ulang data
+func (f *ChangeFeed) Subscribe(epoch string, lastSeq uint64, filter ChangeFilter) (*Subscription, []ChangeEvent, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub := &Subscription{feed: f, ch: make(chan ChangeEvent, changeSubscriberBuffer), filter: filter, startSeq: f.seq}
+	f.subs[sub] = struct{}{}
+
+	if lastSeq == 0 {
+		return sub, nil, true
+	}
+	oldest := f.seq - uint64(f.count) + 1
+	if epoch != f.epoch || lastSeq > f.seq || lastSeq+1 < oldest {
+		return sub, nil, false
+	}
+	var backlog []ChangeEvent
+	for i := int(lastSeq + 1 - oldest); i < f.count; i++ {
+		if ev := f.buffer[(f.start+i)%len(f.buffer)]; filter.Match(ev) {
+			backlog = append(backlog, ev)
+		}
+	}
+	return sub, backlog, true
+}
+
+// LastSeq mengembalikan Seq event terakhir yang diterbitkan.
+func (f *ChangeFeed) LastSeq() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seq
+}
+
+// closeLocked menghapus subscriber dan menutup channel-nya. Harus dipanggil dengan f.mu terkunci.
+func (f *ChangeFeed) closeLocked(sub *Subscription) {
+	if _, ok := f.subs[sub]; ok {
+		delete(f.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Subscription adalah langganan ke ChangeFeed.
+type Subscription struct {
+	feed     *ChangeFeed
+	ch       chan ChangeEvent
+	filter   ChangeFilter
+	startSeq uint64
+	lagged   bool
+}
+
+// StartSeq mengembalikan Seq event terakhir saat langganan dibuat. Event setelahnya
+// dikirim lewat Events, jadi client yang harus memuat ulang data bisa melanjutkan dari sini.
+func (s *Subscription) StartSeq() uint64 {
+	return s.startSeq
+}
+
+// StartID mengembalikan StartSeq sebagai ID event (lihat FormatEventID).
+func (s *Subscription) StartID() string {
+	return FormatEventID(s.feed.epoch, s.startSeq)
+}
+
+// Events mengembalikan channel event baru. Channel ditutup setelah Close, atau ketika
+// subscriber tertinggal terlalu jauh (lihat Lagged).
+func (s *Subscription) Events() <-chan ChangeEvent {
+	return s.ch
+}
+
+// Lagged melaporkan apakah langganan diputus karena subscriber tidak mengambil event
+// cukup cepat. Client sebaiknya berlangganan lagi dengan Seq terakhir yang diterimanya.
+func (s *Subscription) Lagged() bool {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	return s.lagged
+}
+
+// Close menghentikan langganan. Aman dipanggil lebih dari sekali.
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.closeLocked(s)
+}
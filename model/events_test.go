@@ -0,0 +1,157 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeFeed(t *testing.T) {
+	runChangeFeedTests(t, setupStore())
+}
+
+// runChangeFeedTests memastikan setiap perubahan yang berhasil diterbitkan sebagai
+// ChangeEvent berurutan, dan perubahan yang gagal tidak menerbitkan apa pun.
+func runChangeFeedTests(t *testing.T, store BookStore) {
+	t.Helper()
+	feed := store.(ChangeNotifier).Changes()
+	sub, backlog, ok := feed.Subscribe(feed.Epoch(), 0, ChangeFilter{})
+	defer sub.Close()
+	if !ok || len(backlog) != 0 {
+		t.Fatalf("Expected fresh subscription, got backlog %+v (ok=%v)", backlog, ok)
+	}
+
+	added := defaultBook(store)
+	store.UpdateBook(ctx, added.ID, Book{Title: "Updated", Author: "Go Dev", PublishedYear: 2024})
+	store.ApplyBatch(ctx, []BatchOp{{Op: BatchUpdate, ID: added.ID, Version: 99, Book: &Book{Title: "Lost", Author: "X", PublishedYear: 2000}}})
+	store.ApplyBatch(ctx, []BatchOp{
+		{Op: BatchCreate, Book: &Book{Title: "Batch", Author: "Go Dev", PublishedYear: 2024}},
+		{Op: BatchDelete, ID: added.ID},
+	})
+	store.RestoreBook(ctx, added.ID)
+	store.PurgeBook(ctx, added.ID, 0)
+
+	want := []string{ChangeCreated, ChangeUpdated, ChangeCreated, ChangeDeleted, ChangeRestored, ChangePurged}
+	for i, typ := range want {
+		select {
+		case ev := <-sub.Events():
+			if ev.Type != typ || ev.Seq != feed.LastSeq()-uint64(len(want)-1-i) || ev.Book == nil || ev.At.IsZero() {
+				t.Errorf("Event %d: expected %s, got %+v", i, typ, ev)
+			}
+			if typ == ChangeDeleted && (ev.BookID != added.ID || ev.Book.Title != "Updated") {
+				t.Errorf("Expected deleted event to carry the last state, got %+v", ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for event %d (%s)", i, typ)
+		}
+	}
+	select {
+	case ev := <-sub.Events():
+		t.Errorf("Unexpected extra event %+v", ev)
+	default:
+	}
+}
+
+func TestChangeFeedResumeAndFilter(t *testing.T) {
+	feed := NewChangeFeed(3)
+	book := func(id int, author string) *Book { return &Book{ID: id, Author: author} }
+	for i := 1; i <= 5; i++ {
+		author := "Ana"
+		if i%2 == 0 {
+			author = "Budi"
+		}
+		feed.publish(Revision{BookID: i, Action: ActionCreate, After: book(i, author)})
+	}
+
+	tests := []struct {
+		name     string
+		lastSeq  uint64
+		filter   ChangeFilter
+		wantSeqs []uint64
+		wantOK   bool
+	}{
+		{"fresh", 0, ChangeFilter{}, nil, true},
+		{"resume within buffer", 3, ChangeFilter{}, []uint64{4, 5}, true},
+		{"resume at oldest boundary", 2, ChangeFilter{}, []uint64{3, 4, 5}, true},
+		{"up to date", 5, ChangeFilter{}, nil, true},
+		{"evicted", 1, ChangeFilter{}, nil, false},
+		{"from the future", 9, ChangeFilter{}, nil, false},
+		{"author filter", 2, ChangeFilter{Authors: []string{"budi"}}, []uint64{4}, true},
+		{"id filter", 2, ChangeFilter{IDs: []int{3, 5}}, []uint64{3, 5}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog, ok := feed.Subscribe(feed.Epoch(), tt.lastSeq, tt.filter)
+			defer sub.Close()
+			if ok != tt.wantOK || len(backlog) != len(tt.wantSeqs) {
+				t.Fatalf("Expected %v (ok=%v), got %+v (ok=%v)", tt.wantSeqs, tt.wantOK, backlog, ok)
+			}
+			for i, ev := range backlog {
+				if ev.Seq != tt.wantSeqs[i] || ev.ID != FormatEventID(feed.Epoch(), ev.Seq) {
+					t.Errorf("Backlog %d: expected seq %d, got %d (id %q)", i, tt.wantSeqs[i], ev.Seq, ev.ID)
+				}
+			}
+		})
+	}
+
+	// Seq yang sama dari feed lain (server sebelum dijalankan ulang) tidak boleh dilanjutkan.
+	for _, epoch := range []string{NewChangeFeed(3).Epoch(), ""} {
+		sub, backlog, ok := feed.Subscribe(epoch, 3, ChangeFilter{})
+		sub.Close()
+		if ok || len(backlog) != 0 {
+			t.Errorf("Expected reset for epoch %q, got %+v (ok=%v)", epoch, backlog, ok)
+		}
+	}
+
+	sub, _, _ := feed.Subscribe(feed.Epoch(), 0, ChangeFilter{IDs: []int{7}})
+	feed.publish(Revision{BookID: 6, Action: ActionCreate, After: book(6, "Ana")})
+	feed.publish(Revision{BookID: 7, Action: ActionDelete, Before: book(7, "Ana")})
+	if ev := <-sub.Events(); ev.BookID != 7 || ev.Type != ChangeDeleted || ev.Seq != 7 {
+		t.Errorf("Expected only the delete of book 7, got %+v", ev)
+	}
+	sub.Close()
+	sub.Close()
+	if _, open := <-sub.Events(); open {
+		t.Error("Expected channel to be closed after Close")
+	}
+}
+
+func TestChangeFeedDropsLaggingSubscriber(t *testing.T) {
+	feed := NewChangeFeed(0)
+	sub, _, _ := feed.Subscribe(feed.Epoch(), 0, ChangeFilter{})
+	for i := 0; i <= changeSubscriberBuffer; i++ {
+		feed.publish(Revision{BookID: 1, Action: ActionUpdate, After: &Book{ID: 1}})
+	}
+
+	n := 0
+	for range sub.Events() {
+		n++
+	}
+	if n != changeSubscriberBuffer || !sub.Lagged() {
+		t.Errorf("Expected %d queued events and a lagged subscription, got %d (lagged=%v)", changeSubscriberBuffer, n, sub.Lagged())
+	}
+	// Subscriber yang tertinggal bisa melanjutkan dari event terakhir yang diterimanya.
+	_, backlog, ok := feed.Subscribe(feed.Epoch(), uint64(n), ChangeFilter{})
+	if !ok || len(backlog) != 1 {
+		t.Errorf("Expected to resume with 1 missed event, got %d (ok=%v)", len(backlog), ok)
+	}
+}
+
+func TestParseEventID(t *testing.T) {
+	tests := []struct {
+		id        string
+		wantEpoch string
+		wantSeq   uint64
+		wantErr   bool
+	}{
+		{FormatEventID("0a1b2c3d", 42), "0a1b2c3d", 42, false},
+		{"42", "", 42, false},
+		{"0a1b2c3d-", "", 0, true},
+		{"abc", "", 0, true},
+	}
+	for _, tt := range tests {
+		epoch, seq, err := ParseEventID(tt.id)
+		if epoch != tt.wantEpoch || seq != tt.wantSeq || (err != nil) != tt.wantErr {
+			t.Errorf("ParseEventID(%q) = %q, %d, %v", tt.id, epoch, seq, err)
+		}
+	}
+}
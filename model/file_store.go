@@ -0,0 +1,1085 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyncMode menentukan kapan WAL di-fsync ke disk.
+type SyncMode int
+
+const (
+	// SyncAlways melakukan fsync setelah setiap operasi tulis. Paling aman, paling lambat.
+	SyncAlways SyncMode = iota
+	// SyncInterval melakukan fsync secara berkala sesuai FileStoreOptions.SyncInterval.
+	SyncInterval
+	// SyncNone tidak pernah fsync secara eksplisit dan menyerahkannya ke OS.
+	SyncNone
+)
+
+const (
+	walFileName      = "books.wal"
+	snapshotFileName = "books.snapshot"
+	// webhookFileName menyimpan webhook beserta delivery log dan dead letter-nya.
+	// Berisi secret webhook sehingga hanya bisa dibaca pemilik proses.
+	webhookFileName = "webhooks.json"
+
+	walOpPut = "put"
+	// walOpDelete menghapus buku secara permanen; hanya ada pada WAL dari sebelum
+	// soft delete dan tetap dibaca saat replay.
+	walOpDelete = "delete"
+	// walOpTrash memindahkan buku ke trash, walOpRestore mengembalikannya (Book berisi
+	// buku hasil restore), dan walOpPurge menghapus permanen buku aktif maupun di trash.
+	walOpTrash   = "trash"
+	walOpRestore = "restore"
+	walOpPurge   = "purge"
+	// walOpBatch menyimpan beberapa entri dalam satu baris WAL agar batch diterapkan
+	// seluruhnya atau tidak sama sekali saat replay.
+	walOpBatch = "batch"
+
+	defaultSyncInterval      = time.Second
+	defaultSnapshotInterval  = time.Minute
+	defaultSnapshotThreshold = 1000
+)
+
+// ParseSyncMode mengubah string konfigurasi ("always", "interval", "none") menjadi SyncMode.
+func ParseSyncMode(s string) (SyncMode, error) {
+	switch s {
+	case "always", "":
+		return SyncAlways, nil
+	case "interval":
+		return SyncInterval, nil
+	case "none":
+		return SyncNone, nil
+	}
+	return SyncAlways, fmt.Errorf("unknown sync mode %q", s)
+}
+
+// FileStoreOptions berisi konfigurasi untuk BookStore berbasis file.
+type FileStoreOptions struct {
+	// Dir adalah direktori data tempat WAL dan snapshot disimpan.
+	Dir string
+	// SyncMode menentukan kebijakan fsync WAL.
+	SyncMode SyncMode
+	// SyncInterval adalah jeda fsync untuk SyncInterval (default 1 detik).
+	SyncInterval time.Duration
+	// SnapshotInterval adalah jeda compaction berkala (default 1 menit, negatif untuk menonaktifkan).
+	SnapshotInterval time.Duration
+	// SnapshotThreshold adalah jumlah entri WAL yang memicu compaction (default 1000, negatif untuk menonaktifkan).
+	SnapshotThreshold int
+}
+
+// ClosableBookStore adalah BookStore yang memegang resource eksternal dan harus ditutup.
+type ClosableBookStore interface {
+	BookStore
+	io.Closer
+}
+
+type walRecord struct {
+	Seq    uint64      `json:"seq"`
+	Op     string      `json:"op"`
+	ID     int         `json:"id"`
+	LastID int         `json:"last_id"`
+	At     time.Time   `json:"at"`
+	Book   *Book       `json:"book,omitempty"`
+	Batch  []walRecord `json:"batch,omitempty"`
+	// Actor dan RequestID adalah info audit untuk Revision yang dibuat dari entri ini.
+	Actor     string `json:"actor,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type snapshot struct {
+	Seq        uint64    `json:"seq"`
+	LastID     int       `json:"last_id"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Books      []Book    `json:"books"`
+	// History berisi seluruh revision; kosong pada snapshot dari sebelum riwayat dicatat.
+	History []Revision `json:"history,omitempty"`
+	// Trash berisi buku yang sudah dihapus tetapi belum dihapus permanen.
+	Trash []TrashedBook `json:"trash,omitempty"`
+}
+
+// walFile adalah file WAL yang dipakai fileBookStore; *os.File di luar pengujian.
+type walFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+type fileBookStore struct {
+	mu         sync.RWMutex
+	books      map[int]Book
+	trash      map[int]TrashedBook
+	lastID     int
+	seq        uint64
+	walEntries int
+	dirty      bool
+	index      *searchIndex
+	history    historyLog
+	modifiedAt time.Time
+	// feed baru diisi setelah data dipulihkan agar replay tidak menerbitkan event.
+	feed *ChangeFeed
+	// webhooks ditulis ulang utuh ke webhookFileName setiap kali berubah.
+	webhooks *webhookStore
+
+	opts FileStoreOptions
+	wal  walFile
+	// walSize adalah panjang WAL yang berisi entri lengkap, tempat entri yang gagal ditulis dipotong.
+	walSize int64
+	// failed berisi penyebab WAL tidak bisa dipulihkan setelah penulisan gagal; semua
+	// penulisan berikutnya ditolak dengan ErrUnavailable.
+	failed error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewFileBookStore membuka (atau membuat) BookStore yang disimpan di direktori lokal.
+// Semua perubahan ditulis ke write-ahead log yang hanya di-append, lalu dipadatkan
+// secara berkala ke snapshot. Saat dibuka, snapshot dan WAL dibaca ulang untuk
+// memulihkan semua buku beserta lastID.
+//
+// Parameters:
+//   - opts: konfigurasi direktori data, mode fsync, dan jadwal compaction
+//
+// Returns:
+//   - ClosableBookStore yang siap dipakai
+//   - error jika direktori atau file data tidak dapat dibaca/dibuat
+func NewFileBookStore(opts FileStoreOptions) (ClosableBookStore, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("data directory is required")
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = defaultSyncInterval
+	}
+	if opts.SnapshotInterval == 0 {
+		opts.SnapshotInterval = defaultSnapshotInterval
+	}
+	if opts.SnapshotThreshold == 0 {
+		opts.SnapshotThreshold = defaultSnapshotThreshold
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	fs := &fileBookStore{
+		books:   make(map[int]Book),
+		trash:   make(map[int]TrashedBook),
+		index:   newSearchIndex(),
+		history: make(historyLog),
+		opts:    opts,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if err := fs.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := fs.replayWAL(); err != nil {
+		return nil, err
+	}
+	webhooks, err := openFileWebhookStore(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	fs.webhooks = webhooks
+
+	wal, err := os.OpenFile(fs.walPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	info, err := wal.Stat()
+	if err != nil {
+		wal.Close()
+		return nil, fmt.Errorf("stat wal: %w", err)
+	}
+	fs.wal = wal
+	fs.walSize = info.Size()
+	fs.feed = NewChangeFeed(DefaultChangeBufferSize)
+
+	go fs.background()
+
+	return fs, nil
+}
+
+func (fs *fileBookStore) walPath() string {
+	return filepath.Join(fs.opts.Dir, walFileName)
+}
+
+func (fs *fileBookStore) snapshotPath() string {
+	return filepath.Join(fs.opts.Dir, snapshotFileName)
+}
+
+// loadSnapshot membaca snapshot terakhir jika ada.
+func (fs *fileBookStore) loadSnapshot() error {
+	data, err := os.ReadFile(fs.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	fs.seq = snap.Seq
+	fs.lastID = snap.LastID
+	fs.modifiedAt = snap.ModifiedAt
+	for _, b := range snap.Books {
+		if b.Version == 0 {
+			b.Version = 1
+		}
+		fs.books[b.ID] = b
+		fs.index.put(b)
+	}
+	for _, r := range snap.History {
+		fs.history[r.BookID] = append(fs.history[r.BookID], r)
+	}
+	for _, t := range snap.Trash {
+		fs.trash[t.ID] = t
+	}
+	return nil
+}
+
+// replayWAL menerapkan ulang entri WAL yang lebih baru dari snapshot.
+// Entri terakhir yang terpotong atau checksum-nya salah (akibat crash di tengah
+// penulisan) dibuang dan file WAL dipotong ke entri valid terakhir.
+func (fs *fileBookStore) replayWAL() error {
+	f, err := os.OpenFile(fs.walPath(), os.O_RDWR, 0o644)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open wal: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				log.Printf("file store: discarding torn wal tail at offset %d", offset)
+				return f.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read wal: %w", err)
+		}
+
+		// Baris lengkap (diakhiri newline) sudah pernah ditulis utuh, jadi kerusakannya
+		// bukan akibat crash saat menulis. Entri setelahnya mungkin sudah di-fsync,
+		// sehingga store menolak dibuka alih-alih membuangnya.
+		rec, err := decodeWALLine(line)
+		if err != nil {
+			return fmt.Errorf("corrupt wal entry at offset %d: %w", offset, err)
+		}
+		offset += int64(len(line))
+
+		if rec.Seq <= fs.seq {
+			continue
+		}
+		fs.apply(rec)
+		fs.walEntries++
+	}
+}
+
+// apply menerapkan satu entri WAL ke state di memori dan mencatat revision-nya.
+// Karena dipanggil juga saat replay, riwayat yang belum masuk snapshot ikut dipulihkan.
+func (fs *fileBookStore) apply(rec walRecord) {
+	audit := Audit{Actor: rec.Actor, RequestID: rec.RequestID}
+	var before *Book
+	if b, ok := fs.books[rec.ID]; ok {
+		before = &b
+	}
+	switch rec.Op {
+	case walOpPut:
+		if rec.Book != nil {
+			if rec.Book.Version == 0 {
+				// Entri dari sebelum buku memiliki versi.
+				rec.Book.Version = 1
+			}
+			fs.books[rec.ID] = *rec.Book
+			fs.index.put(*rec.Book)
+			fs.feed.publish(fs.history.record(audit, revisionAction(before, rec.Book), before, rec.Book, rec.At))
+		}
+	case walOpDelete:
+		delete(fs.books, rec.ID)
+		fs.index.remove(rec.ID)
+		if before != nil {
+			fs.feed.publish(fs.history.record(audit, ActionDelete, before, nil, rec.At))
+		}
+	case walOpTrash:
+		if before != nil {
+			delete(fs.books, rec.ID)
+			fs.index.remove(rec.ID)
+			fs.trash[rec.ID] = TrashedBook{Book: *before, DeletedAt: rec.At}
+			fs.feed.publish(fs.history.record(audit, ActionDelete, before, nil, rec.At))
+		}
+	case walOpRestore:
+		if rec.Book != nil {
+			delete(fs.trash, rec.ID)
+			fs.books[rec.ID] = *rec.Book
+			fs.index.put(*rec.Book)
+			fs.feed.publish(fs.history.record(audit, ActionRestore, nil, rec.Book, rec.At))
+		}
+	case walOpPurge:
+		if t, ok := fs.trash[rec.ID]; ok && before == nil {
+			before = &t.Book
+		}
+		delete(fs.books, rec.ID)
+		delete(fs.trash, rec.ID)
+		fs.index.remove(rec.ID)
+		if before != nil {
+			fs.feed.publish(fs.history.record(audit, ActionPurge, before, nil, rec.At))
+		}
+	case walOpBatch:
+		for _, sub := range rec.Batch {
+			sub.Seq = rec.Seq
+			sub.Actor, sub.RequestID = rec.Actor, rec.RequestID
+			fs.apply(sub)
+		}
+	}
+	if rec.LastID > fs.lastID {
+		fs.lastID = rec.LastID
+	}
+	if !rec.At.IsZero() {
+		fs.modifiedAt = rec.At
+	}
+	fs.seq = rec.Seq
+}
+
+// encodeWALLine mengubah entri menjadi satu baris "<crc32> <json>\n".
+func encodeWALLine(rec walRecord) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	sum := crc32.ChecksumIEEE(payload)
+	line := make([]byte, 0, len(payload)+10)
+	line = append(line, fmt.Sprintf("%08x ", sum)...)
+	line = append(line, payload...)
+	line = append(line, '\n')
+	return line, nil
+}
+
+func decodeWALLine(line []byte) (walRecord, error) {
+	var rec walRecord
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	sumPart, payload, ok := bytes.Cut(line, []byte{' '})
+	if !ok {
+		return rec, errors.New("malformed entry")
+	}
+	want, err := strconv.ParseUint(string(sumPart), 16, 32)
+	if err != nil {
+		return rec, fmt.Errorf("malformed checksum: %w", err)
+	}
+	if crc32.ChecksumIEEE(payload) != uint32(want) {
+		return rec, errors.New("checksum mismatch")
+	}
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
+
+// appendWAL menulis entri ke WAL dan menerapkan kebijakan fsync. Jika penulisan atau
+// fsync gagal, WAL dipotong kembali ke panjang sebelum entri ditulis agar entri yang
+// ditolak tidak ikut di-replay dan entri berikutnya tidak menempel pada baris yang
+// terpotong. Jika pemotongan juga gagal, store masuk ke kondisi gagal.
+// Harus dipanggil dengan fs.mu terkunci.
+func (fs *fileBookStore) appendWAL(rec walRecord) error {
+	if fs.wal == nil {
+		return ErrUnavailable
+	}
+	if fs.failed != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, fs.failed)
+	}
+
+	line, err := encodeWALLine(rec)
+	if err != nil {
+		return fmt.Errorf("encode wal entry: %w", err)
+	}
+	if _, err := fs.wal.Write(line); err != nil {
+		fs.rollbackWAL()
+		return fmt.Errorf("write wal: %w", err)
+	}
+
+	switch fs.opts.SyncMode {
+	case SyncAlways:
+		if err := fs.wal.Sync(); err != nil {
+			fs.rollbackWAL()
+			return fmt.Errorf("sync wal: %w", err)
+		}
+	case SyncInterval:
+		fs.dirty = true
+	}
+
+	fs.walSize += int64(len(line))
+	fs.walEntries++
+	return nil
+}
+
+// rollbackWAL membuang sisa entri yang gagal ditulis dengan memotong WAL ke fs.walSize.
+// Harus dipanggil dengan fs.mu terkunci.
+func (fs *fileBookStore) rollbackWAL() {
+	err := fs.wal.Truncate(fs.walSize)
+	if err == nil {
+		err = fs.wal.Sync()
+	}
+	if err != nil {
+		fs.failed = fmt.Errorf("roll back wal to offset %d: %w", fs.walSize, err)
+		log.Printf("file store: %v; rejecting further writes", fs.failed)
+	}
+}
+
+// commit mencatat entri ke WAL, beserta info audit dari ctx, lalu menerapkannya ke memori.
+// Harus dipanggil dengan fs.mu terkunci.
+func (fs *fileBookStore) commit(ctx context.Context, rec walRecord) error {
+	rec.Seq = fs.seq + 1
+	if rec.At.IsZero() {
+		rec.At = now()
+	}
+	audit := AuditFromContext(ctx)
+	rec.Actor, rec.RequestID = audit.Actor, audit.RequestID
+	if err := fs.appendWAL(rec); err != nil {
+		return err
+	}
+	fs.apply(rec)
+
+	if fs.opts.SnapshotThreshold > 0 && fs.walEntries >= fs.opts.SnapshotThreshold {
+		if err := fs.compact(); err != nil {
+			log.Printf("file store: compaction failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// compact menulis snapshot atomik dari state saat ini lalu mengosongkan WAL.
+// Harus dipanggil dengan fs.mu terkunci.
+func (fs *fileBookStore) compact() error {
+	snap := snapshot{Seq: fs.seq, LastID: fs.lastID, ModifiedAt: fs.modifiedAt, Books: make([]Book, 0, len(fs.books))}
+	for _, b := range fs.books {
+		snap.Books = append(snap.Books, b)
+	}
+	sort.Slice(snap.Books, func(i, j int) bool { return snap.Books[i].ID < snap.Books[j].ID })
+	snap.History = fs.history.all()
+	for _, t := range fs.trash {
+		snap.Trash = append(snap.Trash, t)
+	}
+	sort.Slice(snap.Trash, func(i, j int) bool { return snap.Trash[i].ID < snap.Trash[j].ID })
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	tmp := fs.snapshotPath() + ".tmp"
+	if err := writeFileSync(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fs.snapshotPath()); err != nil {
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+	if err := syncDir(fs.opts.Dir); err != nil {
+		return err
+	}
+
+	// Snapshot sudah aman di disk; entri WAL dengan seq <= snap.Seq akan
+	// dilewati saat recovery, jadi WAL boleh dikosongkan.
+	if err := fs.wal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	fs.walSize = 0
+	if err := fs.wal.Sync(); err != nil {
+		return fmt.Errorf("sync wal: %w", err)
+	}
+	fs.walEntries = 0
+	fs.dirty = false
+	return nil
+}
+
+func writeFileSync(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// openFileWebhookStore memuat webhook dari webhookFileName di dir (jika ada) dan
+// mengembalikan WebhookStore yang menulis ulang file tersebut secara atomik setelah
+// setiap perubahan.
+func openFileWebhookStore(dir string) (*webhookStore, error) {
+	path := filepath.Join(dir, webhookFileName)
+	ws := &webhookStore{hooks: make(map[int]*webhookEntry)}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("read webhooks: %w", err)
+	default:
+		var st webhookState
+		if err := json.Unmarshal(data, &st); err != nil {
+			return nil, fmt.Errorf("decode webhooks: %w", err)
+		}
+		ws.restore(st)
+	}
+
+	ws.save = func(st webhookState) error {
+		data, err := json.Marshal(st)
+		if err != nil {
+			return fmt.Errorf("encode webhooks: %w", err)
+		}
+		tmp := path + ".tmp"
+		if err := writeFileSync(tmp, data, 0o600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return fmt.Errorf("rename webhooks: %w", err)
+		}
+		return syncDir(dir)
+	}
+	return ws, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open dir: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync dir: %w", err)
+	}
+	return nil
+}
+
+// background menjalankan fsync berkala dan compaction berkala sampai Close dipanggil.
+func (fs *fileBookStore) background() {
+	defer close(fs.done)
+
+	var syncC, snapC <-chan time.Time
+	if fs.opts.SyncMode == SyncInterval {
+		t := time.NewTicker(fs.opts.SyncInterval)
+		defer t.Stop()
+		syncC = t.C
+	}
+	if fs.opts.SnapshotInterval > 0 {
+		t := time.NewTicker(fs.opts.SnapshotInterval)
+		defer t.Stop()
+		snapC = t.C
+	}
+
+	for {
+		select {
+		case <-fs.stop:
+			return
+		case <-syncC:
+			fs.mu.Lock()
+			if fs.dirty && fs.wal != nil {
+				if err := fs.wal.Sync(); err != nil {
+					log.Printf("file store: periodic sync failed: %v", err)
+				} else {
+					fs.dirty = false
+				}
+			}
+			fs.mu.Unlock()
+		case <-snapC:
+			fs.mu.Lock()
+			if fs.walEntries > 0 && fs.wal != nil {
+				if err := fs.compact(); err != nil {
+					log.Printf("file store: periodic compaction failed: %v", err)
+				}
+			}
+			fs.mu.Unlock()
+		}
+	}
+}
+
+// Close menghentikan proses background, melakukan fsync terakhir, dan menutup WAL.
+func (fs *fileBookStore) Close() error {
+	fs.mu.Lock()
+	if fs.wal == nil {
+		fs.mu.Unlock()
+		return nil
+	}
+	fs.mu.Unlock()
+
+	close(fs.stop)
+	<-fs.done
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	err := fs.wal.Sync()
+	if cerr := fs.wal.Close(); err == nil {
+		err = cerr
+	}
+	fs.wal = nil
+	return err
+}
+
+// AddBook menambahkan buku baru, mencatatnya ke WAL, dan memberikan ID secara otomatis.
+//
+// Parameters:
+//   - ctx: context request
+//   - book: Book tanpa ID (akan diisi otomatis)
+//
+// Returns:
+//   - Book yang sudah memiliki ID
+//   - error jika context dibatalkan atau WAL gagal ditulis
+func (fs *fileBookStore) AddBook(ctx context.Context, book Book) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	book.ID = fs.lastID + 1
+	book.Version = 1
+	book.UpdatedAt = now()
+	if err := fs.commit(ctx, walRecord{Op: walOpPut, ID: book.ID, LastID: book.ID, At: book.UpdatedAt, Book: &book}); err != nil {
+		return Book{}, err
+	}
+	return book, nil
+}
+
+// GetAllBooks mengembalikan semua buku dalam bentuk slice.
+//
+// Parameters:
+//   - ctx: context request
+//
+// Returns:
+//   - Slice dari semua Book yang tersimpan
+//   - error jika context sudah dibatalkan
+func (fs *fileBookStore) GetAllBooks(ctx context.Context) ([]Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	books := []Book{}
+	for _, b := range fs.books {
+		books = append(books, b)
+	}
+	return books, nil
+}
+
+// QueryBooks mengembalikan satu halaman buku sesuai filter, urutan, dan paginasi.
+//
+// Parameters:
+//   - ctx: context request
+//   - q: filter, sort, limit/offset atau cursor
+//
+// Returns:
+//   - BookPage berisi buku, total hasil filter, dan cursor halaman berikutnya
+//   - error yang membungkus ErrValidation jika query tidak valid
+func (fs *fileBookStore) QueryBooks(ctx context.Context, q BookQuery) (BookPage, error) {
+	return collectQuery(func(fn func(Book) error) (BookPage, error) {
+		return fs.StreamBooks(ctx, q, fn)
+	})
+}
+
+// StreamBooks memanggil fn untuk setiap buku pada halaman hasil query tanpa menyalin
+// seluruh map. Dengan urutan default (id ascending) hanya daftar ID yang disalin.
+//
+// Parameters:
+//   - ctx: context request
+//   - q: filter, sort, limit/offset atau cursor
+//   - fn: fungsi yang dipanggil untuk setiap buku secara berurutan
+//
+// Returns:
+//   - BookPage berisi total hasil filter dan cursor halaman berikutnya (Books kosong)
+//   - error yang membungkus ErrValidation jika query tidak valid, error dari fn, atau
+//     error context jika request dibatalkan
+func (fs *fileBookStore) StreamBooks(ctx context.Context, q BookQuery, fn func(Book) error) (BookPage, error) {
+	return streamQuery(q, func(fn func(Book) error) error {
+		return fs.EachBook(ctx, fn)
+	}, fn)
+}
+
+// EachBook memanggil fn untuk setiap buku berurutan menurut ID.
+//
+// Parameters:
+//   - ctx: context request
+//   - fn: fungsi yang dipanggil untuk setiap buku
+//
+// Returns:
+//   - error dari fn, atau error context jika request dibatalkan
+func (fs *fileBookStore) EachBook(ctx context.Context, fn func(Book) error) error {
+	return eachBook(ctx, &fs.mu, fs.books, fn)
+}
+
+// SearchBooks mencari buku lewat inverted index pada judul dan penulis.
+//
+// Parameters:
+//   - ctx: context request
+//   - query: kata kunci; setiap kata harus cocok secara utuh atau sebagai awalan
+//   - limit: jumlah hasil maksimum (0 untuk default)
+//
+// Returns:
+//   - Slice SearchHit terurut dari skor tertinggi
+//   - error yang membungkus ErrValidation jika query kosong
+func (fs *fileBookStore) SearchBooks(ctx context.Context, query string, limit int) ([]SearchHit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.index.search(query, limit)
+}
+
+// CollectionStamp mengembalikan penanda perubahan terakhir pada koleksi buku.
+// Seq sama dengan nomor urut entri WAL terakhir sehingga tetap naik setelah restart.
+//
+// Parameters:
+//   - ctx: context request
+//
+// Returns:
+//   - CollectionStamp saat ini
+//   - error jika context sudah dibatalkan
+func (fs *fileBookStore) CollectionStamp(ctx context.Context) (CollectionStamp, error) {
+	if err := ctx.Err(); err != nil {
+		return CollectionStamp{}, err
+	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return CollectionStamp{Seq: fs.seq, ModifiedAt: fs.modifiedAt}, nil
+}
+
+// GetBookByID mencari buku berdasarkan ID.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku yang dicari
+//
+// Returns:
+//   - Book jika ditemukan
+//   - ErrNotFound jika tidak ditemukan
+func (fs *fileBookStore) GetBookByID(ctx context.Context, id int) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	b, ok := fs.books[id]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+	return b, nil
+}
+
+// UpdateBook memperbarui data buku berdasarkan ID dan mencatatnya ke WAL.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku yang ingin diperbarui
+//   - updated: data baru untuk buku; Version yang tidak nol harus sama dengan versi saat ini
+//
+// Returns:
+//   - Book hasil update
+//   - ErrNotFound jika ID tidak ditemukan
+//   - ErrPreconditionFailed jika versi tidak cocok, atau error lain jika WAL gagal ditulis
+func (fs *fileBookStore) UpdateBook(ctx context.Context, id int, updated Book) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	current, ok := fs.books[id]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+	if err := checkVersion(current, updated.Version); err != nil {
+		return Book{}, err
+	}
+	updated.ID = id
+	updated.Version = current.Version + 1
+	updated.UpdatedAt = now()
+	if err := fs.commit(ctx, walRecord{Op: walOpPut, ID: id, LastID: fs.lastID, At: updated.UpdatedAt, Book: &updated}); err != nil {
+		return Book{}, err
+	}
+	return updated, nil
+}
+
+// PatchBook mengubah buku berdasarkan hasil fungsi patch terhadap data saat ini
+// dan mencatatnya ke WAL. Fungsi patch dijalankan saat lock store dipegang.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku yang ingin diubah
+//   - patch: fungsi yang menerima buku saat ini dan mengembalikan buku baru
+//
+// Returns:
+//   - Book hasil patch dengan versi yang dinaikkan
+//   - ErrNotFound jika ID tidak ditemukan, error dari fungsi patch, atau error lain jika WAL gagal ditulis
+func (fs *fileBookStore) PatchBook(ctx context.Context, id int, patch func(current Book) (Book, error)) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	current, ok := fs.books[id]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+	updated, err := patch(current)
+	if err != nil {
+		return Book{}, err
+	}
+	updated.ID = id
+	updated.Version = current.Version + 1
+	updated.UpdatedAt = now()
+	if err := fs.commit(ctx, walRecord{Op: walOpPut, ID: id, LastID: fs.lastID, At: updated.UpdatedAt, Book: &updated}); err != nil {
+		return Book{}, err
+	}
+	return updated, nil
+}
+
+// DeleteBook memindahkan buku berdasarkan ID ke trash dan mencatatnya ke WAL.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku yang akan dihapus
+//   - expectedVersion: versi yang diharapkan, atau 0 untuk tanpa syarat
+//
+// Returns:
+//   - ErrNotFound jika ID tidak ditemukan
+//   - ErrPreconditionFailed jika versi tidak cocok, atau error lain jika WAL gagal ditulis
+func (fs *fileBookStore) DeleteBook(ctx context.Context, id int, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	current, ok := fs.books[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := checkVersion(current, expectedVersion); err != nil {
+		return err
+	}
+	return fs.commit(ctx, walRecord{Op: walOpTrash, ID: id, LastID: fs.lastID})
+}
+
+// ApplyBatch menerapkan semua operasi batch secara atomik. Seluruh perubahan
+// dicatat sebagai satu entri WAL sehingga batch tidak pernah dipulihkan sebagian.
+//
+// Parameters:
+//   - ctx: context request
+//   - ops: operasi create, update, atau delete sesuai urutan
+//
+// Returns:
+//   - hasil setiap operasi (buku yang dibuat/diubah, atau buku yang dihapus)
+//   - *BatchError jika salah satu operasi gagal; tidak ada perubahan yang disimpan
+func (fs *fileBookStore) ApplyBatch(ctx context.Context, ops []BatchOp) ([]Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	lookup := func(id int) (Book, bool) {
+		b, ok := fs.books[id]
+		return b, ok
+	}
+	at := now()
+	results, changes, lastID, err := planBatch(ops, fs.lastID, at, lookup)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return results, nil
+	}
+	batch := make([]walRecord, len(changes))
+	for i, c := range changes {
+		batch[i] = walRecord{Op: walOpPut, ID: c.ID, LastID: lastID, At: at, Book: c.Book}
+		if c.Book == nil {
+			batch[i].Op = walOpTrash
+		}
+	}
+	if err := fs.commit(ctx, walRecord{Op: walOpBatch, LastID: lastID, At: at, Batch: batch}); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// BookHistory mengembalikan semua revision buku berurutan dari yang terlama.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku, termasuk buku yang sudah dihapus
+//
+// Returns:
+//   - slice Revision
+//   - ErrNotFound jika buku tidak pernah ada
+func (fs *fileBookStore) BookHistory(ctx context.Context, id int) ([]Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.history.list(id)
+}
+
+// BookRevision mengembalikan satu revision buku.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku
+//   - rev: nomor revision, mulai dari 1
+//
+// Returns:
+//   - Revision yang diminta
+//   - ErrNotFound jika buku atau revision tidak ada
+func (fs *fileBookStore) BookRevision(ctx context.Context, id int, rev int) (Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return Revision{}, err
+	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.history.get(id, rev)
+}
+
+// ListTrash mengembalikan semua buku di trash, yang paling baru dihapus lebih dulu.
+//
+// Parameters:
+//   - ctx: context request
+//
+// Returns:
+//   - slice TrashedBook
+//   - error jika context sudah dibatalkan
+func (fs *fileBookStore) ListTrash(ctx context.Context) ([]TrashedBook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return trashList(fs.trash), nil
+}
+
+// RestoreBook mengembalikan buku dari trash, menaikkan versinya, dan mencatatnya ke WAL.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku di trash
+//
+// Returns:
+//   - Book yang sudah aktif kembali
+//   - ErrNotFound jika buku tidak ada di trash, atau error lain jika WAL gagal ditulis
+func (fs *fileBookStore) RestoreBook(ctx context.Context, id int) (Book, error) {
+	if err := ctx.Err(); err != nil {
+		return Book{}, err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	trashed, ok := fs.trash[id]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+	book := trashed.Book
+	book.Version++
+	book.UpdatedAt = now()
+	if err := fs.commit(ctx, walRecord{Op: walOpRestore, ID: id, LastID: fs.lastID, At: book.UpdatedAt, Book: &book}); err != nil {
+		return Book{}, err
+	}
+	return book, nil
+}
+
+// PurgeBook menghapus buku secara permanen, baik yang masih aktif maupun yang ada di trash.
+//
+// Parameters:
+//   - ctx: context request
+//   - id: ID buku yang akan dihapus permanen
+//   - expectedVersion: versi yang diharapkan, atau 0 untuk tanpa syarat
+//
+// Returns:
+//   - ErrNotFound jika ID tidak ditemukan di koleksi maupun di trash
+//   - ErrPreconditionFailed jika versi tidak cocok, atau error lain jika WAL gagal ditulis
+func (fs *fileBookStore) PurgeBook(ctx context.Context, id int, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	current, ok := fs.books[id]
+	if !ok {
+		trashed, inTrash := fs.trash[id]
+		if !inTrash {
+			return ErrNotFound
+		}
+		current = trashed.Book
+	}
+	if err := checkVersion(current, expectedVersion); err != nil {
+		return err
+	}
+	return fs.commit(ctx, walRecord{Op: walOpPurge, ID: id, LastID: fs.lastID})
+}
+
+// PurgeTrash menghapus permanen semua buku yang masuk trash sebelum cutoff dalam
+// satu entri WAL.
+//
+// Parameters:
+//   - ctx: context request
+//   - cutoff: buku dengan DeletedAt sebelum waktu ini dihapus
+//
+// Returns:
+//   - jumlah buku yang dihapus
+//   - error jika context dibatalkan atau WAL gagal ditulis
+func (fs *fileBookStore) PurgeTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	ids := expiredTrash(fs.trash, cutoff)
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	at := now()
+	batch := make([]walRecord, len(ids))
+	for i, id := range ids {
+		batch[i] = walRecord{Op: walOpPurge, ID: id, LastID: fs.lastID, At: at}
+	}
+	if err := fs.commit(ctx, walRecord{Op: walOpBatch, LastID: fs.lastID, At: at, Batch: batch}); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// Changes mengembalikan feed event perubahan buku.
+func (fs *fileBookStore) Changes() *ChangeFeed {
+	return fs.feed
+}
+
+// Webhooks mengembalikan WebhookStore yang disimpan di direktori data yang sama.
+func (fs *fileBookStore) Webhooks() WebhookStore {
+	return fs.webhooks
+}
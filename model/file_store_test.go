@@ -0,0 +1,442 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openFileStore(t *testing.T, dir string, opts FileStoreOptions) ClosableBookStore {
+	t.Helper()
+	opts.Dir = dir
+	store, err := NewFileBookStore(opts)
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+	return store
+}
+
+func TestFileStoreRecoversAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{})
+
+	first := defaultBook(store)
+	second := createBook(store, "Laskar Pelangi", "Andrea Hirata", 2005)
+	if _, err := store.UpdateBook(ctx, first.ID, Book{Title: "Updated", Author: "Tester", PublishedYear: 2024}); err != nil {
+		t.Fatalf("Failed to update book: %v", err)
+	}
+	if err := store.DeleteBook(ctx, second.ID, 0); err != nil {
+		t.Fatalf("Failed to delete book: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	reopened := openFileStore(t, dir, FileStoreOptions{})
+	defer reopened.Close()
+
+	if n := countBooks(reopened); n != 1 {
+		t.Fatalf("Expected 1 book after restart, got %d", n)
+	}
+	got, err := reopened.GetBookByID(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Expected book to survive restart, got error: %v", err)
+	}
+	if got.Title != "Updated" {
+		t.Errorf("Expected title %s, got %s", "Updated", got.Title)
+	}
+
+	// lastID harus dipulihkan sehingga ID buku yang sudah dihapus tidak dipakai ulang.
+	added := defaultBook(reopened)
+	if added.ID != second.ID+1 {
+		t.Errorf("Expected next ID %d, got %d", second.ID+1, added.ID)
+	}
+}
+
+func TestFileStoreCompactsIntoSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{SnapshotThreshold: 3, SnapshotInterval: -1})
+
+	for i := 0; i < 4; i++ {
+		defaultBook(store)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err != nil {
+		t.Fatalf("Expected snapshot file, got error: %v", err)
+	}
+
+	reopened := openFileStore(t, dir, FileStoreOptions{})
+	defer reopened.Close()
+
+	if n := countBooks(reopened); n != 4 {
+		t.Errorf("Expected 4 books from snapshot + wal, got %d", n)
+	}
+}
+
+func TestFileStoreDiscardsTornTail(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{})
+	added := defaultBook(store)
+	store.Close()
+
+	// Simulasikan crash di tengah penulisan entri WAL berikutnya.
+	f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open wal: %v", err)
+	}
+	f.WriteString(`0badc0de {"seq":2,"op":"put","id":2`)
+	f.Close()
+
+	reopened := openFileStore(t, dir, FileStoreOptions{})
+	if n := countBooks(reopened); n != 1 {
+		t.Fatalf("Expected 1 book after discarding torn tail, got %d", n)
+	}
+	next := defaultBook(reopened)
+	reopened.Close()
+
+	if next.ID != added.ID+1 {
+		t.Errorf("Expected next ID %d, got %d", added.ID+1, next.ID)
+	}
+
+	// Entri baru harus tetap terbaca setelah ekor WAL yang rusak dipotong.
+	again := openFileStore(t, dir, FileStoreOptions{})
+	defer again.Close()
+	if _, err := again.GetBookByID(ctx, next.ID); err != nil {
+		t.Errorf("Expected book written after recovery to persist, got error: %v", err)
+	}
+}
+
+func TestFileStoreRefusesCorruptMiddleEntry(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{SnapshotInterval: -1, SnapshotThreshold: -1})
+	first := defaultBook(store)
+	defaultBook(store)
+	last := defaultBook(store)
+	store.Close()
+
+	walPath := filepath.Join(dir, walFileName)
+	data, err := os.ReadFile(walPath)
+	if err != nil {
+		t.Fatalf("Failed to read wal: %v", err)
+	}
+	lines := bytes.SplitAfter(bytes.Clone(data), []byte("\n"))
+	if len(lines) < 3 {
+		t.Fatalf("Expected at least 3 wal entries, got %d", len(lines))
+	}
+	// Rusak satu byte di entri tengah; checksum-nya tidak lagi cocok.
+	lines[1][len(lines[1])-3] ^= 0xff
+	corrupt := bytes.Join(lines, nil)
+	if err := os.WriteFile(walPath, corrupt, 0o644); err != nil {
+		t.Fatalf("Failed to write wal: %v", err)
+	}
+
+	if _, err := NewFileBookStore(FileStoreOptions{Dir: dir}); err == nil || !strings.Contains(err.Error(), "offset") {
+		t.Fatalf("Expected corrupt wal error with offset, got %v", err)
+	}
+	after, err := os.ReadFile(walPath)
+	if err != nil || !bytes.Equal(after, corrupt) {
+		t.Fatalf("Expected wal to be left untouched, got %d bytes (%v)", len(after), err)
+	}
+
+	// Setelah entri tengah diperbaiki, semua entri setelahnya masih ada.
+	if err := os.WriteFile(walPath, data, 0o644); err != nil {
+		t.Fatalf("Failed to restore wal: %v", err)
+	}
+	reopened := openFileStore(t, dir, FileStoreOptions{})
+	defer reopened.Close()
+	for _, id := range []int{first.ID, last.ID} {
+		if _, err := reopened.GetBookByID(ctx, id); err != nil {
+			t.Errorf("Expected book %d to survive, got %v", id, err)
+		}
+	}
+}
+
+// faultyWAL menyuntikkan kegagalan sekali pakai ke WAL. Write yang gagal tetap menulis
+// setengah baris seperti penulisan parsial di disk.
+type faultyWAL struct {
+	walFile
+	failWrite, failSync, failTruncate bool
+}
+
+var errInjected = errors.New("injected failure")
+
+func (f *faultyWAL) Write(p []byte) (int, error) {
+	if f.failWrite {
+		f.failWrite = false
+		n, _ := f.walFile.Write(p[:len(p)/2])
+		return n, errInjected
+	}
+	return f.walFile.Write(p)
+}
+
+func (f *faultyWAL) Sync() error {
+	if f.failSync {
+		f.failSync = false
+		return errInjected
+	}
+	return f.walFile.Sync()
+}
+
+func (f *faultyWAL) Truncate(size int64) error {
+	if f.failTruncate {
+		f.failTruncate = false
+		return errInjected
+	}
+	return f.walFile.Truncate(size)
+}
+
+func TestFileStoreRollsBackFailedWALWrites(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{SnapshotInterval: -1})
+	fs := store.(*fileBookStore)
+	wal := &faultyWAL{walFile: fs.wal}
+	fs.wal = wal
+
+	first := defaultBook(store)
+	wal.failWrite = true
+	if _, err := store.AddBook(ctx, Book{Title: "Torn", Author: "Tester", PublishedYear: 2024}); !errors.Is(err, errInjected) {
+		t.Fatalf("Expected injected write error, got %v", err)
+	}
+	second := defaultBook(store)
+
+	wal.failSync = true
+	if _, err := store.AddBook(ctx, Book{Title: "Unsynced", Author: "Tester", PublishedYear: 2024}); !errors.Is(err, errInjected) {
+		t.Fatalf("Expected injected sync error, got %v", err)
+	}
+	third := defaultBook(store)
+	store.Close()
+
+	// Entri setelah penulisan yang gagal harus tetap ada, dan entri yang ditolak tidak boleh muncul.
+	reopened := openFileStore(t, dir, FileStoreOptions{SnapshotInterval: -1})
+	if n := countBooks(reopened); n != 3 {
+		t.Fatalf("Expected 3 books after restart, got %d", n)
+	}
+	for _, b := range []Book{first, second, third} {
+		if got, err := reopened.GetBookByID(ctx, b.ID); err != nil || got.Title != b.Title {
+			t.Errorf("Expected book %d to survive restart, got %+v, %v", b.ID, got, err)
+		}
+	}
+
+	// Jika WAL tidak bisa dipotong, store menolak semua penulisan berikutnya.
+	fs = reopened.(*fileBookStore)
+	fs.wal = &faultyWAL{walFile: fs.wal, failWrite: true, failTruncate: true}
+	if _, err := reopened.AddBook(ctx, Book{Title: "Torn", Author: "Tester", PublishedYear: 2024}); !errors.Is(err, errInjected) {
+		t.Fatalf("Expected injected write error, got %v", err)
+	}
+	if _, err := reopened.AddBook(ctx, Book{Title: "After", Author: "Tester", PublishedYear: 2024}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable after failed rollback, got %v", err)
+	}
+	if _, err := reopened.GetBookByID(ctx, first.ID); err != nil {
+		t.Errorf("Expected reads to keep working, got %v", err)
+	}
+	reopened.Close()
+}
+
+func TestFileStoreSyncModes(t *testing.T) {
+	for _, name := range []string{"always", "interval", "none"} {
+		t.Run(name, func(t *testing.T) {
+			mode, err := ParseSyncMode(name)
+			if err != nil {
+				t.Fatalf("Failed to parse sync mode: %v", err)
+			}
+
+			dir := t.TempDir()
+			store := openFileStore(t, dir, FileStoreOptions{SyncMode: mode})
+			added := defaultBook(store)
+			store.Close()
+
+			reopened := openFileStore(t, dir, FileStoreOptions{SyncMode: mode})
+			defer reopened.Close()
+			if _, err := reopened.GetBookByID(ctx, added.ID); err != nil {
+				t.Errorf("Expected book to persist, got error: %v", err)
+			}
+		})
+	}
+
+	if _, err := ParseSyncMode("sometimes"); err == nil {
+		t.Error("Expected error for unknown sync mode")
+	}
+}
+
+func TestFileStoreClosedIsUnavailable(t *testing.T) {
+	store := openFileStore(t, t.TempDir(), FileStoreOptions{})
+	store.Close()
+
+	if _, err := store.AddBook(ctx, Book{Title: "A", Author: "B", PublishedYear: 2024}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable after close, got %v", err)
+	}
+}
+
+func TestFileStoreVersion(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{})
+	runVersionTests(t, store)
+
+	added := defaultBook(store)
+	store.UpdateBook(ctx, added.ID, Book{Title: "Updated", Author: "Tester", PublishedYear: 2024})
+	store.Close()
+
+	reopened := openFileStore(t, dir, FileStoreOptions{})
+	defer reopened.Close()
+	if got, _ := reopened.GetBookByID(ctx, added.ID); got.Version != 2 {
+		t.Errorf("Expected version 2 to survive restart, got %d", got.Version)
+	}
+}
+
+func TestFileStorePatch(t *testing.T) {
+	store := openFileStore(t, t.TempDir(), FileStoreOptions{})
+	defer store.Close()
+
+	runPatchTests(t, store)
+}
+
+func TestFileStoreBatch(t *testing.T) {
+	store := openFileStore(t, t.TempDir(), FileStoreOptions{})
+	defer store.Close()
+
+	runBatchTests(t, store)
+}
+
+func TestFileStoreBatchSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{})
+	doomed := defaultBook(store)
+	if _, err := store.ApplyBatch(ctx, []BatchOp{
+		{Op: BatchCreate, Book: &Book{Title: "First", Author: "A", PublishedYear: 2001}},
+		{Op: BatchCreate, Book: &Book{Title: "Second", Author: "B", PublishedYear: 2002}},
+		{Op: BatchDelete, ID: doomed.ID},
+	}); err != nil {
+		t.Fatalf("Failed to apply batch: %v", err)
+	}
+	store.Close()
+
+	store = openFileStore(t, dir, FileStoreOptions{})
+	defer store.Close()
+	if got := countBooks(store); got != 2 {
+		t.Errorf("Expected 2 books after restart, got %d", got)
+	}
+	if _, err := store.GetBookByID(ctx, doomed.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected deleted book to stay deleted, got %v", err)
+	}
+	if added := createBook(store, "Third", "C", 2003); added.ID != 4 {
+		t.Errorf("Expected next ID 4 after restart, got %d", added.ID)
+	}
+}
+
+func TestFileStoreEachBook(t *testing.T) {
+	store := openFileStore(t, t.TempDir(), FileStoreOptions{})
+	defer store.Close()
+
+	runEachBookTests(t, store)
+}
+
+func TestFileStoreHistory(t *testing.T) {
+	store := openFileStore(t, t.TempDir(), FileStoreOptions{})
+	defer store.Close()
+
+	runHistoryTests(t, store)
+}
+
+func TestFileStoreHistorySurvivesRestartAndCompaction(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{SnapshotThreshold: 2})
+	audited := WithAudit(ctx, Audit{Actor: "alice"})
+	added, _ := store.AddBook(audited, Book{Title: "One", Author: "A", PublishedYear: 2001})
+	store.UpdateBook(audited, added.ID, Book{Title: "Two", Author: "A", PublishedYear: 2001})
+	store.DeleteBook(audited, added.ID, 0)
+	store.Close()
+
+	store = openFileStore(t, dir, FileStoreOptions{})
+	defer store.Close()
+	revs, err := store.BookHistory(ctx, added.ID)
+	if err != nil || len(revs) != 3 {
+		t.Fatalf("Expected 3 revisions after restart, got %d (%v)", len(revs), err)
+	}
+	if revs[1].Before.Title != "One" || revs[1].After.Title != "Two" || revs[2].Action != ActionDelete || revs[2].Actor != "alice" {
+		t.Errorf("Unexpected revisions after restart: %+v", revs)
+	}
+}
+
+func TestFileStoreTrash(t *testing.T) {
+	store := openFileStore(t, t.TempDir(), FileStoreOptions{})
+	defer store.Close()
+
+	runTrashTests(t, store)
+}
+
+func TestFileStoreTrashSurvivesRestartAndCompaction(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{SnapshotThreshold: 3})
+	kept := defaultBook(store)
+	restored := createBook(store, "Restored", "Go Dev", 2024)
+	store.DeleteBook(ctx, kept.ID, 0)
+	store.DeleteBook(ctx, restored.ID, 0)
+	store.RestoreBook(ctx, restored.ID)
+	store.Close()
+
+	store = openFileStore(t, dir, FileStoreOptions{})
+	defer store.Close()
+	trash, err := store.ListTrash(ctx)
+	if err != nil || len(trash) != 1 || trash[0].ID != kept.ID || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("Expected trashed book after restart, got %+v (%v)", trash, err)
+	}
+	if got, err := store.GetBookByID(ctx, restored.ID); err != nil || got.Version != restored.Version+1 {
+		t.Errorf("Expected restored book after restart, got %+v (%v)", got, err)
+	}
+	if _, err := store.RestoreBook(ctx, kept.ID); err != nil {
+		t.Errorf("Failed to restore book after restart: %v", err)
+	}
+}
+
+func TestFileStoreChangeFeed(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{})
+	runChangeFeedTests(t, store)
+	store.Close()
+
+	// Replay saat store dibuka ulang tidak menerbitkan event lama.
+	store = openFileStore(t, dir, FileStoreOptions{})
+	defer store.Close()
+	if seq := store.(ChangeNotifier).Changes().LastSeq(); seq != 0 {
+		t.Errorf("Expected no events after restart, got last seq %d", seq)
+	}
+}
+
+func TestFileStoreCollectionStampSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{})
+	runStampTests(t, store)
+	before, _ := store.CollectionStamp(ctx)
+	store.Close()
+
+	reopened := openFileStore(t, dir, FileStoreOptions{})
+	defer reopened.Close()
+	after, _ := reopened.CollectionStamp(ctx)
+	if after.Seq != before.Seq || !after.ModifiedAt.Equal(before.ModifiedAt) {
+		t.Errorf("Expected stamp %+v after restart, got %+v", before, after)
+	}
+}
+
+func TestFileStoreWebhooks(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{})
+	runWebhookStoreTests(t, store.(WebhookProvider).Webhooks())
+	store.Close()
+
+	info, err := os.Stat(filepath.Join(dir, webhookFileName))
+	if err != nil {
+		t.Fatalf("Expected webhook file, got %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("Expected webhook file mode 0600, got %v", perm)
+	}
+
+	restartDir := t.TempDir()
+	runWebhookRestartTests(t, func() ClosableBookStore { return openFileStore(t, restartDir, FileStoreOptions{}) })
+}
@@ -0,0 +1,199 @@
+package model
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Jenis perubahan pada Revision.Action.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	// ActionDelete memindahkan buku ke trash.
+	ActionDelete = "delete"
+	// ActionRestore mengembalikan buku dari trash.
+	ActionRestore = "restore"
+	// ActionPurge menghapus buku secara permanen, baik dari koleksi maupun dari trash.
+	ActionPurge = "purge"
+)
+
+// Audit berisi identitas pembuat perubahan yang dicatat pada setiap Revision.
+type Audit struct {
+	// Actor adalah nama atau ID pengguna yang melakukan perubahan.
+	Actor string
+	// RequestID adalah ID request HTTP yang menghasilkan perubahan.
+	RequestID string
+}
+
+type auditKey struct{}
+
+// WithAudit mengembalikan context turunan yang membawa info audit untuk store.
+func WithAudit(ctx context.Context, a Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, a)
+}
+
+// AuditFromContext mengambil info audit dari context; kosong jika tidak ada.
+func AuditFromContext(ctx context.Context) Audit {
+	a, _ := ctx.Value(auditKey{}).(Audit)
+	return a
+}
+
+// Revision adalah catatan permanen satu perubahan pada sebuah buku. Before kosong
+// untuk create dan restore, After kosong untuk delete dan purge.
+type Revision struct {
+	BookID    int       `json:"book_id"`
+	Rev       int       `json:"rev"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	At        time.Time `json:"at"`
+	Before    *Book     `json:"before"`
+	After     *Book     `json:"after"`
+}
+
+// clone menyalin revision beserta isi Before/After agar data tersimpan tidak bisa diubah pemanggil.
+func (r Revision) clone() Revision {
+	if r.Before != nil {
+		b := *r.Before
+		r.Before = &b
+	}
+	if r.After != nil {
+		a := *r.After
+		r.After = &a
+	}
+	return r
+}
+
+// revisionAction menentukan jenis perubahan create, update, atau delete dari kondisi
+// sebelum dan sesudahnya.
+func revisionAction(before, after *Book) string {
+	switch {
+	case before == nil:
+		return ActionCreate
+	case after == nil:
+		return ActionDelete
+	}
+	return ActionUpdate
+}
+
+// FieldChange adalah perubahan nilai satu field buku di antara dua revision.
+// Nilai nil berarti field tidak ada (buku belum dibuat, sudah dihapus, atau ISBN kosong).
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// DiffBooks membandingkan field data buku (title, author, published_year, isbn) dari
+// from ke to. Field yang dikelola store (id, version, updated_at) tidak dibandingkan.
+//
+// Parameters:
+//   - from: kondisi awal; nil jika buku belum ada
+//   - to: kondisi akhir; nil jika buku sudah dihapus
+//
+// Returns:
+//   - daftar field yang berubah, kosong jika tidak ada perbedaan
+func DiffBooks(from, to *Book) []FieldChange {
+	changes := []FieldChange{}
+	for _, f := range diffFields {
+		a, b := f.value(from), f.value(to)
+		if a != b {
+			changes = append(changes, FieldChange{Field: f.name, From: a, To: b})
+		}
+	}
+	return changes
+}
+
+// diffFields adalah field yang dibandingkan DiffBooks, sesuai urutan di JSON.
+var diffFields = []struct {
+	name  string
+	value func(b *Book) interface{}
+}{
+	{"title", func(b *Book) interface{} {
+		if b == nil {
+			return nil
+		}
+		return b.Title
+	}},
+	{"author", func(b *Book) interface{} {
+		if b == nil {
+			return nil
+		}
+		return b.Author
+	}},
+	{"published_year", func(b *Book) interface{} {
+		if b == nil {
+			return nil
+		}
+		return b.PublishedYear
+	}},
+	{"isbn", func(b *Book) interface{} {
+		if b == nil || b.ISBN == "" {
+			return nil
+		}
+		return b.ISBN
+	}},
+}
+
+// historyLog menyimpan revision per ID buku untuk store yang datanya ada di memori.
+type historyLog map[int][]Revision
+
+// record menambahkan revision baru untuk perubahan before -> after dan mengembalikannya.
+func (h historyLog) record(audit Audit, action string, before, after *Book, at time.Time) Revision {
+	id := 0
+	if after != nil {
+		id = after.ID
+	} else if before != nil {
+		id = before.ID
+	}
+	rev := Revision{
+		BookID:    id,
+		Rev:       len(h[id]) + 1,
+		Action:    action,
+		Actor:     audit.Actor,
+		RequestID: audit.RequestID,
+		At:        at,
+		Before:    before,
+		After:     after,
+	}
+	rev = rev.clone()
+	h[id] = append(h[id], rev)
+	return rev
+}
+
+// list mengembalikan salinan semua revision buku id, atau ErrNotFound jika tidak ada.
+func (h historyLog) list(id int) ([]Revision, error) {
+	revs, ok := h[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	out := make([]Revision, len(revs))
+	for i, r := range revs {
+		out[i] = r.clone()
+	}
+	return out, nil
+}
+
+// get mengembalikan salinan revision ke-rev dari buku id, atau ErrNotFound.
+func (h historyLog) get(id, rev int) (Revision, error) {
+	revs := h[id]
+	if rev < 1 || rev > len(revs) {
+		return Revision{}, ErrNotFound
+	}
+	return revs[rev-1].clone(), nil
+}
+
+// all mengembalikan seluruh revision berurutan menurut ID buku lalu nomor revision.
+func (h historyLog) all() []Revision {
+	ids := make([]int, 0, len(h))
+	for id := range h {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	var out []Revision
+	for _, id := range ids {
+		out = append(out, h[id]...)
+	}
+	return out
+}
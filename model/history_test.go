@@ -0,0 +1,112 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBookHistory(t *testing.T) {
+	runHistoryTests(t, setupStore())
+}
+
+// runHistoryTests memastikan setiap perubahan tercatat sebagai revision beserta
+// audit dari context, dan riwayat tetap bisa dibaca setelah buku dihapus.
+func runHistoryTests(t *testing.T, store BookStore) {
+	t.Helper()
+	alice := WithAudit(ctx, Audit{Actor: "alice", RequestID: "req-1"})
+	bob := WithAudit(ctx, Audit{Actor: "bob", RequestID: "req-2"})
+
+	added, err := store.AddBook(alice, Book{Title: "Draft", Author: "Go Dev", PublishedYear: 2023})
+	if err != nil {
+		t.Fatalf("Failed to add book: %v", err)
+	}
+	updated, _ := store.UpdateBook(bob, added.ID, Book{Title: "Final", Author: "Go Dev", PublishedYear: 2023})
+	store.PatchBook(bob, added.ID, func(b Book) (Book, error) {
+		b.ISBN = "978-0-306-40615-7"
+		return b, nil
+	})
+	store.ApplyBatch(alice, []BatchOp{{Op: BatchDelete, ID: added.ID}})
+
+	revs, err := store.BookHistory(ctx, added.ID)
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	want := []struct {
+		action, actor string
+	}{
+		{ActionCreate, "alice"},
+		{ActionUpdate, "bob"},
+		{ActionUpdate, "bob"},
+		{ActionDelete, "alice"},
+	}
+	if len(revs) != len(want) {
+		t.Fatalf("Expected %d revisions, got %+v", len(want), revs)
+	}
+	for i, w := range want {
+		r := revs[i]
+		if r.Rev != i+1 || r.BookID != added.ID || r.Action != w.action || r.Actor != w.actor || r.At.IsZero() {
+			t.Errorf("Revision %d: expected %s by %s, got %+v", i+1, w.action, w.actor, r)
+		}
+	}
+	if revs[0].Before != nil || revs[0].After == nil || revs[0].After.Title != "Draft" || revs[0].RequestID != "req-1" {
+		t.Errorf("Unexpected create revision: %+v", revs[0])
+	}
+	if revs[1].Before.Title != "Draft" || revs[1].After.Title != "Final" || revs[1].After.Version != updated.Version {
+		t.Errorf("Unexpected update revision: before %+v after %+v", revs[1].Before, revs[1].After)
+	}
+	if revs[3].Before == nil || revs[3].Before.ISBN != "978-0-306-40615-7" || revs[3].After != nil {
+		t.Errorf("Unexpected delete revision: %+v", revs[3])
+	}
+
+	// Revision yang dikembalikan adalah salinan; mengubahnya tidak mengubah riwayat.
+	revs[1].After.Title = "Tampered"
+	rev, err := store.BookRevision(ctx, added.ID, 2)
+	if err != nil || rev.After.Title != "Final" {
+		t.Errorf("Expected stored revision 2 to be unchanged, got %+v (%v)", rev.After, err)
+	}
+
+	if _, err := store.BookRevision(ctx, added.ID, 5); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing revision, got %v", err)
+	}
+	if _, err := store.BookHistory(ctx, added.ID+100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown book, got %v", err)
+	}
+
+	// Operasi yang gagal tidak meninggalkan revision.
+	other := defaultBook(store)
+	store.UpdateBook(ctx, other.ID, Book{Title: "Stale", Author: "X", PublishedYear: 2000, Version: 9})
+	store.ApplyBatch(ctx, []BatchOp{
+		{Op: BatchUpdate, ID: other.ID, Book: &Book{Title: "Lost", Author: "X", PublishedYear: 2000}},
+		{Op: BatchDelete, ID: added.ID},
+	})
+	if revs, _ := store.BookHistory(ctx, other.ID); len(revs) != 1 {
+		t.Errorf("Expected failed changes to leave 1 revision, got %d", len(revs))
+	}
+}
+
+func TestDiffBooks(t *testing.T) {
+	a := &Book{ID: 1, Title: "Draft", Author: "Go Dev", PublishedYear: 2023, Version: 1}
+	b := &Book{ID: 1, Title: "Final", Author: "Go Dev", PublishedYear: 2024, ISBN: "0306406152", Version: 3}
+
+	changes := DiffBooks(a, b)
+	want := []FieldChange{
+		{Field: "title", From: "Draft", To: "Final"},
+		{Field: "published_year", From: 2023, To: 2024},
+		{Field: "isbn", From: nil, To: "0306406152"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("Change %d: expected %+v, got %+v", i, want[i], changes[i])
+		}
+	}
+
+	if got := DiffBooks(a, a); len(got) != 0 {
+		t.Errorf("Expected no changes for identical books, got %+v", got)
+	}
+	if got := DiffBooks(a, nil); len(got) != 3 || got[0].To != nil {
+		t.Errorf("Expected every set field to be removed, got %+v", got)
+	}
+}
@@ -0,0 +1,8 @@
+CREATE TABLE books (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    title          TEXT    NOT NULL,
+    author         TEXT    NOT NULL,
+    published_year INTEGER NOT NULL
+);
+
+CREATE INDEX idx_books_author ON books (author);
@@ -0,0 +1,13 @@
+-- Waktu disimpan sebagai Unix nanodetik (UTC).
+ALTER TABLE books ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0;
+
+UPDATE books SET updated_at = CAST(strftime('%s', 'now') AS INTEGER) * 1000000000;
+
+CREATE TABLE book_collection_state (
+    id          INTEGER PRIMARY KEY CHECK (id = 1),
+    seq         INTEGER NOT NULL,
+    modified_at INTEGER NOT NULL
+);
+
+INSERT INTO book_collection_state (id, seq, modified_at)
+SELECT 1, COUNT(*), COALESCE(MAX(updated_at), 0) FROM books;
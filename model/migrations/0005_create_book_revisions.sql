@@ -0,0 +1,24 @@
+-- Riwayat perubahan buku. Tidak memakai foreign key agar riwayat buku yang sudah
+-- dihapus tetap ada; before_json/after_json berisi buku dalam format JSON API.
+CREATE TABLE book_revisions (
+    book_id     INTEGER NOT NULL,
+    rev         INTEGER NOT NULL,
+    action      TEXT    NOT NULL,
+    actor       TEXT    NOT NULL DEFAULT '',
+    request_id  TEXT    NOT NULL DEFAULT '',
+    at          INTEGER NOT NULL,
+    before_json TEXT,
+    after_json  TEXT,
+    PRIMARY KEY (book_id, rev)
+);
+
+-- Revision bersifat permanen.
+CREATE TRIGGER book_revisions_no_update BEFORE UPDATE ON book_revisions
+BEGIN
+    SELECT RAISE(ABORT, 'book revisions are immutable');
+END;
+
+CREATE TRIGGER book_revisions_no_delete BEFORE DELETE ON book_revisions
+BEGIN
+    SELECT RAISE(ABORT, 'book revisions are immutable');
+END;
@@ -0,0 +1,14 @@
+-- Buku yang dihapus (soft delete) dipindahkan ke sini sampai dipulihkan atau
+-- dihapus permanen. ID tetap sama agar buku bisa dikembalikan ke tabel books.
+CREATE TABLE trashed_books (
+    id             INTEGER PRIMARY KEY,
+    title          TEXT    NOT NULL,
+    author         TEXT    NOT NULL,
+    published_year INTEGER NOT NULL,
+    isbn           TEXT    NOT NULL DEFAULT '',
+    version        INTEGER NOT NULL,
+    updated_at     INTEGER NOT NULL,
+    deleted_at     INTEGER NOT NULL
+);
+
+CREATE INDEX idx_trashed_books_deleted_at ON trashed_books (deleted_at);
@@ -0,0 +1,40 @@
+-- Webhook beserta delivery log dan dead letter-nya. events berisi array JSON jenis
+-- ChangeEvent; kosong berarti semua jenis. Riwayat ikut terhapus bersama webhook-nya.
+CREATE TABLE webhooks (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    url        TEXT    NOT NULL,
+    secret     TEXT    NOT NULL,
+    events     TEXT    NOT NULL DEFAULT '[]',
+    active     INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id  INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    delivery_id TEXT    NOT NULL,
+    event_seq   INTEGER NOT NULL,
+    event_type  TEXT    NOT NULL,
+    attempt     INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error       TEXT    NOT NULL DEFAULT '',
+    succeeded   INTEGER NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    at          INTEGER NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id);
+
+-- event_json berisi ChangeEvent dalam format JSON API.
+CREATE TABLE webhook_dead_letters (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id  INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    delivery_id TEXT    NOT NULL,
+    event_json  TEXT    NOT NULL,
+    attempts    INTEGER NOT NULL,
+    last_error  TEXT    NOT NULL DEFAULT '',
+    failed_at   INTEGER NOT NULL
+);
+
+CREATE INDEX idx_webhook_dead_letters_webhook_id ON webhook_dead_letters (webhook_id, id);
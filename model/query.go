@@ -0,0 +1,422 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultQueryLimit adalah jumlah buku per halaman jika limit tidak diisi.
+	DefaultQueryLimit = 100
+	// MaxQueryLimit adalah batas atas limit yang diizinkan.
+	MaxQueryLimit = 1000
+)
+
+// FilterOp adalah operator pembanding pada filter query.
+type FilterOp string
+
+const (
+	OpEq       FilterOp = "eq"
+	OpNe       FilterOp = "ne"
+	OpGt       FilterOp = "gt"
+	OpGte      FilterOp = "gte"
+	OpLt       FilterOp = "lt"
+	OpLte      FilterOp = "lte"
+	OpContains FilterOp = "contains"
+)
+
+// Filter membatasi hasil query berdasarkan satu field, misalnya published_year >= 2000.
+type Filter struct {
+	Field string
+	Op    FilterOp
+	Value string
+}
+
+// SortField menentukan urutan hasil query berdasarkan satu field.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// BookQuery berisi parameter filter, urutan, dan paginasi untuk QueryBooks.
+// Cursor dan Offset tidak boleh dipakai bersamaan.
+type BookQuery struct {
+	Filters []Filter
+	Sort    []SortField
+	Limit   int
+	Offset  int
+	Cursor  string
+}
+
+// BookPage adalah satu halaman hasil QueryBooks.
+type BookPage struct {
+	// Books berisi buku pada halaman ini.
+	Books []Book
+	// Total adalah jumlah seluruh buku yang cocok dengan filter (tanpa paginasi).
+	Total int
+	// NextCursor diisi jika masih ada halaman berikutnya.
+	NextCursor string
+}
+
+type queryField struct {
+	numeric bool
+	column  string
+}
+
+// queryFields adalah daftar field Book yang boleh dipakai untuk filter dan sort.
+var queryFields = map[string]queryField{
+	"id":             {numeric: true, column: "id"},
+	"title":          {column: "title"},
+	"author":         {column: "author"},
+	"published_year": {numeric: true, column: "published_year"},
+}
+
+// Normalize mengisi nilai default dan memvalidasi query.
+//
+// Returns:
+//   - BookQuery yang sudah dinormalisasi
+//   - error yang membungkus ErrValidation jika query tidak valid
+func (q BookQuery) Normalize() (BookQuery, error) {
+	if q.Limit == 0 {
+		q.Limit = DefaultQueryLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxQueryLimit {
+		return q, fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, MaxQueryLimit)
+	}
+	if q.Offset < 0 {
+		return q, fmt.Errorf("%w: offset must not be negative", ErrValidation)
+	}
+	if q.Cursor != "" && q.Offset != 0 {
+		return q, fmt.Errorf("%w: cursor and offset cannot be combined", ErrValidation)
+	}
+
+	for _, f := range q.Filters {
+		field, ok := queryFields[f.Field]
+		if !ok {
+			return q, fmt.Errorf("%w: unknown filter field %q", ErrValidation, f.Field)
+		}
+		switch f.Op {
+		case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+			if field.numeric {
+				if _, err := strconv.Atoi(f.Value); err != nil {
+					return q, fmt.Errorf("%w: %s must be an integer", ErrValidation, f.Field)
+				}
+			}
+		case OpContains:
+			if field.numeric {
+				return q, fmt.Errorf("%w: contains is not supported on %s", ErrValidation, f.Field)
+			}
+		default:
+			return q, fmt.Errorf("%w: unknown filter operator %q", ErrValidation, f.Op)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, s := range q.Sort {
+		if _, ok := queryFields[s.Field]; !ok {
+			return q, fmt.Errorf("%w: unknown sort field %q", ErrValidation, s.Field)
+		}
+		if seen[s.Field] {
+			return q, fmt.Errorf("%w: duplicate sort field %q", ErrValidation, s.Field)
+		}
+		seen[s.Field] = true
+	}
+
+	if q.Cursor != "" {
+		if _, err := q.decodeCursor(); err != nil {
+			return q, err
+		}
+	}
+	return q, nil
+}
+
+// orderKeys mengembalikan urutan lengkap query: field sort diikuti id ascending
+// sebagai pemecah seri, sehingga urutan selalu deterministik.
+func (q BookQuery) orderKeys() []SortField {
+	keys := append([]SortField{}, q.Sort...)
+	for _, s := range keys {
+		if s.Field == "id" {
+			return keys
+		}
+	}
+	return append(keys, SortField{Field: "id"})
+}
+
+// sortSpec adalah representasi string dari urutan, dipakai untuk mengikat cursor ke sort-nya.
+func (q BookQuery) sortSpec() string {
+	parts := make([]string, 0, len(q.Sort))
+	for _, s := range q.orderKeys() {
+		if s.Desc {
+			parts = append(parts, "-"+s.Field)
+		} else {
+			parts = append(parts, s.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+type cursorPayload struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// encodeCursor membuat cursor opaque yang menunjuk ke posisi setelah book.
+func (q BookQuery) encodeCursor(book Book) string {
+	keys := q.orderKeys()
+	payload := cursorPayload{Sort: q.sortSpec(), Values: make([]string, len(keys))}
+	for i, k := range keys {
+		payload.Values[i] = fieldString(book, k.Field)
+	}
+	data, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor membaca nilai kunci dari cursor dan memastikan cursor dibuat dengan sort yang sama.
+func (q BookQuery) decodeCursor() ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+	keys := q.orderKeys()
+	if payload.Sort != q.sortSpec() || len(payload.Values) != len(keys) {
+		return nil, fmt.Errorf("%w: cursor does not match sort order", ErrValidation)
+	}
+	for i, k := range keys {
+		if queryFields[k.Field].numeric {
+			if _, err := strconv.Atoi(payload.Values[i]); err != nil {
+				return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+			}
+		}
+	}
+	return payload.Values, nil
+}
+
+// fieldString mengembalikan nilai field buku sebagai string.
+func fieldString(b Book, field string) string {
+	switch field {
+	case "id":
+		return strconv.Itoa(b.ID)
+	case "title":
+		return b.Title
+	case "author":
+		return b.Author
+	case "published_year":
+		return strconv.Itoa(b.PublishedYear)
+	}
+	return ""
+}
+
+// compareValue membandingkan dua nilai field; angka dibandingkan secara numerik.
+func compareValue(field, a, b string) int {
+	if queryFields[field].numeric {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// matches mengecek apakah buku memenuhi satu filter.
+func (f Filter) matches(b Book) bool {
+	v := fieldString(b, f.Field)
+	if f.Op == OpContains {
+		return strings.Contains(strings.ToLower(v), strings.ToLower(f.Value))
+	}
+	c := compareValue(f.Field, v, f.Value)
+	switch f.Op {
+	case OpEq:
+		return c == 0
+	case OpNe:
+		return c != 0
+	case OpGt:
+		return c > 0
+	case OpGte:
+		return c >= 0
+	case OpLt:
+		return c < 0
+	case OpLte:
+		return c <= 0
+	}
+	return false
+}
+
+// compareKeys membandingkan nilai kunci a dan b menurut urutan keys.
+func compareKeys(keys []SortField, a, b []string) int {
+	for i, k := range keys {
+		c := compareValue(k.Field, a[i], b[i])
+		if k.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func bookKeys(keys []SortField, b Book) []string {
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = fieldString(b, k.Field)
+	}
+	return values
+}
+
+// applyQuery menjalankan query terhadap slice buku di memori. Dipakai oleh store
+// yang menyimpan seluruh data di memori (map dan file).
+func applyQuery(books []Book, q BookQuery) (BookPage, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return BookPage{}, err
+	}
+
+	filtered := make([]Book, 0, len(books))
+	for _, b := range books {
+		ok := true
+		for _, f := range q.Filters {
+			if !f.matches(b) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			filtered = append(filtered, b)
+		}
+	}
+
+	keys := q.orderKeys()
+	sort.Slice(filtered, func(i, j int) bool {
+		return compareKeys(keys, bookKeys(keys, filtered[i]), bookKeys(keys, filtered[j])) < 0
+	})
+
+	page := BookPage{Total: len(filtered)}
+
+	start := q.Offset
+	if q.Cursor != "" {
+		after, _ := q.decodeCursor()
+		start = sort.Search(len(filtered), func(i int) bool {
+			return compareKeys(keys, bookKeys(keys, filtered[i]), after) > 0
+		})
+	}
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	end := start + q.Limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	page.Books = append([]Book{}, filtered[start:end]...)
+	if end < len(filtered) && len(page.Books) > 0 {
+		page.NextCursor = q.encodeCursor(page.Books[len(page.Books)-1])
+	}
+	return page, nil
+}
+
+// streamQuery menjalankan q terhadap buku dari each (berurutan menurut ID) dan memanggil
+// fn untuk setiap buku pada halaman hasil, tanpa menyalin seluruh koleksi. Untuk urutan
+// default (id ascending) buku diteruskan langsung ke fn; urutan lain perlu menampung
+// buku yang cocok dengan filter agar bisa diurutkan. Books pada BookPage selalu kosong.
+func streamQuery(q BookQuery, each func(fn func(Book) error) error, fn func(Book) error) (BookPage, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return BookPage{}, err
+	}
+	matches := func(b Book) bool {
+		for _, f := range q.Filters {
+			if !f.matches(b) {
+				return false
+			}
+		}
+		return true
+	}
+
+	keys := q.orderKeys()
+	if len(keys) != 1 || keys[0] != (SortField{Field: "id"}) {
+		var matched []Book
+		err := each(func(b Book) error {
+			if matches(b) {
+				matched = append(matched, b)
+			}
+			return nil
+		})
+		if err != nil {
+			return BookPage{}, err
+		}
+		page, err := applyQuery(matched, q)
+		if err != nil {
+			return BookPage{}, err
+		}
+		for _, b := range page.Books {
+			if err := fn(b); err != nil {
+				return BookPage{}, err
+			}
+		}
+		page.Books = nil
+		return page, nil
+	}
+
+	afterID := 0
+	if q.Cursor != "" {
+		after, _ := q.decodeCursor()
+		afterID, _ = strconv.Atoi(after[0])
+	}
+	var page BookPage
+	var last Book
+	skipped, sent, more := 0, 0, false
+	// Iterasi berlanjut setelah halaman penuh hanya untuk menghitung Total.
+	err = each(func(b Book) error {
+		if !matches(b) {
+			return nil
+		}
+		page.Total++
+		switch {
+		case b.ID <= afterID:
+			return nil
+		case skipped < q.Offset:
+			skipped++
+			return nil
+		case sent == q.Limit:
+			more = true
+			return nil
+		}
+		sent++
+		last = b
+		return fn(b)
+	})
+	if err != nil {
+		return BookPage{}, err
+	}
+	if more && sent > 0 {
+		page.NextCursor = q.encodeCursor(last)
+	}
+	return page, nil
+}
+
+// collectQuery mengumpulkan hasil stream (lihat streamQuery) menjadi BookPage lengkap.
+func collectQuery(stream func(fn func(Book) error) (BookPage, error)) (BookPage, error) {
+	books := []Book{}
+	page, err := stream(func(b Book) error {
+		books = append(books, b)
+		return nil
+	})
+	if err != nil {
+		return BookPage{}, err
+	}
+	page.Books = books
+	return page, nil
+}
@@ -0,0 +1,247 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func seedQueryBooks(store BookStore) {
+	createBook(store, "Laskar Pelangi", "Andrea Hirata", 2005)
+	createBook(store, "Sang Pemimpi", "Andrea Hirata", 2006)
+	createBook(store, "Bumi Manusia", "Pramoedya Ananta Toer", 1980)
+	createBook(store, "Ronggeng Dukuh Paruk", "Ahmad Tohari", 1982)
+	createBook(store, "Cantik Itu Luka", "Eka Kurniawan", 2002)
+}
+
+func titles(books []Book) []string {
+	out := make([]string, len(books))
+	for i, b := range books {
+		out[i] = b.Title
+	}
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// runQueryTests menjalankan skenario QueryBooks yang sama untuk setiap implementasi store.
+func runQueryTests(t *testing.T, newStore func(t *testing.T) BookStore) {
+	tests := []struct {
+		name      string
+		query     BookQuery
+		want      []string
+		wantTotal int
+	}{
+		{
+			name:      "default order by id",
+			query:     BookQuery{},
+			want:      []string{"Laskar Pelangi", "Sang Pemimpi", "Bumi Manusia", "Ronggeng Dukuh Paruk", "Cantik Itu Luka"},
+			wantTotal: 5,
+		},
+		{
+			name:      "filter author and sort by year desc",
+			query:     BookQuery{Filters: []Filter{{Field: "author", Op: OpEq, Value: "Andrea Hirata"}}, Sort: []SortField{{Field: "published_year", Desc: true}}},
+			want:      []string{"Sang Pemimpi", "Laskar Pelangi"},
+			wantTotal: 2,
+		},
+		{
+			name:      "year range sorted by title",
+			query:     BookQuery{Filters: []Filter{{Field: "published_year", Op: OpGte, Value: "1982"}, {Field: "published_year", Op: OpLt, Value: "2006"}}, Sort: []SortField{{Field: "title"}}},
+			want:      []string{"Cantik Itu Luka", "Laskar Pelangi", "Ronggeng Dukuh Paruk"},
+			wantTotal: 3,
+		},
+		{
+			name:      "title contains is case-insensitive",
+			query:     BookQuery{Filters: []Filter{{Field: "title", Op: OpContains, Value: "PEMIMPI"}}},
+			want:      []string{"Sang Pemimpi"},
+			wantTotal: 1,
+		},
+		{
+			name:      "limit and offset",
+			query:     BookQuery{Sort: []SortField{{Field: "title"}}, Limit: 2, Offset: 1},
+			want:      []string{"Cantik Itu Luka", "Laskar Pelangi"},
+			wantTotal: 5,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newStore(t)
+			seedQueryBooks(store)
+
+			page, err := store.QueryBooks(ctx, tc.query)
+			if err != nil {
+				t.Fatalf("QueryBooks failed: %v", err)
+			}
+			if got := titles(page.Books); !equalStrings(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+			if page.Total != tc.wantTotal {
+				t.Errorf("got total %d, want %d", page.Total, tc.wantTotal)
+			}
+
+			var streamed []Book
+			streamPage, err := store.StreamBooks(ctx, tc.query, func(b Book) error {
+				streamed = append(streamed, b)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("StreamBooks failed: %v", err)
+			}
+			if got := titles(streamed); !equalStrings(got, tc.want) || streamPage.Total != tc.wantTotal || streamPage.Books != nil {
+				t.Errorf("StreamBooks: got %v (total %d, books %v), want %v (total %d)", got, streamPage.Total, streamPage.Books, tc.want, tc.wantTotal)
+			}
+		})
+	}
+
+	t.Run("stream cursor walks every page in id order", func(t *testing.T) {
+		store := newStore(t)
+		seedQueryBooks(store)
+
+		q := BookQuery{Filters: []Filter{{Field: "published_year", Op: OpGt, Value: "1980"}}, Limit: 2}
+		var got []string
+		for pages := 0; pages < 10; pages++ {
+			page, err := store.StreamBooks(ctx, q, func(b Book) error {
+				got = append(got, b.Title)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("StreamBooks failed: %v", err)
+			}
+			if page.Total != 4 {
+				t.Errorf("got total %d, want 4", page.Total)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+
+		want := []string{"Laskar Pelangi", "Sang Pemimpi", "Ronggeng Dukuh Paruk", "Cantik Itu Luka"}
+		if !equalStrings(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("stream stops when fn fails", func(t *testing.T) {
+		store := newStore(t)
+		seedQueryBooks(store)
+
+		errStop := errors.New("stop")
+		calls := 0
+		_, err := store.StreamBooks(ctx, BookQuery{}, func(Book) error {
+			calls++
+			return errStop
+		})
+		if !errors.Is(err, errStop) || calls != 1 {
+			t.Errorf("expected errStop after 1 call, got %v after %d calls", err, calls)
+		}
+		// Stream yang berhenti harus melepas koneksi (store SQLite hanya punya satu).
+		if _, err := store.AddBook(ctx, Book{Title: "Perahu Kertas", Author: "Dee Lestari", PublishedYear: 2006}); err != nil {
+			t.Errorf("AddBook after stopped stream failed: %v", err)
+		}
+	})
+
+	t.Run("contains folds non-ASCII letters", func(t *testing.T) {
+		store := newStore(t)
+		createBook(store, "L'Assommoir", "Émile Zola", 1877)
+		createBook(store, "Germinal", "ÉMILE ZOLA", 1885)
+		createBook(store, "Diskon 100%_off", "Anonim", 2020)
+
+		for _, tc := range []struct {
+			filter Filter
+			want   []string
+		}{
+			{Filter{Field: "author", Op: OpContains, Value: "émile"}, []string{"L'Assommoir", "Germinal"}},
+			{Filter{Field: "title", Op: OpContains, Value: "ASSOMMOIR"}, []string{"L'Assommoir"}},
+			{Filter{Field: "title", Op: OpContains, Value: "100%_"}, []string{"Diskon 100%_off"}},
+			{Filter{Field: "title", Op: OpContains, Value: "_"}, []string{"Diskon 100%_off"}},
+		} {
+			page, err := store.QueryBooks(ctx, BookQuery{Filters: []Filter{tc.filter}})
+			if err != nil {
+				t.Fatalf("QueryBooks(%s contains %q) failed: %v", tc.filter.Field, tc.filter.Value, err)
+			}
+			if got := titles(page.Books); !equalStrings(got, tc.want) {
+				t.Errorf("%s contains %q: got %v, want %v", tc.filter.Field, tc.filter.Value, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("cursor walks every page", func(t *testing.T) {
+		store := newStore(t)
+		seedQueryBooks(store)
+		createBook(store, "Perahu Kertas", "Dee Lestari", 2006)
+
+		q := BookQuery{Sort: []SortField{{Field: "published_year", Desc: true}, {Field: "title"}}, Limit: 2}
+		var got []string
+		for pages := 0; pages < 10; pages++ {
+			page, err := store.QueryBooks(ctx, q)
+			if err != nil {
+				t.Fatalf("QueryBooks failed: %v", err)
+			}
+			got = append(got, titles(page.Books)...)
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+
+		want := []string{"Perahu Kertas", "Sang Pemimpi", "Laskar Pelangi", "Cantik Itu Luka", "Ronggeng Dukuh Paruk", "Bumi Manusia"}
+		if !equalStrings(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		store := newStore(t)
+		cursor := BookQuery{Sort: []SortField{{Field: "title"}}}.encodeCursor(Book{ID: 1, Title: "x"})
+
+		invalid := []BookQuery{
+			{Filters: []Filter{{Field: "isbn", Op: OpEq, Value: "1"}}},
+			{Filters: []Filter{{Field: "published_year", Op: OpGte, Value: "abc"}}},
+			{Filters: []Filter{{Field: "published_year", Op: OpContains, Value: "19"}}},
+			{Sort: []SortField{{Field: "price"}}},
+			{Limit: MaxQueryLimit + 1},
+			{Cursor: "not-a-cursor"},
+			{Cursor: cursor, Sort: []SortField{{Field: "author"}}},
+			{Cursor: cursor, Sort: []SortField{{Field: "title"}}, Offset: 1},
+		}
+		for _, q := range invalid {
+			if _, err := store.QueryBooks(ctx, q); !errors.Is(err, ErrValidation) {
+				t.Errorf("query %+v: expected ErrValidation, got %v", q, err)
+			}
+			if _, err := store.StreamBooks(ctx, q, func(Book) error { return nil }); !errors.Is(err, ErrValidation) {
+				t.Errorf("stream %+v: expected ErrValidation, got %v", q, err)
+			}
+		}
+	})
+}
+
+func TestQueryBooksMemory(t *testing.T) {
+	runQueryTests(t, func(t *testing.T) BookStore { return setupStore() })
+}
+
+func TestQueryBooksSQL(t *testing.T) {
+	runQueryTests(t, func(t *testing.T) BookStore {
+		store := openSQLStore(t, ":memory:")
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+func TestQueryBooksFile(t *testing.T) {
+	runQueryTests(t, func(t *testing.T) BookStore {
+		store := openFileStore(t, t.TempDir(), FileStoreOptions{})
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
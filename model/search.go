@@ -0,0 +1,313 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// DefaultSearchLimit adalah jumlah hasil pencarian jika limit tidak diisi.
+	DefaultSearchLimit = 20
+	// MaxSearchLimit adalah batas atas jumlah hasil pencarian.
+	MaxSearchLimit = 100
+
+	titleBoost  = 2.0
+	authorBoost = 1.0
+	// prefixWeight adalah bobot kecocokan prefix dibanding kecocokan kata utuh.
+	prefixWeight = 0.5
+
+	snippetRadius = 40
+)
+
+// SearchHit adalah satu hasil pencarian beserta skor relevansi dan potongan teks
+// yang kata cocoknya ditandai dengan <mark>.
+type SearchHit struct {
+	Book       Book              `json:"book"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// BookSearcher diimplementasikan oleh store yang mendukung pencarian teks penuh.
+type BookSearcher interface {
+	// SearchBooks mencari buku berdasarkan kata (atau awalan kata) pada judul dan penulis.
+	// Mengembalikan error yang membungkus ErrValidation jika query tidak berisi kata.
+	SearchBooks(ctx context.Context, query string, limit int) ([]SearchHit, error)
+}
+
+// posting mencatat frekuensi sebuah token pada tiap field satu buku.
+type posting struct {
+	title  int
+	author int
+}
+
+// searchIndex adalah inverted index token -> buku untuk field title dan author.
+// Tidak aman dipakai bersamaan; store pemiliknya yang mengatur locking.
+type searchIndex struct {
+	postings map[string]map[int]posting
+	docs     map[int]Book
+	// vocab adalah daftar token terurut untuk pencarian prefix dengan binary search.
+	vocab []string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[int]posting),
+		docs:     make(map[int]Book),
+	}
+}
+
+var accentFolder = runes.Remove(runes.In(unicode.Mn))
+
+// foldToken menormalkan kata: huruf kecil dan tanpa tanda diakritik
+// (misalnya "Pramoedya" dan "Prámoedyá" menjadi token yang sama).
+func foldToken(s string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, accentFolder, norm.NFC), s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+type token struct {
+	text       string
+	start, end int
+}
+
+// tokenize memecah teks menjadi token terlipat beserta posisi byte aslinya.
+func tokenize(s string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range s {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			tokens = append(tokens, token{text: foldToken(s[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{text: foldToken(s[start:]), start: start, end: len(s)})
+	}
+	return tokens
+}
+
+// add memasukkan buku ke index.
+func (idx *searchIndex) add(b Book) {
+	counts := map[string]posting{}
+	for _, t := range tokenize(b.Title) {
+		p := counts[t.text]
+		p.title++
+		counts[t.text] = p
+	}
+	for _, t := range tokenize(b.Author) {
+		p := counts[t.text]
+		p.author++
+		counts[t.text] = p
+	}
+
+	for text, p := range counts {
+		docs, ok := idx.postings[text]
+		if !ok {
+			docs = make(map[int]posting)
+			idx.postings[text] = docs
+			i := sort.SearchStrings(idx.vocab, text)
+			idx.vocab = append(idx.vocab, "")
+			copy(idx.vocab[i+1:], idx.vocab[i:])
+			idx.vocab[i] = text
+		}
+		docs[b.ID] = p
+	}
+	idx.docs[b.ID] = b
+}
+
+// remove menghapus buku dari index.
+func (idx *searchIndex) remove(id int) {
+	b, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	delete(idx.docs, id)
+
+	for _, t := range append(tokenize(b.Title), tokenize(b.Author)...) {
+		docs, ok := idx.postings[t.text]
+		if !ok {
+			continue
+		}
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(idx.postings, t.text)
+			i := sort.SearchStrings(idx.vocab, t.text)
+			if i < len(idx.vocab) && idx.vocab[i] == t.text {
+				idx.vocab = append(idx.vocab[:i], idx.vocab[i+1:]...)
+			}
+		}
+	}
+}
+
+// put memasukkan atau mengganti buku di index.
+func (idx *searchIndex) put(b Book) {
+	idx.remove(b.ID)
+	idx.add(b)
+}
+
+// expand mengembalikan semua token di index yang sama dengan atau diawali term.
+func (idx *searchIndex) expand(term string) []string {
+	var out []string
+	for i := sort.SearchStrings(idx.vocab, term); i < len(idx.vocab); i++ {
+		if !strings.HasPrefix(idx.vocab[i], term) {
+			break
+		}
+		out = append(out, idx.vocab[i])
+	}
+	return out
+}
+
+// search mencari buku yang mengandung setiap kata query (utuh atau sebagai awalan)
+// dan mengurutkannya berdasarkan skor TF-IDF dengan bobot lebih untuk judul.
+func (idx *searchIndex) search(query string, limit int) ([]SearchHit, error) {
+	terms := uniqueTerms(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: search query must contain at least one word", ErrValidation)
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	n := float64(len(idx.docs))
+	var scores map[int]float64
+	for _, term := range terms {
+		termScores := map[int]float64{}
+		for _, word := range idx.expand(term) {
+			docs := idx.postings[word]
+			idf := math.Log(1 + n/float64(len(docs)))
+			weight := 1.0
+			if word != term {
+				weight = prefixWeight * float64(len(term)) / float64(len(word))
+			}
+			for id, p := range docs {
+				tf := titleBoost*float64(p.title) + authorBoost*float64(p.author)
+				if s := idf * tf * weight; s > termScores[id] {
+					termScores[id] = s
+				}
+			}
+		}
+
+		// Semua kata query harus cocok; buku yang tidak cocok dengan term ini dibuang.
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, SearchHit{Book: idx.docs[id], Score: math.Round(score*1000) / 1000})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Book.ID < hits[j].Book.ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	for i := range hits {
+		hits[i].Highlights = map[string]string{}
+		if s, ok := highlight(hits[i].Book.Title, terms); ok {
+			hits[i].Highlights["title"] = s
+		}
+		if s, ok := highlight(hits[i].Book.Author, terms); ok {
+			hits[i].Highlights["author"] = s
+		}
+	}
+	return hits, nil
+}
+
+// uniqueTerms mengembalikan token query tanpa duplikat dengan urutan tetap.
+func uniqueTerms(query string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	for _, t := range tokenize(query) {
+		if !seen[t.text] {
+			seen[t.text] = true
+			terms = append(terms, t.text)
+		}
+	}
+	return terms
+}
+
+// highlight menandai kata yang cocok dengan term dalam <mark>…</mark> dan memotong
+// teks panjang di sekitar kecocokan pertama. Teks lain di-escape sebagai HTML.
+func highlight(text string, terms []string) (string, bool) {
+	var matched []token
+	for _, t := range tokenize(text) {
+		for _, term := range terms {
+			if strings.HasPrefix(t.text, term) {
+				matched = append(matched, t)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return "", false
+	}
+
+	from, to := 0, len(text)
+	if from < matched[0].start-snippetRadius {
+		from = min(wordBoundary(text, matched[0].start-snippetRadius), matched[0].start)
+	}
+	if last := matched[len(matched)-1].end + snippetRadius; last < to {
+		to = wordBoundary(text, last)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, t := range matched {
+		if t.start < from || t.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:t.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[t.start:t.end]))
+		b.WriteString("</mark>")
+		pos = t.end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// wordBoundary menggeser posisi i ke spasi terdekat agar potongan tidak memotong kata.
+func wordBoundary(text string, i int) int {
+	for i > 0 && i < len(text) && text[i] != ' ' {
+		i++
+	}
+	return i
+}
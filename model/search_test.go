@@ -0,0 +1,136 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func searchStore(t *testing.T) BookStore {
+	t.Helper()
+	store := setupStore()
+	createBook(store, "Laskar Pelangi", "Andrea Hirata", 2005)
+	createBook(store, "Sang Pemimpi", "Andrea Hirata", 2006)
+	createBook(store, "Bumi Manusia", "Pramoedya Ananta Toer", 1980)
+	createBook(store, "Anak Semua Bangsa", "Pramoedya Ananta Toer", 1981)
+	createBook(store, "Pelangi di Atas Danau", "Ahmad Tohari", 2010)
+	return store
+}
+
+func search(t *testing.T, store BookStore, query string) []SearchHit {
+	t.Helper()
+	hits, err := store.(BookSearcher).SearchBooks(ctx, query, 0)
+	if err != nil {
+		t.Fatalf("SearchBooks(%q) failed: %v", query, err)
+	}
+	return hits
+}
+
+func TestSearchBooksMatchesAllTerms(t *testing.T) {
+	store := searchStore(t)
+
+	hits := search(t, store, "andrea pelangi")
+	if len(hits) != 1 || hits[0].Book.Title != "Laskar Pelangi" {
+		t.Fatalf("Expected only Laskar Pelangi, got %+v", hits)
+	}
+	if hits[0].Highlights["title"] != "Laskar <mark>Pelangi</mark>" {
+		t.Errorf("Unexpected title highlight: %q", hits[0].Highlights["title"])
+	}
+	if hits[0].Highlights["author"] != "<mark>Andrea</mark> Hirata" {
+		t.Errorf("Unexpected author highlight: %q", hits[0].Highlights["author"])
+	}
+}
+
+func TestSearchBooksPrefixAndAccentFolding(t *testing.T) {
+	store := searchStore(t)
+
+	hits := search(t, store, "PRÁMOED")
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 books by Pramoedya, got %d", len(hits))
+	}
+
+	createBook(store, "Cerita dari Blora", "Pramoedyá Anantá Toer", 1952)
+	hits = search(t, store, "ananta")
+	if len(hits) != 3 {
+		t.Errorf("Expected accented author to match unaccented query, got %d hits", len(hits))
+	}
+}
+
+func TestSearchBooksRanking(t *testing.T) {
+	store := searchStore(t)
+
+	// Kecocokan di judul lebih tinggi dari penulis, dan kata utuh lebih tinggi dari prefix.
+	createBook(store, "Kumpulan Esai", "Pelangi Nusantara", 2015)
+	hits := search(t, store, "pelangi")
+	if len(hits) != 3 {
+		t.Fatalf("Expected 3 hits, got %d", len(hits))
+	}
+	if hits[2].Book.Author != "Pelangi Nusantara" {
+		t.Errorf("Expected author-only match to rank last, got %+v", hits)
+	}
+
+	hits = search(t, store, "pel")
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score {
+			t.Errorf("Hits not sorted by score: %+v", hits)
+		}
+	}
+}
+
+func TestSearchIndexFollowsUpdatesAndDeletes(t *testing.T) {
+	store := searchStore(t)
+
+	hits := search(t, store, "bumi")
+	if len(hits) != 1 {
+		t.Fatalf("Expected 1 hit, got %d", len(hits))
+	}
+	id := hits[0].Book.ID
+
+	if _, err := store.UpdateBook(ctx, id, Book{Title: "Jejak Langkah", Author: "Pramoedya Ananta Toer", PublishedYear: 1985}); err != nil {
+		t.Fatalf("Failed to update book: %v", err)
+	}
+	if hits := search(t, store, "bumi"); len(hits) != 0 {
+		t.Errorf("Expected old title to be unindexed, got %+v", hits)
+	}
+	if hits := search(t, store, "jejak"); len(hits) != 1 {
+		t.Errorf("Expected new title to be indexed, got %+v", hits)
+	}
+
+	if err := store.DeleteBook(ctx, id, 0); err != nil {
+		t.Fatalf("Failed to delete book: %v", err)
+	}
+	if hits := search(t, store, "jejak"); len(hits) != 0 {
+		t.Errorf("Expected deleted book to be unindexed, got %+v", hits)
+	}
+}
+
+func TestSearchBooksEmptyQuery(t *testing.T) {
+	store := searchStore(t)
+
+	if _, err := store.(BookSearcher).SearchBooks(ctx, " -- ", 0); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+}
+
+func TestHighlightEscapesAndTruncates(t *testing.T) {
+	got, ok := highlight("<b>Kisah</b> "+strings.Repeat("panjang ", 20)+"pelangi di akhir", []string{"pelangi"})
+	if !ok {
+		t.Fatal("Expected a match")
+	}
+	if !strings.HasPrefix(got, "…") || !strings.Contains(got, "<mark>pelangi</mark>") || strings.Contains(got, "<b>") {
+		t.Errorf("Unexpected snippet: %q", got)
+	}
+}
+
+func TestFileStoreSearchSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	store := openFileStore(t, dir, FileStoreOptions{})
+	createBook(store, "Laskar Pelangi", "Andrea Hirata", 2005)
+	store.Close()
+
+	reopened := openFileStore(t, dir, FileStoreOptions{})
+	defer reopened.Close()
+	if hits := search(t, reopened, "laskar"); len(hits) != 1 {
+		t.Errorf("Expected index to be rebuilt on recovery, got %+v", hits)
+	}
+}
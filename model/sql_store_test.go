@@ -0,0 +1,216 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func openSQLStore(t *testing.T, path string) ClosableBookStore {
+	t.Helper()
+	store, err := OpenSQLiteBookStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite store: %v", err)
+	}
+	return store
+}
+
+func TestSQLStoreCRUD(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	if countBooks(store) != 0 {
+		t.Error("Expected empty book list")
+	}
+
+	added := defaultBook(store)
+	if added.ID == 0 {
+		t.Fatal("Expected valid book ID")
+	}
+
+	got, err := store.GetBookByID(ctx, added.ID)
+	if err != nil {
+		t.Fatalf("Expected book to exist, got error: %v", err)
+	}
+	if got != added {
+		t.Errorf("Expected %+v, got %+v", added, got)
+	}
+
+	updated, err := store.UpdateBook(ctx, added.ID, Book{Title: "Updated Book", Author: "Tester", PublishedYear: 2024})
+	if err != nil {
+		t.Fatalf("Failed to update book: %v", err)
+	}
+	if updated.ID != added.ID || updated.Title != "Updated Book" {
+		t.Errorf("Unexpected updated book: %+v", updated)
+	}
+
+	if err := store.DeleteBook(ctx, added.ID, 0); err != nil {
+		t.Fatalf("Failed to delete book: %v", err)
+	}
+	if _, err := store.GetBookByID(ctx, added.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected book not found, got %v", err)
+	}
+}
+
+func TestSQLStoreNotFound(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	if _, err := store.UpdateBook(ctx, 1, Book{Title: "A", Author: "B", PublishedYear: 2024}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateBook: expected book not found, got %v", err)
+	}
+	if err := store.DeleteBook(ctx, 1, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteBook: expected book not found, got %v", err)
+	}
+}
+
+func TestSQLStoreMigrationsAreIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.db")
+
+	store := openSQLStore(t, path)
+	first := defaultBook(store)
+	if err := store.DeleteBook(ctx, first.ID, 0); err != nil {
+		t.Fatalf("Failed to delete book: %v", err)
+	}
+	store.Close()
+
+	reopened := openSQLStore(t, path)
+	defer reopened.Close()
+
+	var applied int
+	db := reopened.(*sqlBookStore).db
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatalf("Failed to read schema_migrations: %v", err)
+	}
+	migrations, _ := loadMigrations()
+	if applied != len(migrations) {
+		t.Errorf("Expected %d applied migrations, got %d", len(migrations), applied)
+	}
+
+	// AUTOINCREMENT menjamin ID buku yang dihapus tidak dipakai ulang.
+	next := defaultBook(reopened)
+	if next.ID != first.ID+1 {
+		t.Errorf("Expected next ID %d, got %d", first.ID+1, next.ID)
+	}
+}
+
+func TestSQLStoreHonoursCanceledContext(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := store.AddBook(canceled, Book{Title: "A", Author: "B", PublishedYear: 2024}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSQLStoreClosedIsUnavailable(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	store.Close()
+
+	if _, err := store.GetBookByID(ctx, 1); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable after close, got %v", err)
+	}
+}
+
+func TestSQLStoreVersion(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	runVersionTests(t, store)
+}
+
+func TestSQLStorePatch(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	runPatchTests(t, store)
+}
+
+func TestSQLStoreBatch(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	runBatchTests(t, store)
+}
+
+func TestSQLStoreEachBook(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	runEachBookTests(t, store)
+}
+
+func TestSQLStoreHistory(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	runHistoryTests(t, store)
+}
+
+func TestSQLStoreRevisionsAreImmutable(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+	defaultBook(store)
+
+	db := store.(*sqlBookStore).db
+	if _, err := db.Exec(`UPDATE book_revisions SET actor = 'mallory'`); err == nil {
+		t.Error("Expected UPDATE on book_revisions to fail")
+	}
+	if _, err := db.Exec(`DELETE FROM book_revisions`); err == nil {
+		t.Error("Expected DELETE on book_revisions to fail")
+	}
+}
+
+func TestSQLStoreTrash(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	runTrashTests(t, store)
+
+	// Buku yang dipulihkan tetap memakai ID lamanya dan ID baru tidak pernah memakai ulang ID di trash.
+	trashed := createBook(store, "Trashed", "Go Dev", 2024)
+	store.DeleteBook(ctx, trashed.ID, 0)
+	next := createBook(store, "Next", "Go Dev", 2024)
+	if next.ID <= trashed.ID {
+		t.Errorf("Expected new ID after %d, got %d", trashed.ID, next.ID)
+	}
+	if restored, err := store.RestoreBook(ctx, trashed.ID); err != nil || restored.ID != trashed.ID {
+		t.Errorf("Expected book %d to be restored, got %+v (%v)", trashed.ID, restored, err)
+	}
+}
+
+func TestSQLStoreChangeFeed(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	runChangeFeedTests(t, store)
+}
+
+func TestSQLStoreWebhooks(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	runWebhookStoreTests(t, store.(WebhookProvider).Webhooks())
+	store.Close()
+
+	path := filepath.Join(t.TempDir(), "books.db")
+	runWebhookRestartTests(t, func() ClosableBookStore { return openSQLStore(t, path) })
+}
+
+func TestSQLStoreCollectionStamp(t *testing.T) {
+	store := openSQLStore(t, ":memory:")
+	defer store.Close()
+
+	runStampTests(t, store)
+
+	added, _ := store.AddBook(ctx, Book{Title: "Bumi Manusia", Author: "Pramoedya", PublishedYear: 1980, ISBN: "978-0-306-40615-7"})
+	got, _ := store.GetBookByID(ctx, added.ID)
+	if got.ISBN != added.ISBN {
+		t.Errorf("Expected ISBN %q to round-trip, got %q", added.ISBN, got.ISBN)
+	}
+	if !got.UpdatedAt.Equal(added.UpdatedAt) {
+		t.Errorf("Expected UpdatedAt %v to round-trip, got %v", added.UpdatedAt, got.UpdatedAt)
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+)
+
+// TrashPurgerActor adalah Actor pada revision purge yang dibuat oleh RunTrashPurger.
+const TrashPurgerActor = "system:trash-purger"
+
+// TrashedBook adalah buku yang sudah dihapus (soft delete) dan menunggu dipulihkan
+// atau dihapus permanen.
+type TrashedBook struct {
+	Book
+	// DeletedAt adalah waktu (UTC) buku dipindahkan ke trash.
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// trashList mengembalikan isi trash dengan buku yang paling baru dihapus lebih dulu.
+func trashList(trash map[int]TrashedBook) []TrashedBook {
+	out := make([]TrashedBook, 0, len(trash))
+	for _, t := range trash {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].DeletedAt.Equal(out[j].DeletedAt) {
+			return out[i].DeletedAt.After(out[j].DeletedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+// expiredTrash mengembalikan ID buku di trash yang dihapus sebelum cutoff, berurutan menurut ID.
+func expiredTrash(trash map[int]TrashedBook, cutoff time.Time) []int {
+	var ids []int
+	for id, t := range trash {
+		if t.DeletedAt.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// RunTrashPurger menghapus permanen buku yang sudah berada di trash lebih lama dari
+// retention, sekali saat mulai lalu setiap interval, sampai ctx dibatalkan. Kegagalan
+// store hanya dicatat ke log dan dicoba lagi pada putaran berikutnya.
+//
+// Parameters:
+//   - ctx: context yang menghentikan purger ketika dibatalkan
+//   - store: BookStore yang trash-nya dibersihkan
+//   - retention: lama buku disimpan di trash sebelum dihapus permanen
+//   - interval: jeda antar pembersihan
+func RunTrashPurger(ctx context.Context, store BookStore, retention, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	ctx = WithAudit(ctx, Audit{Actor: TrashPurgerActor})
+	for {
+		n, err := store.PurgeTrash(ctx, now().Add(-retention))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("trash purger: %v", err)
+		case n > 0:
+			log.Printf("trash purger: permanently deleted %d book(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
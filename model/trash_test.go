@@ -0,0 +1,143 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	runTrashTests(t, setupStore())
+}
+
+// runTrashTests memastikan delete memindahkan buku ke trash, restore mengembalikannya
+// dengan versi baru, dan purge menghapusnya permanen beserta revision-nya.
+func runTrashTests(t *testing.T, store BookStore) {
+	t.Helper()
+	defer func(orig func() time.Time) { now = orig }(now)
+	t0 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return t0 }
+
+	first := defaultBook(store)
+	second := createBook(store, "Second", "Go Dev", 2024)
+
+	if err := store.DeleteBook(ctx, first.ID, first.Version+1); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed, got %v", err)
+	}
+	if err := store.DeleteBook(ctx, first.ID, first.Version); err != nil {
+		t.Fatalf("Failed to delete book: %v", err)
+	}
+	now = func() time.Time { return t0.Add(time.Hour) }
+	if _, err := store.ApplyBatch(ctx, []BatchOp{{Op: BatchDelete, ID: second.ID}}); err != nil {
+		t.Fatalf("Failed to delete book in batch: %v", err)
+	}
+
+	if _, err := store.GetBookByID(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected trashed book to be hidden, got %v", err)
+	}
+	if n := countBooks(store); n != 0 {
+		t.Errorf("Expected no active books, got %d", n)
+	}
+	trash, err := store.ListTrash(ctx)
+	if err != nil || len(trash) != 2 {
+		t.Fatalf("Expected 2 trashed books, got %+v (%v)", trash, err)
+	}
+	if trash[0].ID != second.ID || trash[1].ID != first.ID || !trash[1].DeletedAt.Equal(t0) || trash[1].Title != first.Title {
+		t.Errorf("Expected newest deletion first, got %+v", trash)
+	}
+
+	restored, err := store.RestoreBook(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Failed to restore book: %v", err)
+	}
+	if restored.Version != first.Version+1 || restored.Title != first.Title {
+		t.Errorf("Expected restored book with version %d, got %+v", first.Version+1, restored)
+	}
+	if got, err := store.GetBookByID(ctx, first.ID); err != nil || got.Version != restored.Version {
+		t.Errorf("Expected restored book to be visible, got %+v (%v)", got, err)
+	}
+	if _, err := store.RestoreBook(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound restoring an active book, got %v", err)
+	}
+
+	if n, err := store.PurgeTrash(ctx, t0.Add(30*time.Minute)); err != nil || n != 0 {
+		t.Errorf("Expected nothing older than cutoff, purged %d (%v)", n, err)
+	}
+	if n, err := store.PurgeTrash(ctx, t0.Add(2*time.Hour)); err != nil || n != 1 {
+		t.Errorf("Expected 1 purged book, got %d (%v)", n, err)
+	}
+	if trash, _ := store.ListTrash(ctx); len(trash) != 0 {
+		t.Errorf("Expected empty trash, got %+v", trash)
+	}
+	if _, err := store.RestoreBook(ctx, second.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected purged book to be gone, got %v", err)
+	}
+
+	if err := store.PurgeBook(ctx, first.ID, first.Version); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("Expected ErrPreconditionFailed for stale version, got %v", err)
+	}
+	if err := store.PurgeBook(ctx, first.ID, restored.Version); err != nil {
+		t.Fatalf("Failed to purge active book: %v", err)
+	}
+	if _, err := store.GetBookByID(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected purged book to be gone, got %v", err)
+	}
+	if err := store.PurgeBook(ctx, first.ID, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound purging twice, got %v", err)
+	}
+
+	revs, _ := store.BookHistory(ctx, first.ID)
+	actions := []string{ActionCreate, ActionDelete, ActionRestore, ActionPurge}
+	if len(revs) != len(actions) {
+		t.Fatalf("Expected %d revisions, got %+v", len(actions), revs)
+	}
+	for i, action := range actions {
+		if revs[i].Action != action {
+			t.Errorf("Revision %d: expected %s, got %s", i+1, action, revs[i].Action)
+		}
+	}
+	if revs[2].Before != nil || revs[2].After.Version != restored.Version || revs[3].Before == nil || revs[3].After != nil {
+		t.Errorf("Unexpected restore/purge revisions: %+v %+v", revs[2], revs[3])
+	}
+	if revs, _ := store.BookHistory(ctx, second.ID); len(revs) != 3 || revs[2].Action != ActionPurge || revs[2].Before.Title != "Second" {
+		t.Errorf("Expected trashed book purge to be recorded, got %+v", revs)
+	}
+
+	// Buku di trash juga bisa dihapus permanen langsung.
+	third := createBook(store, "Third", "Go Dev", 2024)
+	store.DeleteBook(ctx, third.ID, 0)
+	if err := store.PurgeBook(ctx, third.ID, third.Version); err != nil {
+		t.Errorf("Failed to purge trashed book: %v", err)
+	}
+	if trash, _ := store.ListTrash(ctx); len(trash) != 0 {
+		t.Errorf("Expected empty trash, got %+v", trash)
+	}
+}
+
+func TestRunTrashPurger(t *testing.T) {
+	store := setupStore()
+	old := defaultBook(store)
+	fresh := createBook(store, "Fresh", "Go Dev", 2024)
+
+	defer func(orig func() time.Time) { now = orig }(now)
+	t0 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return t0 }
+	store.DeleteBook(ctx, old.ID, 0)
+	now = func() time.Time { return t0.Add(47 * time.Hour) }
+	store.DeleteBook(ctx, fresh.ID, 0)
+	now = func() time.Time { return t0.Add(48 * time.Hour) }
+
+	runCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	RunTrashPurger(runCtx, store, 24*time.Hour, time.Millisecond)
+
+	trash, _ := store.ListTrash(ctx)
+	if len(trash) != 1 || trash[0].ID != fresh.ID {
+		t.Errorf("Expected only the fresh book in trash, got %+v", trash)
+	}
+	revs, _ := store.BookHistory(ctx, old.ID)
+	if last := revs[len(revs)-1]; last.Action != ActionPurge || last.Actor != TrashPurgerActor {
+		t.Errorf("Expected purge by %s, got %+v", TrashPurgerActor, last)
+	}
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTitleLength adalah panjang maksimum judul buku (dalam karakter).
+	MaxTitleLength = 255
+	// MaxAuthorLength adalah panjang maksimum nama penulis (dalam karakter).
+	MaxAuthorLength = 100
+)
+
+// Kode aturan validasi pada FieldError.Code. Nilainya stabil sehingga client
+// dapat memetakannya ke pesan atau penanda input sendiri.
+const (
+	RuleRequired     = "required"
+	RuleTrimmed      = "trimmed"
+	RuleMaxLength    = "max_length"
+	RuleMin          = "min"
+	RuleNotFuture    = "not_future"
+	RuleISBNFormat   = "isbn_format"
+	RuleISBNChecksum = "isbn_checksum"
+	RuleReadOnly     = "read_only"
+	// Kode di bawah ini dipakai validasi request terhadap dokumen OpenAPI.
+	RuleType     = "type"
+	RuleMax      = "max"
+	RulePattern  = "pattern"
+	RuleFormat   = "format"
+	RuleMinItems = "min_items"
+	RuleMaxItems = "max_items"
+)
+
+// FieldError adalah satu pelanggaran aturan validasi pada sebuah field.
+type FieldError struct {
+	// Field adalah path field dalam representasi JSON, misalnya "published_year".
+	Field string `json:"field"`
+	// Code adalah kode aturan yang dilanggar (lihat konstanta Rule*).
+	Code string `json:"code"`
+	// Message adalah penjelasan yang bisa dibaca manusia.
+	Message string `json:"message"`
+}
+
+// ValidationError berisi seluruh pelanggaran validasi sebuah buku.
+// errors.Is(err, ErrValidation) bernilai true untuk error ini.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Message
+	}
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// add mencatat satu pelanggaran.
+func (e *ValidationError) add(field, code, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate memeriksa data buku yang dikirim client: field wajib, panjang teks,
+// spasi di awal/akhir, tahun terbit yang tidak di masa depan, dan checksum ISBN jika diisi.
+// Field yang dikelola store (ID, Version, UpdatedAt) tidak diperiksa.
+//
+// Returns:
+//   - nil jika buku valid
+//   - *ValidationError berisi semua pelanggaran jika tidak valid
+func (b Book) Validate() error {
+	verr := &ValidationError{}
+	validateText(verr, "title", b.Title, MaxTitleLength)
+	validateText(verr, "author", b.Author, MaxAuthorLength)
+
+	switch thisYear := now().Year(); {
+	case b.PublishedYear == 0:
+		verr.add("published_year", RuleRequired, "published_year is required")
+	case b.PublishedYear < 0:
+		verr.add("published_year", RuleMin, "published_year must be positive")
+	case b.PublishedYear > thisYear:
+		verr.add("published_year", RuleNotFuture, "published_year must not be later than %d", thisYear)
+	}
+
+	if b.ISBN != "" {
+		validateISBN(verr, b.ISBN)
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// validateText memeriksa field teks wajib: tidak kosong, tanpa spasi di awal/akhir,
+// dan tidak lebih dari max karakter.
+func validateText(verr *ValidationError, field, value string, max int) {
+	if strings.TrimSpace(value) == "" {
+		verr.add(field, RuleRequired, "%s is required", field)
+		return
+	}
+	if strings.TrimSpace(value) != value {
+		verr.add(field, RuleTrimmed, "%s must not start or end with whitespace", field)
+	}
+	if n := utf8.RuneCountInString(value); n > max {
+		verr.add(field, RuleMaxLength, "%s must be at most %d characters, got %d", field, max, n)
+	}
+}
+
+// validateISBN memeriksa format dan checksum ISBN-10 atau ISBN-13.
+// Tanda hubung dan spasi di antara digit diabaikan.
+func validateISBN(verr *ValidationError, isbn string) {
+	if strings.TrimSpace(isbn) != isbn {
+		verr.add("isbn", RuleTrimmed, "isbn must not start or end with whitespace")
+		return
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, isbn)
+
+	var ok, wellFormed bool
+	switch len(digits) {
+	case 10:
+		ok, wellFormed = isbn10Checksum(digits)
+	case 13:
+		ok, wellFormed = isbn13Checksum(digits)
+	}
+	switch {
+	case !wellFormed:
+		verr.add("isbn", RuleISBNFormat, "isbn must be a 10 or 13 digit ISBN")
+	case !ok:
+		verr.add("isbn", RuleISBNChecksum, "isbn checksum is invalid")
+	}
+}
+
+// isbn10Checksum mengembalikan apakah checksum ISBN-10 benar dan apakah formatnya valid.
+// Digit terakhir boleh "X" (bernilai 10).
+func isbn10Checksum(s string) (ok, wellFormed bool) {
+	sum := 0
+	for i, r := range s {
+		var d int
+		switch {
+		case r >= '0' && r <= '9':
+			d = int(r - '0')
+		case (r == 'X' || r == 'x') && i == 9:
+			d = 10
+		default:
+			return false, false
+		}
+		sum += (10 - i) * d
+	}
+	return sum%11 == 0, true
+}
+
+// isbn13Checksum mengembalikan apakah checksum ISBN-13 benar dan apakah formatnya valid.
+func isbn13Checksum(s string) (ok, wellFormed bool) {
+	sum := 0
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return false, false
+		}
+		d := int(r - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return sum%10 == 0, true
+}
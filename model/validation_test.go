@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBookValidate(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	valid := Book{Title: "Bumi Manusia", Author: "Pramoedya Ananta Toer", PublishedYear: 1980}
+
+	tests := []struct {
+		name   string
+		modify func(b *Book)
+		want   []FieldError
+	}{
+		{"valid", func(b *Book) {}, nil},
+		{"valid isbn-13", func(b *Book) { b.ISBN = "978-0-306-40615-7" }, nil},
+		{"valid isbn-10 with X", func(b *Book) { b.ISBN = "0-8044-2957-X" }, nil},
+		{"current year", func(b *Book) { b.PublishedYear = 2025 }, nil},
+		{"all missing", func(b *Book) { *b = Book{} }, []FieldError{
+			{Field: "title", Code: RuleRequired},
+			{Field: "author", Code: RuleRequired},
+			{Field: "published_year", Code: RuleRequired},
+		}},
+		{"whitespace only", func(b *Book) { b.Title = "   " }, []FieldError{{Field: "title", Code: RuleRequired}}},
+		{"untrimmed", func(b *Book) { b.Author = " Pram " }, []FieldError{{Field: "author", Code: RuleTrimmed}}},
+		{"too long", func(b *Book) { b.Title = strings.Repeat("é", MaxTitleLength+1) }, []FieldError{{Field: "title", Code: RuleMaxLength}}},
+		{"negative year", func(b *Book) { b.PublishedYear = -5 }, []FieldError{{Field: "published_year", Code: RuleMin}}},
+		{"future year", func(b *Book) { b.PublishedYear = 2026 }, []FieldError{{Field: "published_year", Code: RuleNotFuture}}},
+		{"isbn bad checksum", func(b *Book) { b.ISBN = "978-0-306-40615-8" }, []FieldError{{Field: "isbn", Code: RuleISBNChecksum}}},
+		{"isbn bad length", func(b *Book) { b.ISBN = "12345" }, []FieldError{{Field: "isbn", Code: RuleISBNFormat}}},
+		{"isbn letters", func(b *Book) { b.ISBN = "97803064061AB" }, []FieldError{{Field: "isbn", Code: RuleISBNFormat}}},
+		{"multiple", func(b *Book) { b.Title = ""; b.PublishedYear = 3000; b.ISBN = "0306406153" }, []FieldError{
+			{Field: "title", Code: RuleRequired},
+			{Field: "published_year", Code: RuleNotFuture},
+			{Field: "isbn", Code: RuleISBNChecksum},
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := valid
+			tc.modify(&b)
+			err := b.Validate()
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("Expected valid book, got %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) || !errors.Is(err, ErrValidation) {
+				t.Fatalf("Expected *ValidationError wrapping ErrValidation, got %v", err)
+			}
+			if len(verr.Fields) != len(tc.want) {
+				t.Fatalf("Expected %d violations, got %+v", len(tc.want), verr.Fields)
+			}
+			for i, want := range tc.want {
+				got := verr.Fields[i]
+				if got.Field != want.Field || got.Code != want.Code || got.Message == "" {
+					t.Errorf("Violation %d: expected %s/%s, got %+v", i, want.Field, want.Code, got)
+				}
+			}
+		})
+	}
+}
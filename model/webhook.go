@@ -0,0 +1,470 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxWebhookURLLength adalah panjang maksimum URL webhook (dalam karakter).
+	MaxWebhookURLLength = 2048
+	// MinWebhookSecretLength adalah panjang minimum secret webhook yang diisi client.
+	MinWebhookSecretLength = 16
+	// MaxWebhookSecretLength adalah panjang maksimum secret webhook.
+	MaxWebhookSecretLength = 256
+
+	// maxWebhookDeliveries adalah jumlah attempt terakhir yang disimpan di delivery log per webhook.
+	maxWebhookDeliveries = 100
+	// maxWebhookDeadLetters adalah jumlah dead letter terakhir yang disimpan per webhook.
+	maxWebhookDeadLetters = 1000
+)
+
+// Kode aturan validasi tambahan untuk webhook (lihat juga konstanta Rule* di validation.go).
+const (
+	RuleURL       = "url"
+	RuleMinLength = "min_length"
+)
+
+// ErrWebhookNotFound dikembalikan ketika webhook dengan ID yang diminta tidak ada.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Webhook adalah langganan sistem lain terhadap perubahan buku. Setiap ChangeEvent
+// yang cocok dikirim sebagai POST JSON ke URL, ditandatangani dengan Secret.
+type Webhook struct {
+	ID  int    `json:"id"`
+	URL string `json:"url"`
+	// Secret adalah kunci HMAC-SHA256 untuk header signature. Hanya dikirim ke client
+	// saat webhook dibuat (lihat Redacted).
+	Secret string `json:"secret,omitempty"`
+	// Events berisi jenis ChangeEvent yang dikirim; kosong berarti semua jenis.
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate memeriksa data webhook yang dikirim client: URL http/https absolut,
+// jenis event yang dikenal, dan panjang secret jika diisi.
+//
+// Returns:
+//   - nil jika webhook valid
+//   - *ValidationError berisi semua pelanggaran jika tidak valid
+func (wh Webhook) Validate() error {
+	verr := &ValidationError{}
+	switch u, err := url.Parse(wh.URL); {
+	case strings.TrimSpace(wh.URL) == "":
+		verr.add("url", RuleRequired, "url is required")
+	case utf8.RuneCountInString(wh.URL) > MaxWebhookURLLength:
+		verr.add("url", RuleMaxLength, "url must be at most %d characters", MaxWebhookURLLength)
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		verr.add("url", RuleURL, "url must be an absolute http or https URL")
+	}
+
+	for _, ev := range wh.Events {
+		if _, ok := changeTypeSet[ev]; !ok {
+			verr.add("events", RuleOneOf, "events must be one of created, updated, deleted, restored, purged, got %q", ev)
+		}
+	}
+
+	if n := utf8.RuneCountInString(wh.Secret); wh.Secret != "" && n < MinWebhookSecretLength {
+		verr.add("secret", RuleMinLength, "secret must be at least %d characters", MinWebhookSecretLength)
+	} else if n > MaxWebhookSecretLength {
+		verr.add("secret", RuleMaxLength, "secret must be at most %d characters", MaxWebhookSecretLength)
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// Redacted mengembalikan salinan webhook tanpa Secret, untuk response selain pembuatan.
+func (wh Webhook) Redacted() Webhook {
+	wh.Secret = ""
+	return wh
+}
+
+// Wants melaporkan apakah webhook aktif dan berlangganan jenis event typ.
+func (wh Webhook) Wants(typ string) bool {
+	if !wh.Active {
+		return false
+	}
+	if len(wh.Events) == 0 {
+		return true
+	}
+	for _, ev := range wh.Events {
+		if ev == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// clone mengembalikan salinan webhook yang tidak berbagi slice Events.
+func (wh Webhook) clone() Webhook {
+	wh.Events = append([]string(nil), wh.Events...)
+	return wh
+}
+
+// changeTypeSet berisi semua nilai ChangeEvent.Type yang valid.
+var changeTypeSet = func() map[string]struct{} {
+	set := make(map[string]struct{}, len(changeTypes))
+	for _, typ := range changeTypes {
+		set[typ] = struct{}{}
+	}
+	return set
+}()
+
+// WebhookDelivery adalah satu attempt pengiriman event ke sebuah webhook.
+type WebhookDelivery struct {
+	ID int `json:"id"`
+	// DeliveryID sama untuk semua attempt pengiriman event yang sama dan dikirim di
+	// header X-Webhook-Delivery agar penerima bisa mengabaikan duplikat.
+	DeliveryID string `json:"delivery_id"`
+	WebhookID  int    `json:"webhook_id"`
+	EventSeq   uint64 `json:"event_seq"`
+	EventType  string `json:"event_type"`
+	Attempt    int    `json:"attempt"`
+	// StatusCode adalah status HTTP dari penerima, atau 0 jika request gagal dikirim.
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Succeeded  bool      `json:"succeeded"`
+	DurationMS int64     `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+// WebhookDeadLetter adalah event yang gagal dikirim setelah semua attempt habis.
+type WebhookDeadLetter struct {
+	ID         int         `json:"id"`
+	DeliveryID string      `json:"delivery_id"`
+	WebhookID  int         `json:"webhook_id"`
+	Event      ChangeEvent `json:"event"`
+	Attempts   int         `json:"attempts"`
+	LastError  string      `json:"last_error"`
+	FailedAt   time.Time   `json:"failed_at"`
+}
+
+// WebhookStore menyimpan webhook beserta delivery log dan dead letter-nya.
+type WebhookStore interface {
+	// AddWebhook menyimpan webhook baru dan mengisi ID serta waktunya. Secret kosong
+	// diganti secret acak.
+	AddWebhook(ctx context.Context, wh Webhook) (Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	GetWebhook(ctx context.Context, id int) (Webhook, error)
+	// UpdateWebhook mengganti URL, Events, dan Active. Secret kosong berarti secret lama dipakai.
+	UpdateWebhook(ctx context.Context, id int, wh Webhook) (Webhook, error)
+	// DeleteWebhook menghapus webhook beserta delivery log dan dead letter-nya.
+	DeleteWebhook(ctx context.Context, id int) error
+
+	RecordDelivery(ctx context.Context, d WebhookDelivery) (WebhookDelivery, error)
+	// ListDeliveries mengembalikan attempt terakhir webhook id, yang terbaru lebih dulu.
+	ListDeliveries(ctx context.Context, id int) ([]WebhookDelivery, error)
+	AddDeadLetter(ctx context.Context, dl WebhookDeadLetter) (WebhookDeadLetter, error)
+	// ListDeadLetters mengembalikan dead letter webhook id, yang terbaru lebih dulu.
+	ListDeadLetters(ctx context.Context, id int) ([]WebhookDeadLetter, error)
+}
+
+// WebhookProvider diimplementasikan oleh BookStore durable yang menyimpan webhook di
+// storage yang sama dengan bukunya, sehingga webhook tetap ada setelah restart.
+type WebhookProvider interface {
+	Webhooks() WebhookStore
+}
+
+// webhookEntry adalah webhook beserta riwayat pengirimannya.
+type webhookEntry struct {
+	webhook     Webhook
+	deliveries  []WebhookDelivery
+	deadLetters []WebhookDeadLetter
+}
+
+type webhookStore struct {
+	mu           sync.RWMutex
+	hooks        map[int]*webhookEntry
+	lastID       int
+	lastDelivery int
+	lastDead     int
+	// save menyimpan state setelah setiap perubahan; nil untuk store yang hanya di memori.
+	save func(webhookState) error
+}
+
+// webhookState adalah seluruh isi webhookStore dalam bentuk yang bisa di-encode ke JSON.
+type webhookState struct {
+	LastID       int             `json:"last_id"`
+	LastDelivery int             `json:"last_delivery_id"`
+	LastDead     int             `json:"last_dead_letter_id"`
+	Webhooks     []webhookRecord `json:"webhooks"`
+}
+
+type webhookRecord struct {
+	Webhook     Webhook             `json:"webhook"`
+	Deliveries  []WebhookDelivery   `json:"deliveries,omitempty"`
+	DeadLetters []WebhookDeadLetter `json:"dead_letters,omitempty"`
+}
+
+// NewWebhookStore membuat WebhookStore yang menyimpan data di memori. Delivery log
+// dibatasi 100 attempt terakhir dan dead letter 1000 terakhir per webhook.
+func NewWebhookStore() WebhookStore {
+	return &webhookStore{hooks: make(map[int]*webhookEntry)}
+}
+
+// state mengembalikan salinan isi store, webhook berurutan menurut ID.
+// Harus dipanggil dengan ws.mu terkunci.
+func (ws *webhookStore) state() webhookState {
+	st := webhookState{LastID: ws.lastID, LastDelivery: ws.lastDelivery, LastDead: ws.lastDead, Webhooks: []webhookRecord{}}
+	for _, e := range ws.hooks {
+		st.Webhooks = append(st.Webhooks, webhookRecord{
+			Webhook:     e.webhook.clone(),
+			Deliveries:  append([]WebhookDelivery(nil), e.deliveries...),
+			DeadLetters: append([]WebhookDeadLetter(nil), e.deadLetters...),
+		})
+	}
+	sort.Slice(st.Webhooks, func(i, j int) bool { return st.Webhooks[i].Webhook.ID < st.Webhooks[j].Webhook.ID })
+	return st
+}
+
+// restore mengganti isi store dengan st. Harus dipanggil dengan ws.mu terkunci.
+func (ws *webhookStore) restore(st webhookState) {
+	ws.lastID, ws.lastDelivery, ws.lastDead = st.LastID, st.LastDelivery, st.LastDead
+	ws.hooks = make(map[int]*webhookEntry, len(st.Webhooks))
+	for _, rec := range st.Webhooks {
+		ws.hooks[rec.Webhook.ID] = &webhookEntry{webhook: rec.Webhook, deliveries: rec.Deliveries, deadLetters: rec.DeadLetters}
+	}
+}
+
+// update menjalankan fn dengan ws.mu terkunci. Jika store durable, state disimpan
+// setelah fn berhasil; jika penyimpanan gagal, perubahan fn dibatalkan dan
+// ErrUnavailable dikembalikan.
+func (ws *webhookStore) update(fn func() error) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.save == nil {
+		return fn()
+	}
+	before := ws.state()
+	if err := fn(); err != nil {
+		return err
+	}
+	if err := ws.save(ws.state()); err != nil {
+		ws.restore(before)
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// AddWebhook menyimpan webhook baru.
+//
+// Returns:
+//   - Webhook yang tersimpan, termasuk Secret
+//   - *ValidationError jika data tidak valid, atau error dari ctx
+func (ws *webhookStore) AddWebhook(ctx context.Context, wh Webhook) (Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return Webhook{}, err
+	}
+	if err := wh.Validate(); err != nil {
+		return Webhook{}, err
+	}
+	if wh.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return Webhook{}, err
+		}
+		wh.Secret = secret
+	}
+
+	err := ws.update(func() error {
+		ws.lastID++
+		wh.ID = ws.lastID
+		wh.CreatedAt = now()
+		wh.UpdatedAt = wh.CreatedAt
+		wh = wh.clone()
+		ws.hooks[wh.ID] = &webhookEntry{webhook: wh}
+		return nil
+	})
+	if err != nil {
+		return Webhook{}, err
+	}
+	return wh.clone(), nil
+}
+
+// ListWebhooks mengembalikan semua webhook berurutan menurut ID.
+func (ws *webhookStore) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	out := make([]Webhook, 0, len(ws.hooks))
+	for _, e := range ws.hooks {
+		out = append(out, e.webhook.clone())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// GetWebhook mengembalikan webhook berdasarkan ID, atau ErrWebhookNotFound.
+func (ws *webhookStore) GetWebhook(ctx context.Context, id int) (Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return Webhook{}, err
+	}
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	e, ok := ws.hooks[id]
+	if !ok {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return e.webhook.clone(), nil
+}
+
+// UpdateWebhook mengganti data webhook; ID dan CreatedAt tidak berubah.
+//
+// Returns:
+//   - Webhook yang sudah diperbarui, termasuk Secret
+//   - ErrWebhookNotFound jika ID tidak ditemukan
+//   - *ValidationError jika data tidak valid
+func (ws *webhookStore) UpdateWebhook(ctx context.Context, id int, wh Webhook) (Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return Webhook{}, err
+	}
+	if err := wh.Validate(); err != nil {
+		return Webhook{}, err
+	}
+
+	err := ws.update(func() error {
+		e, ok := ws.hooks[id]
+		if !ok {
+			return ErrWebhookNotFound
+		}
+		if wh.Secret == "" {
+			wh.Secret = e.webhook.Secret
+		}
+		wh.ID = id
+		wh.CreatedAt = e.webhook.CreatedAt
+		wh.UpdatedAt = now()
+		e.webhook = wh.clone()
+		return nil
+	})
+	if err != nil {
+		return Webhook{}, err
+	}
+	return wh.clone(), nil
+}
+
+// DeleteWebhook menghapus webhook berdasarkan ID, atau mengembalikan ErrWebhookNotFound.
+func (ws *webhookStore) DeleteWebhook(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ws.update(func() error {
+		if _, ok := ws.hooks[id]; !ok {
+			return ErrWebhookNotFound
+		}
+		delete(ws.hooks, id)
+		return nil
+	})
+}
+
+// RecordDelivery menambahkan satu attempt ke delivery log webhook d.WebhookID.
+// Attempt untuk webhook yang sudah dihapus mengembalikan ErrWebhookNotFound.
+func (ws *webhookStore) RecordDelivery(ctx context.Context, d WebhookDelivery) (WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return WebhookDelivery{}, err
+	}
+	err := ws.update(func() error {
+		e, ok := ws.hooks[d.WebhookID]
+		if !ok {
+			return ErrWebhookNotFound
+		}
+		ws.lastDelivery++
+		d.ID = ws.lastDelivery
+		e.deliveries = appendBounded(e.deliveries, d, maxWebhookDeliveries)
+		return nil
+	})
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+// ListDeliveries mengembalikan delivery log webhook id, yang terbaru lebih dulu.
+func (ws *webhookStore) ListDeliveries(ctx context.Context, id int) ([]WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	e, ok := ws.hooks[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	return newestFirst(e.deliveries), nil
+}
+
+// AddDeadLetter menyimpan event yang gagal dikirim ke webhook dl.WebhookID.
+func (ws *webhookStore) AddDeadLetter(ctx context.Context, dl WebhookDeadLetter) (WebhookDeadLetter, error) {
+	if err := ctx.Err(); err != nil {
+		return WebhookDeadLetter{}, err
+	}
+	err := ws.update(func() error {
+		e, ok := ws.hooks[dl.WebhookID]
+		if !ok {
+			return ErrWebhookNotFound
+		}
+		ws.lastDead++
+		dl.ID = ws.lastDead
+		e.deadLetters = appendBounded(e.deadLetters, dl, maxWebhookDeadLetters)
+		return nil
+	})
+	if err != nil {
+		return WebhookDeadLetter{}, err
+	}
+	return dl, nil
+}
+
+// ListDeadLetters mengembalikan dead letter webhook id, yang terbaru lebih dulu.
+func (ws *webhookStore) ListDeadLetters(ctx context.Context, id int) ([]WebhookDeadLetter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	e, ok := ws.hooks[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	return newestFirst(e.deadLetters), nil
+}
+
+// appendBounded menambahkan v ke s dan membuang elemen terlama jika panjangnya melebihi max.
+func appendBounded[T any](s []T, v T, max int) []T {
+	if len(s) == max {
+		s = append(s[:0], s[1:]...)
+	}
+	return append(s, v)
+}
+
+// newestFirst mengembalikan salinan s dengan urutan terbalik.
+func newestFirst[T any](s []T) []T {
+	out := make([]T, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
+
+// newWebhookSecret membuat secret acak 32 byte dalam bentuk hex.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,304 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Header yang dikirim pada setiap request webhook.
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookSignatureHeader berisi "sha256=" diikuti HMAC-SHA256 hex dari
+	// "<timestamp>.<body>" dengan secret webhook (lihat SignWebhookPayload).
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookDispatcherOptions mengatur pengiriman webhook. Field yang kosong memakai default.
+type WebhookDispatcherOptions struct {
+	// Client dipakai untuk mengirim request (default: http.Client dengan timeout 10 detik).
+	Client *http.Client
+	// MaxAttempts adalah jumlah attempt sebelum event dipindahkan ke dead letter (default 5).
+	MaxAttempts int
+	// InitialBackoff adalah jeda sebelum retry pertama; jeda berikutnya dua kali lipat (default 1 detik).
+	InitialBackoff time.Duration
+	// MaxBackoff adalah batas atas jeda antar attempt (default 5 menit).
+	MaxBackoff time.Duration
+	// QueueSize adalah jumlah event yang boleh antre per webhook; event yang tidak
+	// muat langsung menjadi dead letter (default 1000).
+	QueueSize int
+}
+
+// withDefaults mengisi field yang kosong dengan nilai default.
+func (o WebhookDispatcherOptions) withDefaults() WebhookDispatcherOptions {
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1000
+	}
+	return o
+}
+
+// backoff mengembalikan jeda sebelum attempt berikutnya setelah attempt ke-n gagal.
+func (o WebhookDispatcherOptions) backoff(n int) time.Duration {
+	d := o.InitialBackoff
+	for i := 1; i < n && d < o.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > o.MaxBackoff {
+		d = o.MaxBackoff
+	}
+	return d
+}
+
+// SignWebhookPayload menghitung nilai header X-Webhook-Signature untuk body yang
+// dikirim pada timestamp (detik Unix). Penerima menghitung ulang nilai ini dengan
+// secret yang sama dan membandingkannya dengan hmac.Equal.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RunWebhookDispatcher mengirim setiap event dari feed ke webhook aktif yang
+// berlangganan jenis event tersebut, sampai ctx dibatalkan. Event dikirim berurutan
+// per webhook; attempt yang gagal (error jaringan atau status selain 2xx) diulang
+// dengan exponential backoff, lalu dipindahkan ke dead letter setelah MaxAttempts.
+// Setiap attempt dicatat di delivery log.
+//
+// Parameters:
+//   - ctx: context yang menghentikan dispatcher ketika dibatalkan
+//   - feed: sumber event, dari ChangeNotifier.Changes
+//   - hooks: WebhookStore berisi webhook tujuan dan tempat mencatat hasil pengiriman
+//   - opts: pengaturan client, retry, dan antrean
+func RunWebhookDispatcher(ctx context.Context, feed *ChangeFeed, hooks WebhookStore, opts WebhookDispatcherOptions) {
+	d := &webhookDispatcher{
+		hooks:   hooks,
+		opts:    opts.withDefaults(),
+		workers: make(map[int]chan ChangeEvent),
+	}
+	defer d.wg.Wait()
+
+	var lastSeq uint64
+	for {
+		sub, backlog, ok := feed.Subscribe(feed.Epoch(), lastSeq, ChangeFilter{})
+		if !ok {
+			log.Printf("webhook dispatcher: events after seq %d are no longer available and were not delivered", lastSeq)
+		}
+		for _, ev := range backlog {
+			d.dispatch(ctx, ev)
+			lastSeq = ev.Seq
+		}
+		if lastSeq == 0 || !ok {
+			lastSeq = sub.StartSeq()
+		}
+
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				sub.Close()
+				return
+			case ev, more := <-sub.Events():
+				if open = more; more {
+					d.dispatch(ctx, ev)
+					lastSeq = ev.Seq
+				}
+			}
+		}
+		// Langganan diputus karena dispatcher tertinggal; lanjutkan dari event terakhir.
+		sub.Close()
+	}
+}
+
+// webhookDispatcher membagi event ke worker per webhook.
+type webhookDispatcher struct {
+	hooks   WebhookStore
+	opts    WebhookDispatcherOptions
+	wg      sync.WaitGroup
+	workers map[int]chan ChangeEvent
+}
+
+// dispatch memasukkan ev ke antrean setiap webhook yang berlangganan, dan menghentikan
+// worker milik webhook yang sudah dihapus.
+func (d *webhookDispatcher) dispatch(ctx context.Context, ev ChangeEvent) {
+	hooks, err := d.hooks.ListWebhooks(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("webhook dispatcher: %v", err)
+		}
+		return
+	}
+
+	existing := make(map[int]bool, len(hooks))
+	for _, wh := range hooks {
+		existing[wh.ID] = true
+	}
+	for id, queue := range d.workers {
+		if !existing[id] {
+			close(queue)
+			delete(d.workers, id)
+		}
+	}
+
+	for _, wh := range hooks {
+		if !wh.Wants(ev.Type) {
+			continue
+		}
+		queue, ok := d.workers[wh.ID]
+		if !ok {
+			queue = make(chan ChangeEvent, d.opts.QueueSize)
+			d.workers[wh.ID] = queue
+			d.wg.Add(1)
+			go d.work(ctx, wh.ID, queue)
+		}
+		select {
+		case queue <- ev:
+		default:
+			d.deadLetter(ctx, wh.ID, ev, 0, "delivery queue is full")
+		}
+	}
+}
+
+// work mengirim event dari queue ke webhook id satu per satu sampai queue ditutup
+// atau ctx dibatalkan.
+func (d *webhookDispatcher) work(ctx context.Context, id int, queue <-chan ChangeEvent) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-queue:
+			if !ok {
+				return
+			}
+			d.deliver(ctx, id, ev)
+		}
+	}
+}
+
+// deliver mengirim ev ke webhook id dengan retry. Webhook yang dihapus atau dinonaktifkan
+// di tengah retry berhenti dikirimi; perubahan URL dan secret berlaku pada attempt berikutnya.
+func (d *webhookDispatcher) deliver(ctx context.Context, id int, ev ChangeEvent) {
+	deliveryID := webhookDeliveryID(ev, id)
+	body, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("webhook dispatcher: encode event %d: %v", ev.Seq, err)
+		return
+	}
+
+	var lastErr string
+	for attempt := 1; attempt <= d.opts.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			t := time.NewTimer(d.opts.backoff(attempt - 1))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+
+		wh, err := d.hooks.GetWebhook(ctx, id)
+		if err != nil || !wh.Wants(ev.Type) {
+			return
+		}
+
+		rec := d.attempt(ctx, wh, deliveryID, ev, body)
+		rec.Attempt = attempt
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := d.hooks.RecordDelivery(ctx, rec); errors.Is(err, ErrWebhookNotFound) {
+			return
+		}
+		if rec.Succeeded {
+			return
+		}
+		lastErr = rec.Error
+	}
+	d.deadLetter(ctx, id, ev, d.opts.MaxAttempts, lastErr)
+}
+
+// attempt mengirim satu request webhook dan mengembalikan catatan hasilnya.
+func (d *webhookDispatcher) attempt(ctx context.Context, wh Webhook, deliveryID string, ev ChangeEvent, body []byte) (rec WebhookDelivery) {
+	rec = WebhookDelivery{DeliveryID: deliveryID, WebhookID: wh.ID, EventSeq: ev.Seq, EventType: ev.Type, At: now()}
+	start := time.Now()
+	defer func() { rec.DurationMS = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	ts := rec.At.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "book-api-webhooks/1")
+	req.Header.Set(WebhookIDHeader, strconv.Itoa(wh.ID))
+	req.Header.Set(WebhookEventHeader, ev.Type)
+	req.Header.Set(WebhookDeliveryHeader, deliveryID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(wh.Secret, ts, body))
+
+	res, err := d.opts.Client.Do(req)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	// Body dibaca sebagian agar koneksi bisa dipakai ulang.
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	res.Body.Close()
+
+	rec.StatusCode = res.StatusCode
+	rec.Succeeded = res.StatusCode >= 200 && res.StatusCode < 300
+	if !rec.Succeeded {
+		rec.Error = "unexpected status " + res.Status
+	}
+	return rec
+}
+
+// webhookDeliveryID membuat ID pengiriman ev ke webhook id. ID event berisi epoch feed,
+// jadi ID pengiriman tetap unik setelah server dijalankan ulang dan Seq mulai dari 1 lagi.
+func webhookDeliveryID(ev ChangeEvent, id int) string {
+	return fmt.Sprintf("%s-%d", ev.ID, id)
+}
+
+// deadLetter mencatat ev sebagai gagal dikirim ke webhook id.
+func (d *webhookDispatcher) deadLetter(ctx context.Context, id int, ev ChangeEvent, attempts int, reason string) {
+	dl := WebhookDeadLetter{
+		DeliveryID: webhookDeliveryID(ev, id),
+		WebhookID:  id,
+		Event:      ev,
+		Attempts:   attempts,
+		LastError:  reason,
+		FailedAt:   now(),
+	}
+	if _, err := d.hooks.AddDeadLetter(ctx, dl); err != nil && !errors.Is(err, ErrWebhookNotFound) && ctx.Err() == nil {
+		log.Printf("webhook dispatcher: %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// webhookColumns adalah urutan kolom yang dibaca oleh scanWebhook.
+const webhookColumns = `id, url, secret, events, active, created_at, updated_at`
+
+const deliveryColumns = `id, delivery_id, webhook_id, event_seq, event_type, attempt, status_code, error, succeeded, duration_ms, at`
+
+const deadLetterColumns = `id, delivery_id, webhook_id, event_json, attempts, last_error, failed_at`
+
+// sqlWebhookStore menyimpan webhook di tabel webhooks, webhook_deliveries, dan
+// webhook_dead_letters pada database yang sama dengan sqlBookStore.
+type sqlWebhookStore struct {
+	db *sql.DB
+}
+
+func scanWebhook(row rowScanner) (Webhook, error) {
+	var wh Webhook
+	var events string
+	var createdAt, updatedAt int64
+	if err := row.Scan(&wh.ID, &wh.URL, &wh.Secret, &events, &wh.Active, &createdAt, &updatedAt); err != nil {
+		return Webhook{}, err
+	}
+	if err := json.Unmarshal([]byte(events), &wh.Events); err != nil {
+		return Webhook{}, fmt.Errorf("decode webhook events: %w", err)
+	}
+	if len(wh.Events) == 0 {
+		wh.Events = nil
+	}
+	wh.CreatedAt = fromUnixNano(createdAt)
+	wh.UpdatedAt = fromUnixNano(updatedAt)
+	return wh, nil
+}
+
+func scanDelivery(row rowScanner) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	var at int64
+	err := row.Scan(&d.ID, &d.DeliveryID, &d.WebhookID, &d.EventSeq, &d.EventType, &d.Attempt,
+		&d.StatusCode, &d.Error, &d.Succeeded, &d.DurationMS, &at)
+	d.At = fromUnixNano(at)
+	return d, err
+}
+
+func scanDeadLetter(row rowScanner) (WebhookDeadLetter, error) {
+	var dl WebhookDeadLetter
+	var event string
+	var failedAt int64
+	if err := row.Scan(&dl.ID, &dl.DeliveryID, &dl.WebhookID, &event, &dl.Attempts, &dl.LastError, &failedAt); err != nil {
+		return WebhookDeadLetter{}, err
+	}
+	if err := json.Unmarshal([]byte(event), &dl.Event); err != nil {
+		return WebhookDeadLetter{}, fmt.Errorf("decode dead letter event: %w", err)
+	}
+	dl.FailedAt = fromUnixNano(failedAt)
+	return dl, nil
+}
+
+// mapWebhookError seperti mapSQLError, tetapi baris yang tidak ada berarti ErrWebhookNotFound.
+func mapWebhookError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	return mapSQLError(err)
+}
+
+// marshalWebhookEvents mengubah Events menjadi JSON untuk kolom events; nil menjadi [].
+func marshalWebhookEvents(events []string) (string, error) {
+	if events == nil {
+		events = []string{}
+	}
+	data, err := json.Marshal(events)
+	return string(data), err
+}
+
+// withTx menjalankan fn di dalam transaksi dan melakukan commit jika fn berhasil.
+func (ws *sqlWebhookStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := ws.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapSQLError(err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return mapSQLError(tx.Commit())
+}
+
+// rowQuerier dipenuhi oleh *sql.DB dan *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// requireWebhook mengembalikan ErrWebhookNotFound jika webhook id tidak ada.
+func requireWebhook(ctx context.Context, q rowQuerier, id int) error {
+	var one int
+	return mapWebhookError(q.QueryRowContext(ctx, `SELECT 1 FROM webhooks WHERE id = ?`, id).Scan(&one))
+}
+
+// AddWebhook menyimpan webhook baru ke tabel webhooks; ID diberikan oleh database.
+//
+// Returns:
+//   - Webhook yang tersimpan, termasuk Secret
+//   - *ValidationError jika data tidak valid, atau error jika query gagal
+func (ws *sqlWebhookStore) AddWebhook(ctx context.Context, wh Webhook) (Webhook, error) {
+	if err := wh.Validate(); err != nil {
+		return Webhook{}, err
+	}
+	if wh.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return Webhook{}, err
+		}
+		wh.Secret = secret
+	}
+	events, err := marshalWebhookEvents(wh.Events)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	wh.CreatedAt = now()
+	wh.UpdatedAt = wh.CreatedAt
+	err = ws.db.QueryRowContext(ctx,
+		`INSERT INTO webhooks (url, secret, events, active, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+		wh.URL, wh.Secret, events, wh.Active, wh.CreatedAt.UnixNano(), wh.UpdatedAt.UnixNano(),
+	).Scan(&wh.ID)
+	if err != nil {
+		return Webhook{}, mapSQLError(err)
+	}
+	return wh.clone(), nil
+}
+
+// ListWebhooks mengembalikan semua webhook berurutan menurut ID.
+func (ws *sqlWebhookStore) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	rows, err := ws.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, mapSQLError(err)
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, mapSQLError(err)
+		}
+		hooks = append(hooks, wh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapSQLError(err)
+	}
+	return hooks, nil
+}
+
+// GetWebhook mengembalikan webhook berdasarkan ID, atau ErrWebhookNotFound.
+func (ws *sqlWebhookStore) GetWebhook(ctx context.Context, id int) (Webhook, error) {
+	wh, err := scanWebhook(ws.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id))
+	if err != nil {
+		return Webhook{}, mapWebhookError(err)
+	}
+	return wh, nil
+}
+
+// UpdateWebhook mengganti URL, Events, dan Active; Secret kosong berarti secret lama dipakai.
+//
+// Returns:
+//   - Webhook yang sudah diperbarui, termasuk Secret
+//   - ErrWebhookNotFound jika ID tidak ditemukan
+//   - *ValidationError jika data tidak valid
+func (ws *sqlWebhookStore) UpdateWebhook(ctx context.Context, id int, wh Webhook) (Webhook, error) {
+	if err := wh.Validate(); err != nil {
+		return Webhook{}, err
+	}
+	events, err := marshalWebhookEvents(wh.Events)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	var updated Webhook
+	err = ws.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE webhooks SET url = ?, secret = COALESCE(NULLIF(?, ''), secret), events = ?, active = ?, updated_at = ? WHERE id = ?`,
+			wh.URL, wh.Secret, events, wh.Active, now().UnixNano(), id)
+		if err != nil {
+			return mapSQLError(err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return mapSQLError(err)
+		} else if n == 0 {
+			return ErrWebhookNotFound
+		}
+		updated, err = scanWebhook(tx.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id))
+		return mapWebhookError(err)
+	})
+	if err != nil {
+		return Webhook{}, err
+	}
+	return updated, nil
+}
+
+// DeleteWebhook menghapus webhook beserta delivery log dan dead letter-nya.
+func (ws *sqlWebhookStore) DeleteWebhook(ctx context.Context, id int) error {
+	res, err := ws.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return mapSQLError(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return mapSQLError(err)
+	} else if n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// RecordDelivery menambahkan satu attempt ke delivery log webhook d.WebhookID dan
+// membuang attempt yang lebih lama dari 100 attempt terakhir.
+func (ws *sqlWebhookStore) RecordDelivery(ctx context.Context, d WebhookDelivery) (WebhookDelivery, error) {
+	err := ws.withTx(ctx, func(tx *sql.Tx) error {
+		if err := requireWebhook(ctx, tx, d.WebhookID); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO webhook_deliveries (delivery_id, webhook_id, event_seq, event_type, attempt, status_code, error, succeeded, duration_ms, at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+			d.DeliveryID, d.WebhookID, int64(d.EventSeq), d.EventType, d.Attempt, d.StatusCode, d.Error, d.Succeeded, d.DurationMS, d.At.UnixNano(),
+		).Scan(&d.ID)
+		if err != nil {
+			return mapSQLError(err)
+		}
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM webhook_deliveries WHERE webhook_id = ? AND id <= (
+			     SELECT id FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?)`,
+			d.WebhookID, d.WebhookID, maxWebhookDeliveries)
+		return mapSQLError(err)
+	})
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+// ListDeliveries mengembalikan delivery log webhook id, yang terbaru lebih dulu.
+func (ws *sqlWebhookStore) ListDeliveries(ctx context.Context, id int) ([]WebhookDelivery, error) {
+	if err := requireWebhook(ctx, ws.db, id); err != nil {
+		return nil, err
+	}
+	rows, err := ws.db.QueryContext(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC`, id)
+	if err != nil {
+		return nil, mapSQLError(err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, mapSQLError(err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapSQLError(err)
+	}
+	return deliveries, nil
+}
+
+// AddDeadLetter menyimpan event yang gagal dikirim ke webhook dl.WebhookID dan
+// membuang dead letter yang lebih lama dari 1000 terakhir.
+func (ws *sqlWebhookStore) AddDeadLetter(ctx context.Context, dl WebhookDeadLetter) (WebhookDeadLetter, error) {
+	event, err := json.Marshal(dl.Event)
+	if err != nil {
+		return WebhookDeadLetter{}, err
+	}
+	err = ws.withTx(ctx, func(tx *sql.Tx) error {
+		if err := requireWebhook(ctx, tx, dl.WebhookID); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO webhook_dead_letters (delivery_id, webhook_id, event_json, attempts, last_error, failed_at)
+			 VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+			dl.DeliveryID, dl.WebhookID, string(event), dl.Attempts, dl.LastError, dl.FailedAt.UnixNano(),
+		).Scan(&dl.ID)
+		if err != nil {
+			return mapSQLError(err)
+		}
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM webhook_dead_letters WHERE webhook_id = ? AND id <= (
+			     SELECT id FROM webhook_dead_letters WHERE webhook_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?)`,
+			dl.WebhookID, dl.WebhookID, maxWebhookDeadLetters)
+		return mapSQLError(err)
+	})
+	if err != nil {
+		return WebhookDeadLetter{}, err
+	}
+	return dl, nil
+}
+
+// ListDeadLetters mengembalikan dead letter webhook id, yang terbaru lebih dulu.
+func (ws *sqlWebhookStore) ListDeadLetters(ctx context.Context, id int) ([]WebhookDeadLetter, error) {
+	if err := requireWebhook(ctx, ws.db, id); err != nil {
+		return nil, err
+	}
+	rows, err := ws.db.QueryContext(ctx,
+		`SELECT `+deadLetterColumns+` FROM webhook_dead_letters WHERE webhook_id = ? ORDER BY id DESC`, id)
+	if err != nil {
+		return nil, mapSQLError(err)
+	}
+	defer rows.Close()
+
+	deadLetters := []WebhookDeadLetter{}
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, mapSQLError(err)
+		}
+		deadLetters = append(deadLetters, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapSQLError(err)
+	}
+	return deadLetters, nil
+}
@@ -0,0 +1,310 @@
+package model
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		webhook   Webhook
+		wantCodes []string
+	}{
+		{"valid", Webhook{URL: "https://example.com/hook", Events: []string{ChangeCreated}}, nil},
+		{"missing url", Webhook{}, []string{RuleRequired}},
+		{"relative url", Webhook{URL: "/hook"}, []string{RuleURL}},
+		{"unsupported scheme", Webhook{URL: "ftp://example.com"}, []string{RuleURL}},
+		{"unknown event", Webhook{URL: "http://example.com", Events: []string{"created", "renamed"}}, []string{RuleOneOf}},
+		{"short secret", Webhook{URL: "http://example.com", Secret: "short"}, []string{RuleMinLength}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.webhook.Validate()
+			var verr *ValidationError
+			if tt.wantCodes == nil {
+				if err != nil {
+					t.Fatalf("Expected valid webhook, got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &verr) || len(verr.Fields) != len(tt.wantCodes) {
+				t.Fatalf("Expected codes %v, got %v", tt.wantCodes, err)
+			}
+			for i, code := range tt.wantCodes {
+				if verr.Fields[i].Code != code {
+					t.Errorf("Field %d: expected code %s, got %+v", i, code, verr.Fields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookStore(t *testing.T) {
+	runWebhookStoreTests(t, NewWebhookStore())
+}
+
+// runWebhookStoreTests memastikan perilaku WebhookStore sama di semua implementasi.
+func runWebhookStoreTests(t *testing.T, ws WebhookStore) {
+	created, err := ws.AddWebhook(ctx, Webhook{URL: "https://example.com/hook", Active: true})
+	if err != nil {
+		t.Fatalf("Failed to add webhook: %v", err)
+	}
+	if created.ID != 1 || len(created.Secret) < MinWebhookSecretLength || created.CreatedAt.IsZero() {
+		t.Errorf("Expected ID, generated secret and timestamps, got %+v", created)
+	}
+
+	updated, err := ws.UpdateWebhook(ctx, created.ID, Webhook{URL: "https://example.com/v2", Events: []string{ChangeDeleted}})
+	if err != nil {
+		t.Fatalf("Failed to update webhook: %v", err)
+	}
+	if updated.Secret != created.Secret || updated.Active || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Expected secret and created_at to be kept, got %+v", updated)
+	}
+	if got, _ := ws.GetWebhook(ctx, created.ID); got.URL != "https://example.com/v2" {
+		t.Errorf("Expected updated URL, got %+v", got)
+	}
+
+	for i := 0; i < maxWebhookDeliveries+5; i++ {
+		ws.RecordDelivery(ctx, WebhookDelivery{WebhookID: created.ID, Attempt: i + 1})
+	}
+	deliveries, _ := ws.ListDeliveries(ctx, created.ID)
+	if len(deliveries) != maxWebhookDeliveries || deliveries[0].Attempt != maxWebhookDeliveries+5 {
+		t.Errorf("Expected %d newest deliveries first, got %d starting at %+v", maxWebhookDeliveries, len(deliveries), deliveries[0])
+	}
+
+	if err := ws.DeleteWebhook(ctx, created.ID); err != nil {
+		t.Fatalf("Failed to delete webhook: %v", err)
+	}
+	if _, err := ws.GetWebhook(ctx, created.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("Expected ErrWebhookNotFound, got %v", err)
+	}
+	if _, err := ws.ListDeadLetters(ctx, created.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("Expected ErrWebhookNotFound for dead letters, got %v", err)
+	}
+	if _, err := ws.AddWebhook(ctx, Webhook{URL: "nope"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+}
+
+// runWebhookRestartTests memastikan webhook beserta delivery log dan dead letter-nya
+// tetap ada setelah store dibuka ulang.
+func runWebhookRestartTests(t *testing.T, open func() ClosableBookStore) {
+	store := open()
+	ws := store.(WebhookProvider).Webhooks()
+	created, err := ws.AddWebhook(ctx, Webhook{URL: "https://example.com/hook", Events: []string{ChangeCreated}, Active: true})
+	if err != nil {
+		t.Fatalf("Failed to add webhook: %v", err)
+	}
+	if _, err := ws.RecordDelivery(ctx, WebhookDelivery{WebhookID: created.ID, DeliveryID: "e-1", EventSeq: 1, EventType: ChangeCreated, Attempt: 1, Succeeded: true, At: now()}); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+	event := ChangeEvent{ID: "e-2", Seq: 2, Type: ChangeCreated, BookID: 7, At: now()}
+	if _, err := ws.AddDeadLetter(ctx, WebhookDeadLetter{WebhookID: created.ID, DeliveryID: "e-2-1", Event: event, Attempts: 3, LastError: "boom", FailedAt: now()}); err != nil {
+		t.Fatalf("Failed to add dead letter: %v", err)
+	}
+	store.Close()
+
+	store = open()
+	defer store.Close()
+	ws = store.(WebhookProvider).Webhooks()
+	got, err := ws.GetWebhook(ctx, created.ID)
+	if err != nil || got.URL != created.URL || got.Secret != created.Secret || len(got.Events) != 1 || !got.Active {
+		t.Fatalf("Expected webhook %+v after restart, got %+v (%v)", created, got, err)
+	}
+	if deliveries, _ := ws.ListDeliveries(ctx, created.ID); len(deliveries) != 1 || deliveries[0].DeliveryID != "e-1" {
+		t.Errorf("Expected delivery log after restart, got %+v", deliveries)
+	}
+	if dead, _ := ws.ListDeadLetters(ctx, created.ID); len(dead) != 1 || dead[0].Event.ID != "e-2" || dead[0].Event.BookID != 7 {
+		t.Errorf("Expected dead letter after restart, got %+v", dead)
+	}
+	if next, err := ws.AddWebhook(ctx, Webhook{URL: "https://example.com/other"}); err != nil || next.ID != created.ID+1 {
+		t.Errorf("Expected next webhook ID %d, got %+v (%v)", created.ID+1, next, err)
+	}
+}
+
+func TestWebhookStoreRollsBackFailedSave(t *testing.T) {
+	ws := &webhookStore{hooks: make(map[int]*webhookEntry)}
+	ws.save = func(webhookState) error { return errInjected }
+
+	if _, err := ws.AddWebhook(ctx, Webhook{URL: "https://example.com/hook"}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected ErrUnavailable, got %v", err)
+	}
+	if hooks, _ := ws.ListWebhooks(ctx); len(hooks) != 0 {
+		t.Errorf("Expected failed add to be rolled back, got %+v", hooks)
+	}
+
+	ws.save = nil
+	if created, err := ws.AddWebhook(ctx, Webhook{URL: "https://example.com/hook"}); err != nil || created.ID != 1 {
+		t.Errorf("Expected ID 1 to be reused after rollback, got %+v (%v)", created, err)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	opts := WebhookDispatcherOptions{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}.withDefaults()
+	for n, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := opts.backoff(n); got != want {
+			t.Errorf("backoff(%d): expected %v, got %v", n, want, got)
+		}
+	}
+}
+
+// webhookReceiver adalah penerima webhook untuk test yang mencatat setiap request.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	// status mengembalikan status response untuk request ke-n (mulai dari 1).
+	status func(n int) int
+	got    chan struct{}
+}
+
+func newWebhookReceiver(status func(n int) int) (*webhookReceiver, *httptest.Server) {
+	rcv := &webhookReceiver{status: status, got: make(chan struct{}, 100)}
+	return rcv, httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		rcv.requests = append(rcv.requests, r)
+		rcv.bodies = append(rcv.bodies, body)
+		n := len(rcv.requests)
+		rcv.mu.Unlock()
+		w.WriteHeader(rcv.status(n))
+		rcv.got <- struct{}{}
+	}))
+}
+
+// wait menunggu sampai receiver menerima n request.
+func (rcv *webhookReceiver) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-rcv.got:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for webhook request %d", i+1)
+		}
+	}
+}
+
+// startDispatcher menjalankan RunWebhookDispatcher untuk store sampai test selesai.
+func startDispatcher(t *testing.T, store BookStore, hooks WebhookStore, maxAttempts int) {
+	t.Helper()
+	feed := store.(ChangeNotifier).Changes()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunWebhookDispatcher(ctx, feed, hooks, WebhookDispatcherOptions{
+			MaxAttempts:    maxAttempts,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     5 * time.Millisecond,
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// Event yang terbit sebelum dispatcher berlangganan tidak dikirim.
+	waitFor(t, "dispatcher subscription", func() bool {
+		feed.mu.Lock()
+		defer feed.mu.Unlock()
+		return len(feed.subs) > 0
+	})
+}
+
+// waitFor menunggu sampai cond bernilai true.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookDispatcherDeliversSignedEvents(t *testing.T) {
+	rcv, srv := newWebhookReceiver(func(int) int { return http.StatusNoContent })
+	defer srv.Close()
+
+	store := setupStore()
+	hooks := NewWebhookStore()
+	wh, _ := hooks.AddWebhook(ctx, Webhook{URL: srv.URL, Events: []string{ChangeCreated, ChangeDeleted}, Active: true})
+	hooks.AddWebhook(ctx, Webhook{URL: srv.URL, Active: false})
+	startDispatcher(t, store, hooks, 3)
+
+	book := defaultBook(store)
+	store.UpdateBook(ctx, book.ID, Book{Title: "Updated", Author: "Go Dev", PublishedYear: 2024})
+	store.DeleteBook(ctx, book.ID, 0)
+	rcv.wait(t, 2)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	for i, typ := range []string{ChangeCreated, ChangeDeleted} {
+		r, body := rcv.requests[i], rcv.bodies[i]
+		if r.Header.Get(WebhookEventHeader) != typ || r.Header.Get(WebhookIDHeader) != strconv.Itoa(wh.ID) {
+			t.Errorf("Request %d: expected %s event for webhook %d, got headers %v", i, typ, wh.ID, r.Header)
+		}
+		ts, _ := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+		want := SignWebhookPayload(wh.Secret, ts, body)
+		if !hmac.Equal([]byte(r.Header.Get(WebhookSignatureHeader)), []byte(want)) {
+			t.Errorf("Request %d: invalid signature %q", i, r.Header.Get(WebhookSignatureHeader))
+		}
+	}
+
+	waitFor(t, "delivery log", func() bool {
+		d, _ := hooks.ListDeliveries(ctx, wh.ID)
+		return len(d) == 2
+	})
+	deliveries, _ := hooks.ListDeliveries(ctx, wh.ID)
+	if !deliveries[0].Succeeded || deliveries[0].StatusCode != http.StatusNoContent || deliveries[0].EventType != ChangeDeleted {
+		t.Errorf("Expected successful delivery of the delete event first, got %+v", deliveries[0])
+	}
+}
+
+func TestWebhookDispatcherRetriesAndDeadLetters(t *testing.T) {
+	// Request pertama gagal lalu berhasil; semua request untuk buku kedua gagal.
+	rcv, srv := newWebhookReceiver(func(n int) int {
+		if n == 2 {
+			return http.StatusOK
+		}
+		return http.StatusServiceUnavailable
+	})
+	defer srv.Close()
+
+	store := setupStore()
+	hooks := NewWebhookStore()
+	wh, _ := hooks.AddWebhook(ctx, Webhook{URL: srv.URL, Active: true})
+	startDispatcher(t, store, hooks, 3)
+
+	defaultBook(store)
+	rcv.wait(t, 2)
+	second := createBook(store, "Second", "Go Dev", 2024)
+	rcv.wait(t, 3)
+
+	waitFor(t, "dead letter", func() bool {
+		dl, _ := hooks.ListDeadLetters(ctx, wh.ID)
+		return len(dl) == 1
+	})
+	dead, _ := hooks.ListDeadLetters(ctx, wh.ID)
+	if dead[0].Event.BookID != second.ID || dead[0].Attempts != 3 || dead[0].LastError == "" {
+		t.Errorf("Expected second book to be dead-lettered after 3 attempts, got %+v", dead[0])
+	}
+
+	deliveries, _ := hooks.ListDeliveries(ctx, wh.ID)
+	if len(deliveries) != 5 {
+		t.Fatalf("Expected 5 attempts in the delivery log, got %+v", deliveries)
+	}
+	first := deliveries[3:]
+	if first[1].Attempt != 1 || first[1].Succeeded || first[0].Attempt != 2 || !first[0].Succeeded || first[0].DeliveryID != first[1].DeliveryID {
+		t.Errorf("Expected a failed attempt followed by a successful retry, got %+v", first)
+	}
+}
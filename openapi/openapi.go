@@ -0,0 +1,195 @@
+// Package openapi berisi tipe dokumen OpenAPI 3.1 dan generator schema dari tipe Go,
+// dipakai untuk mendeskripsikan API book-api.
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Version adalah versi spesifikasi OpenAPI yang dipakai dokumen.
+const Version = "3.1.0"
+
+// Document adalah akar dokumen OpenAPI.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info berisi metadata API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components berisi schema, response, dan skema keamanan yang dirujuk dari operasi.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme adalah cara autentikasi, misalnya token Bearer.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem berisi operasi untuk satu path.
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+}
+
+// Operation mengembalikan operasi untuk method HTTP, atau nil jika tidak ada.
+func (p *PathItem) Operation(method string) *Operation {
+	if p == nil {
+		return nil
+	}
+	switch strings.ToUpper(method) {
+	case "GET":
+		return p.Get
+	case "PUT":
+		return p.Put
+	case "POST":
+		return p.Post
+	case "DELETE":
+		return p.Delete
+	case "PATCH":
+		return p.Patch
+	}
+	return nil
+}
+
+// SetOperation mengisi operasi untuk method HTTP. Method lain diabaikan.
+func (p *PathItem) SetOperation(method string, op *Operation) {
+	switch strings.ToUpper(method) {
+	case "GET":
+		p.Get = op
+	case "PUT":
+		p.Put = op
+	case "POST":
+		p.Post = op
+	case "DELETE":
+		p.Delete = op
+	case "PATCH":
+		p.Patch = op
+	}
+}
+
+// Operation adalah satu endpoint (method + path).
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security berisi nama SecurityScheme yang bisa dipakai operasi ini.
+	Security []map[string][]string `json:"security,omitempty"`
+	// Deprecated menandai operasi yang masih dilayani tetapi akan dihapus.
+	Deprecated bool `json:"deprecated,omitempty"`
+}
+
+// Parameter adalah parameter path, query, atau header.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody adalah body request beserta media type yang diterima.
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType berisi schema untuk satu media type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Response adalah response untuk satu kode status, atau $ref ke components.responses.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header adalah header response.
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Schema adalah JSON Schema (dialek OpenAPI 3.1) yang dipakai dokumen ini.
+type Schema struct {
+	Ref  string `json:"$ref,omitempty"`
+	Type string `json:"-"`
+	// Nullable menambahkan "null" ke type, ditulis sebagai type: [<Type>, "null"].
+	Nullable             bool               `json:"-"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+}
+
+// MarshalJSON menulis Type dan Nullable sebagai "type" sesuai OpenAPI 3.1.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	out := struct {
+		Type interface{} `json:"type,omitempty"`
+		plain
+	}{plain: plain(s)}
+	switch {
+	case s.Type != "" && s.Nullable:
+		out.Type = []string{s.Type, "null"}
+	case s.Type != "":
+		out.Type = s.Type
+	}
+	return json.Marshal(out)
+}
+
+// Ref membuat schema $ref ke components.schemas[name].
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// RefName mengembalikan nama schema dari $ref "#/components/schemas/<name>".
+func RefName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+// Int mengembalikan pointer ke n, untuk field seperti Schema.MinLength.
+func Int(n int) *int {
+	return &n
+}
+
+// Float mengembalikan pointer ke f, untuk field seperti Schema.Minimum.
+func Float(f float64) *float64 {
+	return &f
+}
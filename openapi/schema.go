@@ -0,0 +1,145 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Generator membuat Schema dari tipe Go mengikuti aturan encoding/json. Struct bernama
+// disimpan sekali di Schemas dan dirujuk dengan $ref.
+type Generator struct {
+	// Schemas adalah isi components.schemas yang diisi generator.
+	Schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// NewGenerator membuat Generator yang menyimpan schema struct di schemas.
+func NewGenerator(schemas map[string]*Schema) *Generator {
+	return &Generator{Schemas: schemas, names: make(map[reflect.Type]string)}
+}
+
+// Schema mengembalikan schema untuk tipe nilai v. Untuk struct bernama hasilnya adalah
+// $ref; namanya adalah nama tipe dengan huruf pertama kapital.
+func (g *Generator) Schema(v interface{}) *Schema {
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+// Named sama seperti Schema, tetapi menyimpan struct v dengan nama name. Dipakai jika
+// nama tipe Go tidak cocok sebagai nama schema atau bertabrakan dengan tipe lain.
+func (g *Generator) Named(name string, v interface{}) *Schema {
+	t := reflect.TypeOf(v)
+	g.names[t] = name
+	g.Schemas[name] = g.structSchema(t)
+	return Ref(name)
+}
+
+func (g *Generator) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{}
+	case t.Kind() != reflect.Pointer && t.Implements(marshalerType):
+		// Bentuk JSON ditentukan MarshalJSON dan tidak bisa dibaca dari field-nya.
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schemaOf(t.Elem())
+		if s.Ref != "" {
+			return &Schema{OneOf: []*Schema{s, {Type: "null"}}}
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: Float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		// Slice dan map nil ditulis encoding/json sebagai null.
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = exportedName(t.Name())
+			g.names[t] = name
+			g.Schemas[name] = g.structSchema(t)
+		}
+		return Ref(name)
+	}
+	// interface{} dan tipe lain: nilai JSON apa pun.
+	return &Schema{}
+}
+
+// structSchema membuat schema object dari field struct t. Field tanpa omitempty
+// dianggap wajib; field embedded tanpa tag json digabung ke objek induknya.
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t)
+	return s
+}
+
+func (g *Generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schemaOf(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// exportedName mengubah huruf pertama name menjadi kapital.
+func exportedName(name string) string {
+	r, n := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[n:]
+}
@@ -0,0 +1,55 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type author struct {
+	Name string `json:"name"`
+}
+
+type sample struct {
+	ID       int       `json:"id"`
+	Tags     []string  `json:"tags,omitempty"`
+	Author   *author   `json:"author"`
+	Year     *int      `json:"year,omitempty"`
+	Created  time.Time `json:"created_at"`
+	Internal string    `json:"-"`
+	embedded
+}
+
+type embedded struct {
+	Note string `json:"note,omitempty"`
+}
+
+func TestGeneratorSchema(t *testing.T) {
+	schemas := make(map[string]*Schema)
+	ref := NewGenerator(schemas).Schema(sample{})
+	if ref.Ref != "#/components/schemas/Sample" {
+		t.Fatalf("Expected $ref to Sample, got %+v", ref)
+	}
+
+	got, _ := json.Marshal(schemas["Sample"])
+	want := `{"type":"object","properties":{"author":{"oneOf":[{"$ref":"#/components/schemas/Author"},{"type":"null"}]},"created_at":{"type":"string","format":"date-time"},"id":{"type":"integer"},"note":{"type":"string"},"tags":{"type":["array","null"],"items":{"type":"string"}},"year":{"type":["integer","null"]}},"required":["id","author","created_at"]}`
+	if string(got) != want {
+		t.Errorf("Unexpected schema:\n got %s\nwant %s", got, want)
+	}
+	if _, ok := schemas["Author"]; !ok {
+		t.Error("Expected nested struct to be stored as Author")
+	}
+}
+
+func TestPathItemOperation(t *testing.T) {
+	item := &PathItem{}
+	op := &Operation{OperationID: "getThing"}
+	item.SetOperation("get", op)
+	if item.Operation("GET") != op || item.Operation("POST") != nil {
+		t.Errorf("Unexpected operations: %+v", item)
+	}
+	var missing *PathItem
+	if missing.Operation("GET") != nil {
+		t.Error("Expected nil operation for missing path")
+	}
+}
@@ -0,0 +1,307 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"mime"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"book-api/model"
+)
+
+// FindOperation mencari operasi untuk method dan path request. Segmen "{name}" pada
+// template path cocok dengan satu segmen apa pun; jika beberapa template cocok, template
+// dengan segmen literal terbanyak dipilih (contoh: /books/search sebelum /books/{id}).
+//
+// Returns:
+//   - operasi yang cocok, template path-nya, dan nilai parameter path
+//   - nil jika tidak ada operasi untuk method dan path tersebut
+func (d *Document) FindOperation(method, path string) (op *Operation, template string, params map[string]string) {
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	segments := strings.Split(path, "/")
+	best := -1
+	for tmpl, item := range d.Paths {
+		candidate := item.Operation(method)
+		if candidate == nil {
+			continue
+		}
+		literal, values, ok := matchPath(strings.Split(tmpl, "/"), segments)
+		if !ok || literal < best || (literal == best && tmpl > template) {
+			continue
+		}
+		best, op, template, params = literal, candidate, tmpl, values
+	}
+	return op, template, params
+}
+
+// matchPath mencocokkan segmen template dengan segmen path dan menghitung segmen literalnya.
+func matchPath(tmpl, segments []string) (literal int, params map[string]string, ok bool) {
+	if len(tmpl) != len(segments) {
+		return 0, nil, false
+	}
+	for i, part := range tmpl {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return 0, nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[part[1:len(part)-1]] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return 0, nil, false
+		}
+		literal++
+	}
+	return literal, params, true
+}
+
+// IsJSON melaporkan apakah media type (boleh berisi parameter seperti charset) adalah
+// application/json atau turunannya dengan akhiran +json.
+func IsJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// ParseParameter mengubah nilai string dari path, query, atau header menjadi nilai JSON
+// sesuai type pada schema, agar bisa diperiksa dengan ValidateValue.
+//
+// Returns:
+//   - nilai hasil konversi (float64, bool, atau string, sama seperti hasil json.Unmarshal)
+//   - false jika nilai tidak bisa dikonversi ke type schema
+func ParseParameter(s *Schema, value string) (interface{}, bool) {
+	switch s.Type {
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		return float64(n), err == nil
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	}
+	return value, true
+}
+
+// ValidateValue memeriksa nilai JSON v (hasil json.Unmarshal ke interface{}) terhadap
+// schema s. $ref dicari di components.schemas dokumen ini.
+//
+// Params:
+//   - s: schema yang diharapkan.
+//   - v: nilai yang diperiksa.
+//   - field: path nilai v untuk FieldError.Field, misalnya "book" atau "operations.0".
+//
+// Returns:
+//   - semua pelanggaran, dengan Code berupa konstanta model.Rule*; nil jika valid
+func (d *Document) ValidateValue(s *Schema, v interface{}, field string) []model.FieldError {
+	var errs []model.FieldError
+	d.validate(s, v, field, &errs)
+	return errs
+}
+
+func (d *Document) validate(s *Schema, v interface{}, field string, errs *[]model.FieldError) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		d.validate(d.Components.Schemas[RefName(s.Ref)], v, field, errs)
+		return
+	}
+	for _, sub := range s.AllOf {
+		d.validate(sub, v, field, errs)
+	}
+	if len(s.OneOf) > 0 {
+		d.validateOneOf(s.OneOf, v, field, errs)
+	}
+	if s.Type == "" {
+		return
+	}
+
+	label := field
+	if label == "" {
+		label = "body"
+	}
+	add := func(code, format string, args ...interface{}) {
+		*errs = append(*errs, model.FieldError{Field: label, Code: code, Message: label + " " + fmt.Sprintf(format, args...)})
+	}
+
+	if v == nil {
+		if !s.Nullable && s.Type != "null" {
+			add(model.RuleType, "must be %s, got null", article(s.Type))
+		}
+		return
+	}
+	if !hasType(s.Type, v) {
+		add(model.RuleType, "must be %s", article(s.Type))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		add(model.RuleOneOf, "must be one of %s", joinEnum(s.Enum))
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		switch {
+		case s.MinLength != nil && n < *s.MinLength && *s.MinLength == 1:
+			add(model.RuleRequired, "is required")
+		case s.MinLength != nil && n < *s.MinLength:
+			add(model.RuleMinLength, "must be at least %d characters", *s.MinLength)
+		case s.MaxLength != nil && n > *s.MaxLength:
+			add(model.RuleMaxLength, "must be at most %d characters, got %d", *s.MaxLength, n)
+		}
+		if s.Pattern != "" && !compilePattern(s.Pattern).MatchString(v) {
+			add(model.RulePattern, "must match %s", s.Pattern)
+		}
+		if !hasFormat(s.Format, v) {
+			add(model.RuleFormat, "must be a valid %s", s.Format)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			add(model.RuleMin, "must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			add(model.RuleMax, "must be at most %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			add(model.RuleMinItems, "must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			add(model.RuleMaxItems, "must contain at most %d items, got %d", *s.MaxItems, len(v))
+		}
+		for i, item := range v {
+			d.validate(s.Items, item, join(field, strconv.Itoa(i)), errs)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				f := join(field, name)
+				*errs = append(*errs, model.FieldError{Field: f, Code: model.RuleRequired, Message: f + " is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			d.validate(prop, v[name], join(field, name), errs)
+		}
+	}
+}
+
+// validateOneOf menerima v jika cocok dengan salah satu schema. Jika tidak ada yang cocok,
+// pelanggaran terhadap schema pertama yang bukan null dilaporkan.
+func (d *Document) validateOneOf(schemas []*Schema, v interface{}, field string, errs *[]model.FieldError) {
+	var first []model.FieldError
+	for _, sub := range schemas {
+		if sub.Type == "null" {
+			if v == nil {
+				return
+			}
+			continue
+		}
+		subErrs := d.ValidateValue(sub, v, field)
+		if len(subErrs) == 0 {
+			return
+		}
+		if first == nil {
+			first = subErrs
+		}
+	}
+	*errs = append(*errs, first...)
+}
+
+// hasType melaporkan apakah nilai JSON v bertipe typ.
+func hasType(typ string, v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		return typ == "string"
+	case float64:
+		return typ == "number" || (typ == "integer" && v == math.Trunc(v))
+	case bool:
+		return typ == "boolean"
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+	return false
+}
+
+// hasFormat memeriksa format string yang dipakai dokumen ini; format lain selalu dianggap valid.
+func hasFormat(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.IsAbs()
+	}
+	return true
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinEnum(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		parts[i] = fmt.Sprint(e)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// article mengembalikan nama type JSON beserta kata sandangnya untuk pesan error.
+func article(typ string) string {
+	switch typ {
+	case "integer", "array", "object":
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+// join menyambung path field dengan titik, contoh "operations" + "0" = "operations.0".
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+var patterns sync.Map
+
+// compilePattern meng-compile regex pattern sekali dan menyimpannya untuk dipakai ulang.
+func compilePattern(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+	return re
+}
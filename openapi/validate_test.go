@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"testing"
+
+	"book-api/model"
+)
+
+func TestFindOperation(t *testing.T) {
+	get := func(id string) *PathItem { return &PathItem{Get: &Operation{OperationID: id}} }
+	doc := &Document{Paths: map[string]*PathItem{
+		"/books":              get("list"),
+		"/books/{id}":         get("get"),
+		"/books/search":       get("search"),
+		"/books/{id}/h/{rev}": get("rev"),
+	}}
+
+	tests := []struct {
+		method, path, wantID string
+		wantParams           map[string]string
+	}{
+		{"GET", "/books/", "list", nil},
+		{"GET", "/books/search", "search", nil},
+		{"GET", "/books/7", "get", map[string]string{"id": "7"}},
+		{"GET", "/books/7/h/2", "rev", map[string]string{"id": "7", "rev": "2"}},
+		{"POST", "/books", "", nil},
+		{"GET", "/nope", "", nil},
+	}
+	for _, tt := range tests {
+		op, _, params := doc.FindOperation(tt.method, tt.path)
+		if (op == nil && tt.wantID != "") || (op != nil && op.OperationID != tt.wantID) {
+			t.Errorf("%s %s: expected %q, got %+v", tt.method, tt.path, tt.wantID, op)
+			continue
+		}
+		for k, v := range tt.wantParams {
+			if params[k] != v {
+				t.Errorf("%s %s: expected param %s=%s, got %v", tt.method, tt.path, k, v, params)
+			}
+		}
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	doc := &Document{Components: Components{Schemas: map[string]*Schema{
+		"Op": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"op":   {Type: "string", Enum: []interface{}{"create", "delete"}},
+				"note": {Type: "string", Nullable: true},
+			},
+			Required: []string{"op"},
+		},
+	}}}
+	list := &Schema{Type: "array", Items: &Schema{OneOf: []*Schema{Ref("Op"), {Type: "null"}}}, MaxItems: Int(3)}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []model.FieldError
+	}{
+		{"valid", []interface{}{map[string]interface{}{"op": "create", "note": nil}, nil}, nil},
+		{"wrong type", "create", []model.FieldError{{Field: "ops", Code: model.RuleType}}},
+		{"nested", []interface{}{map[string]interface{}{"op": "rename"}, map[string]interface{}{}}, []model.FieldError{
+			{Field: "ops.0.op", Code: model.RuleOneOf},
+			{Field: "ops.1.op", Code: model.RuleRequired},
+		}},
+		{"too many", []interface{}{nil, nil, nil, nil}, []model.FieldError{{Field: "ops", Code: model.RuleMaxItems}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := doc.ValidateValue(list, tt.value, "ops")
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %+v, got %+v", tt.want, got)
+			}
+			for i, want := range tt.want {
+				if got[i].Field != want.Field || got[i].Code != want.Code {
+					t.Errorf("Violation %d: expected %+v, got %+v", i, want, got[i])
+				}
+			}
+		})
+	}
+}
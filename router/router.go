@@ -2,6 +2,7 @@ package router
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -9,29 +10,165 @@ import (
 	"book-api/handler"
 	middleware2 "book-api/middleware"
 	"book-api/model"
+	"book-api/utils"
 )
 
 // SetupRouter mengatur dan mengembalikan konfigurasi HTTP router utama.
-// Fungsi ini menggunakan chi router dan menambahkan middleware serta route untuk resource /books.
+// Fungsi ini menggunakan chi router dan menambahkan middleware serta route untuk resource
+// /books dan /webhooks di bawah /v1 dan /v2. Path tanpa prefix versi dilayani versi dari
+// header API-Version (default v1).
+// Data buku disimpan di memori; gunakan SetupRouterWithStore untuk memilih store lain.
 func SetupRouter() http.Handler {
+	return SetupRouterWithStore(model.NewBookStore())
+}
+
+// SetupRouterWithStore sama seperti SetupRouter, tetapi memakai BookStore yang diberikan
+// (misalnya store berbasis file dari model.NewFileBookStore) dan opsi handler tambahan.
+func SetupRouterWithStore(bookService model.BookStore, opts ...handler.Option) http.Handler {
+	return setupRouter(bookService, false, opts...)
+}
+
+// SetupDevRouterWithStore sama seperti SetupRouterWithStore, tetapi setiap response
+// diperiksa terhadap dokumen OpenAPI dan pelanggarannya dicatat ke log (mode -dev).
+// Pemeriksaan dipasang setelah negotiateVersion sehingga response untuk path tanpa
+// prefix versi (contoh /books/1) ikut diperiksa terhadap operasi /v<N> yang melayaninya.
+func SetupDevRouterWithStore(bookService model.BookStore, opts ...handler.Option) http.Handler {
+	return setupRouter(bookService, true, opts...)
+}
+
+func setupRouter(bookService model.BookStore, validateResponses bool, opts ...handler.Option) http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Recoverer)
+	r.Use(middleware2.RecovererMiddleware)
 	r.Use(middleware.Logger)
 	r.Use(middleware2.LoggerMiddleware)
+	r.Use(middleware2.AuditMiddleware)
+	r.Use(negotiateVersion)
+	if validateResponses {
+		r.Use(middleware2.ResponseValidationMiddleware(handler.OpenAPISpec()))
+	}
+	r.Use(middleware2.RequestValidationMiddleware(handler.OpenAPISpec()))
 
-	bookService := model.NewBookStore()
-	bookHandler := handler.NewBookHandler(bookService)
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, r, http.StatusNotFound, "route not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(allowedMethods(r), ", "))
+		utils.WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	})
 
-	r.Route("/books", func(r chi.Router) {
+	bookHandler := handler.NewBookHandler(bookService, opts...)
+
+	r.Get("/openapi.json", handler.OpenAPIHandler)
+	r.Get("/docs", handler.DocsHandler)
+
+	r.Route(handler.APIVersion1.Prefix(), func(r chi.Router) {
+		r.Use(bookHandler.VersionMiddleware(handler.APIVersion1))
+		r.Route("/books", func(r chi.Router) {
+			mountBookRoutes(r, bookHandler)
+		})
+		r.Route("/webhooks", func(r chi.Router) { mountWebhookRoutes(r, bookHandler) })
+	})
+	r.Route(handler.APIVersion2.Prefix(), func(r chi.Router) {
+		r.Use(bookHandler.VersionMiddleware(handler.APIVersion2))
+		r.Route("/books", func(r chi.Router) { mountBookRoutes(r, bookHandler) })
+		r.Route("/webhooks", func(r chi.Router) { mountWebhookRoutes(r, bookHandler) })
+	})
+
+	r.Post("/graphql", bookHandler.GraphQLHandler)
+
+	return r
+}
+
+// mountBookRoutes mendaftarkan route /books; semua route ada di setiap versi. Export
+// dan change feed memakai format sendiri sehingga tidak melalui content negotiation.
+func mountBookRoutes(r chi.Router, bookHandler handler.BookHandler) {
+	r.Get("/export", bookHandler.ExportBooksHandler)
+	r.Get("/events", bookHandler.BookEventsHandler)
+	r.Get("/events/ws", bookHandler.BookEventsWebSocketHandler)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware2.ContentNegotiationMiddleware)
 		r.Get("/", bookHandler.GetBooksHandler)
+		r.Get("/search", bookHandler.SearchBooksHandler)
+		r.Get("/trash", bookHandler.ListTrashHandler)
 		r.Get("/{id}", bookHandler.GetBookHandler)
 		r.Post("/", bookHandler.CreateBookHandler)
 		r.Put("/{id}", bookHandler.UpdateBookHandler)
+		r.Patch("/{id}", bookHandler.PatchBookHandler)
 		r.Delete("/{id}", bookHandler.DeleteBookHandler)
+		r.Post("/{id}/restore", bookHandler.RestoreBookHandler)
+		r.Post("/batch", bookHandler.BatchBooksHandler)
+		r.Post("/import", bookHandler.ImportBooksHandler)
+		r.Get("/{id}/history", bookHandler.GetBookHistoryHandler)
+		r.Get("/{id}/history/diff", bookHandler.DiffBookRevisionsHandler)
+		r.Get("/{id}/history/{rev}", bookHandler.GetBookRevisionHandler)
 	})
+}
 
-	return r
+// mountWebhookRoutes mendaftarkan route /webhooks; bentuknya sama di semua versi.
+func mountWebhookRoutes(r chi.Router, bookHandler handler.BookHandler) {
+	r.Use(middleware2.ContentNegotiationMiddleware)
+	r.Get("/", bookHandler.ListWebhooksHandler)
+	r.Post("/", bookHandler.CreateWebhookHandler)
+	r.Get("/{id}", bookHandler.GetWebhookHandler)
+	r.Put("/{id}", bookHandler.UpdateWebhookHandler)
+	r.Delete("/{id}", bookHandler.DeleteWebhookHandler)
+	r.Get("/{id}/deliveries", bookHandler.ListWebhookDeliveriesHandler)
+	r.Get("/{id}/dead-letters", bookHandler.ListWebhookDeadLettersHandler)
+}
+
+// versionedResources adalah prefix path yang ada di bawah /v1 dan /v2.
+var versionedResources = []string{"/books", "/webhooks"}
+
+// negotiateVersion mengarahkan request ke path tanpa prefix versi (contoh /books/1) ke
+// /v<N>/books/1 sesuai header API-Version; tanpa header dipakai v1. Nilai header yang
+// tidak didukung dijawab 400. Path yang sudah memakai prefix versi tidak terpengaruh.
+func negotiateVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isVersionedResource(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", handler.APIVersionHeader)
+		version := handler.APIVersion1
+		if value := r.Header.Get(handler.APIVersionHeader); value != "" {
+			v, ok := handler.ParseAPIVersion(value)
+			if !ok {
+				utils.WriteError(w, r, http.StatusBadRequest, "unsupported API version")
+				return
+			}
+			version = v
+		}
+
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = version.Prefix() + r.URL.Path
+		r2.URL.RawPath = ""
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// isVersionedResource melaporkan apakah path adalah resource berversi tanpa prefix versi.
+func isVersionedResource(path string) bool {
+	for _, prefix := range versionedResources {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedMethods mengembalikan method yang memiliki route untuk path request,
+// untuk mengisi header Allow pada response 405.
+func allowedMethods(r *http.Request) []string {
+	rctx := chi.RouteContext(r.Context())
+	var methods []string
+	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions} {
+		if rctx.Routes.Match(chi.NewRouteContext(), m, r.URL.Path) {
+			methods = append(methods, m)
+		}
+	}
+	return methods
 }
@@ -1,10 +1,23 @@
 package router
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
+
+	"book-api/handler"
+	"book-api/middleware"
+	"book-api/model"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func TestRouterRoutes(t *testing.T) {
@@ -21,6 +34,7 @@ func TestRouterRoutes(t *testing.T) {
 		{"POST /books", http.MethodPost, "/books", `{"title":"Go","author":"Riki","published_year":2024}`, http.StatusCreated},
 		{"PUT /books/1", http.MethodPut, "/books/1", `{"title":"Updated","author":"Updated","published_year":2024}`, http.StatusOK},
 		{"GET /books/1", http.MethodGet, "/books/1", "", http.StatusOK},
+		{"GET /books/search", http.MethodGet, "/books/search?q=upd", "", http.StatusOK},
 		{"DELETE /books/1", http.MethodDelete, "/books/1", "", http.StatusOK},
 	}
 
@@ -38,3 +52,611 @@ func TestRouterRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestRouterPatch(t *testing.T) {
+	router := SetupRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(`{"title":"Go","author":"Riki","published_year":2024}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodPatch, "/books/1", strings.NewReader(`{"title":"Patched"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Errorf("unexpected status: got %v, want %v", res.Code, http.StatusOK)
+	}
+}
+
+func TestRouterBatch(t *testing.T) {
+	router := SetupRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/books/batch?atomic=true", strings.NewReader(
+		`{"operations":[{"op":"create","book":{"title":"Go","author":"Riki","published_year":2024}}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Errorf("unexpected status: got %v, want %v", res.Code, http.StatusOK)
+	}
+}
+
+func TestRouterImportExport(t *testing.T) {
+	router := SetupRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/books/import", strings.NewReader("title,author,published_year\nGo,Riki,2024\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Errorf("import: unexpected status: got %v, want %v", res.Code, http.StatusOK)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/books/export?format=csv", nil))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "Go,Riki,2024") {
+		t.Errorf("export: unexpected response: %v %q", res.Code, res.Body.String())
+	}
+}
+
+func TestRouterHistoryRecordsActor(t *testing.T) {
+	router := SetupRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(`{"title":"Go","author":"Riki","published_year":2024}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Actor", "alice")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/books/1/history", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %v, want %v", res.Code, http.StatusOK)
+	}
+	if body := res.Body.String(); !strings.Contains(body, `"actor":"alice"`) || !strings.Contains(body, `"request_id":"`) {
+		t.Errorf("expected actor and request ID in history, got %s", body)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/books/1/history/diff?from=1&to=1", nil))
+	if res.Code != http.StatusOK {
+		t.Errorf("diff: unexpected status: got %v, want %v", res.Code, http.StatusOK)
+	}
+}
+
+func TestRouterTrash(t *testing.T) {
+	router := SetupRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(`{"title":"Go","author":"Riki","published_year":2024}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/books/1", nil))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/books/trash", nil))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"deleted_at":"`) {
+		t.Errorf("trash: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/books/1/restore", nil))
+	if res.Code != http.StatusOK {
+		t.Errorf("restore: unexpected status: got %v, want %v", res.Code, http.StatusOK)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodDelete, "/books/1?hard=true", nil))
+	if res.Code != http.StatusForbidden {
+		t.Errorf("hard delete without admin token: got %v, want %v", res.Code, http.StatusForbidden)
+	}
+}
+
+func TestRouterEvents(t *testing.T) {
+	srv := httptest.NewServer(SetupRouter())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/books/events/ws")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("websocket without upgrade: got %v, want %v", res.StatusCode, http.StatusBadRequest)
+	}
+
+	// Stream SSE harus tetap bisa di-flush melewati semua middleware.
+	res, err = http.Get(srv.URL + "/books/events")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+	line, _ := bufio.NewReader(res.Body).ReadString('\n')
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(line, "retry: ") {
+		t.Errorf("events: unexpected response: %v %q", res.StatusCode, line)
+	}
+}
+
+func TestRouterWebhooks(t *testing.T) {
+	router := SetupRouterWithStore(model.NewBookStore(), handler.WithWebhookStore(model.NewWebhookStore()))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://example.com/hook"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create: got %v, want %v: %s", res.Code, http.StatusCreated, res.Body.String())
+	}
+
+	for _, path := range []string{"/webhooks", "/webhooks/1", "/webhooks/1/deliveries", "/webhooks/1/dead-letters"} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		if res.Code != http.StatusOK {
+			t.Errorf("GET %s: got %v, want %v", path, res.Code, http.StatusOK)
+		}
+	}
+
+	res = httptest.NewRecorder()
+	SetupRouter().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+	if res.Code != http.StatusNotImplemented {
+		t.Errorf("without webhook store: got %v, want %v", res.Code, http.StatusNotImplemented)
+	}
+}
+
+func TestRouterVersioning(t *testing.T) {
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	router := SetupRouterWithStore(model.NewBookStore(), handler.WithV1Sunset(sunset))
+	do := func(method, path, version, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if version != "" {
+			req.Header.Set(handler.APIVersionHeader, version)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	res := do(http.MethodPost, "/v2/books", "", `{"title":"Go","author":{"name":"Riki"},"publication":{"year":2024}}`)
+	if res.Code != http.StatusCreated || !strings.Contains(res.Body.String(), `"author":{"name":"Riki"},"publication":{"year":2024},"isbn":null`) {
+		t.Fatalf("create v2: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+	if res.Header().Get(handler.APIVersionHeader) != "2" || res.Header().Get("Deprecation") != "" {
+		t.Errorf("v2 headers: %v", res.Header())
+	}
+
+	res = do(http.MethodGet, "/books/1", "", "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"author":"Riki","published_year":2024`) {
+		t.Errorf("default version: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+	h := res.Header()
+	if h.Get(handler.APIVersionHeader) != "1" || h.Get("Deprecation") == "" || h.Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" ||
+		h.Get("Link") != `</v2/books>; rel="successor-version"` || h.Get("Vary") != handler.APIVersionHeader {
+		t.Errorf("v1 headers: %v", h)
+	}
+
+	res = do(http.MethodGet, "/books/1", "v2", "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"publication":{"year":2024}`) {
+		t.Errorf("API-Version v2: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+	res = do(http.MethodGet, "/v1/books/1", "2", "")
+	if res.Header().Get(handler.APIVersionHeader) != "1" {
+		t.Errorf("versioned path must ignore API-Version, got version %q", res.Header().Get(handler.APIVersionHeader))
+	}
+
+	if res := do(http.MethodGet, "/books", "3", ""); res.Code != http.StatusBadRequest {
+		t.Errorf("unsupported version: got %v, want %v", res.Code, http.StatusBadRequest)
+	}
+	res = do(http.MethodGet, "/v2/books/1/history", "", "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"after":{"id":1,"title":"Go","author":{"name":"Riki"}`) ||
+		!strings.Contains(res.Body.String(), `"field":"publication.year"`) {
+		t.Errorf("v2 history: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+	res = do(http.MethodGet, "/v2/books/export?format=csv", "", "")
+	if res.Code != http.StatusOK || !strings.HasPrefix(res.Body.String(), "id,title,author.name,publication.year,isbn,version,updated_at\n") {
+		t.Errorf("v2 export: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+	res = do(http.MethodPost, "/v2/books/batch", "", `{"operations":[{"op":"create","book":{"title":"Rust","author":{"name":"Riki"},"publication":{"year":9999}}}]}`)
+	if res.Code != http.StatusMultiStatus || !strings.Contains(res.Body.String(), `"field":"book.publication.year","code":"not_future","message":"publication.year `) {
+		t.Errorf("v2 batch: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+
+	res = do(http.MethodPut, "/v2/books/1", "", `{"title":"Go","author":{"name":"Riki"},"publication":{"year":9999}}`)
+	var body struct {
+		Details []model.FieldError `json:"details"`
+	}
+	json.NewDecoder(res.Body).Decode(&body)
+	if res.Code != http.StatusBadRequest || len(body.Details) != 1 || body.Details[0].Field != "publication.year" ||
+		!strings.HasPrefix(body.Details[0].Message, "publication.year ") {
+		t.Errorf("v2 validation: got %v with details %+v", res.Code, body.Details)
+	}
+}
+
+func TestRouterIdempotencyKeyScopedByEndpointAndVersion(t *testing.T) {
+	router := SetupRouter()
+	do := func(path, version, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "shared-key")
+		if version != "" {
+			req.Header.Set(handler.APIVersionHeader, version)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	v1Body := `{"title":"Go","author":"Riki","published_year":2024}`
+	v2Body := `{"title":"Go","author":{"name":"Riki"},"publication":{"year":2024}}`
+	batchBody := `{"operations":[{"op":"delete","id":1}]}`
+
+	if res := do("/books", "", v1Body); res.Code != http.StatusCreated {
+		t.Fatalf("v1 create: unexpected status: got %v, want %v", res.Code, http.StatusCreated)
+	}
+	if res := do("/books", "2", v2Body); res.Code != http.StatusCreated || res.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("v2 create with same key: expected fresh 201, got %v %v", res.Code, res.Header())
+	}
+	if res := do("/books/batch", "", batchBody); res.Code != http.StatusMultiStatus || res.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("batch with same key: expected fresh 207, got %v %q", res.Code, res.Body.String())
+	}
+	if res := do("/v1/books", "", v1Body); res.Code != http.StatusCreated || res.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("/v1/books retry: expected replayed 201, got %v %v", res.Code, res.Header())
+	}
+	if res := do("/books/batch?atomic=true", "", batchBody); res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("batch retry with different query: unexpected status: got %v, want %v", res.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestRouterETagsDependOnRepresentation(t *testing.T) {
+	router := SetupRouter()
+	do := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	req := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(`{"title":"Go","author":"Riki","published_year":2024}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, path := range []string{"/books/1", "/books"} {
+		jsonTag := do(path, nil).Header().Get("ETag")
+		if res := do(path, map[string]string{"If-None-Match": jsonTag}); res.Code != http.StatusNotModified {
+			t.Errorf("%s same representation: unexpected status: got %v, want %v", path, res.Code, http.StatusNotModified)
+		}
+		variants := []map[string]string{
+			{"Accept": "text/csv"},
+			{handler.APIVersionHeader: "2"},
+			{"Accept": "application/yaml", handler.APIVersionHeader: "2"},
+		}
+		seen := map[string]bool{jsonTag: true}
+		for _, headers := range variants {
+			headers["If-None-Match"] = jsonTag
+			res := do(path, headers)
+			if res.Code != http.StatusOK {
+				t.Errorf("%s %v: unexpected status: got %v, want %v", path, headers, res.Code, http.StatusOK)
+			}
+			if tag := res.Header().Get("ETag"); seen[tag] {
+				t.Errorf("%s %v: ETag %s is shared with another representation", path, headers, tag)
+			} else {
+				seen[tag] = true
+			}
+		}
+	}
+
+	// ETag representasi lain tetap berlaku untuk If-Match karena berisi versi yang sama.
+	tag := do("/books/1", map[string]string{handler.APIVersionHeader: "2"}).Header().Get("ETag")
+	req = httptest.NewRequest(http.MethodPatch, "/books/1", strings.NewReader(`{"title":"Go 2"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", tag)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Errorf("PATCH with If-Match %s: unexpected status: got %v, want %v", tag, res.Code, http.StatusOK)
+	}
+}
+
+func TestRouterContentNegotiation(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	router := middleware.ResponseValidationMiddleware(handler.OpenAPISpec())(SetupRouterWithStore(model.NewBookStore()))
+	do := func(method, path, contentType, accept, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	res := do(http.MethodPost, "/v1/books", "application/xml", "application/xml",
+		`<book><title>1984</title><author>Orwell</author><published_year>1949</published_year></book>`)
+	if res.Code != http.StatusCreated || res.Header().Get("Content-Type") != "application/xml" ||
+		!strings.Contains(res.Body.String(), "<title>1984</title><author>Orwell</author><published_year>1949</published_year>") {
+		t.Fatalf("create XML: unexpected response: %v %v %s", res.Code, res.Header(), res.Body.String())
+	}
+
+	res = do(http.MethodPost, "/v2/books", "text/csv", "", "title,author.name,publication.year\nGo,Riki,2024\n")
+	if res.Code != http.StatusCreated || !strings.Contains(res.Body.String(), `"author":{"name":"Riki"},"publication":{"year":2024}`) {
+		t.Fatalf("create CSV: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+
+	res = do(http.MethodGet, "/v2/books", "", "text/csv", "")
+	if res.Code != http.StatusOK || res.Header().Get("Content-Type") != "text/csv; charset=utf-8" ||
+		!strings.HasPrefix(res.Body.String(), "id,title,author.name,publication.year,isbn,version,updated_at\n") {
+		t.Errorf("list CSV: unexpected response: %v %v %s", res.Code, res.Header(), res.Body.String())
+	}
+
+	res = do(http.MethodGet, "/books/2", "", "application/yaml", "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "\n  title: Go\n") ||
+		!strings.Contains(strings.Join(res.Header().Values("Vary"), ","), "Accept") {
+		t.Errorf("get YAML: unexpected response: %v %v %s", res.Code, res.Header(), res.Body.String())
+	}
+
+	res = do(http.MethodGet, "/v1/books/999", "", "application/xml", "")
+	if res.Code != http.StatusNotFound || !strings.Contains(res.Body.String(), "<error>") {
+		t.Errorf("XML error: unexpected response: %v %s", res.Code, res.Body.String())
+	}
+
+	if res := do(http.MethodGet, "/v1/books", "", "text/html", ""); res.Code != http.StatusNotAcceptable {
+		t.Errorf("unsupported Accept: got %v, want %v", res.Code, http.StatusNotAcceptable)
+	}
+	if res := do(http.MethodPost, "/v1/books", "text/plain", "", "Go"); res.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("unsupported Content-Type: got %v, want %v", res.Code, http.StatusUnsupportedMediaType)
+	}
+
+	if strings.Contains(logs.String(), "openapi:") {
+		t.Errorf("responses do not match the OpenAPI document:\n%s", logs.String())
+	}
+}
+
+func TestRouterErrorsUseProblemJSON(t *testing.T) {
+	router := SetupRouter()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"unknown route", http.MethodGet, "/nope", http.StatusNotFound},
+		{"method not allowed", http.MethodPost, "/books/1", http.StatusMethodNotAllowed},
+		{"handler error", http.MethodGet, "/books/999", http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("Accept", "application/problem+json")
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			if res.Code != tc.wantStatus {
+				t.Errorf("unexpected status: got %v, want %v", res.Code, tc.wantStatus)
+			}
+			if ct := res.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("unexpected content type: %q", ct)
+			}
+			if tc.wantStatus == http.StatusMethodNotAllowed && res.Header().Get("Allow") != "GET, PUT, PATCH, DELETE" {
+				t.Errorf("unexpected Allow header: %q", res.Header().Get("Allow"))
+			}
+			if !strings.Contains(res.Body.String(), `"instance":"`) {
+				t.Errorf("expected request ID as instance, got %s", res.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouterRoutesDocumentedInOpenAPI(t *testing.T) {
+	routes, ok := SetupRouter().(chi.Routes)
+	if !ok {
+		t.Fatal("SetupRouter does not return chi.Routes")
+	}
+	spec := handler.OpenAPISpec()
+
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		path := route
+		if path != "/" {
+			path = strings.TrimSuffix(path, "/")
+		}
+		if spec.Paths[path].Operation(method) == nil {
+			t.Errorf("route %s %s is missing from the OpenAPI document", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+
+	res := httptest.NewRecorder()
+	SetupRouter().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil || doc.OpenAPI != "3.1.0" || len(doc.Paths) != len(spec.Paths) {
+		t.Errorf("GET /openapi.json: got %v %+v (%v)", res.Code, doc.OpenAPI, err)
+	}
+
+	res = httptest.NewRecorder()
+	SetupRouter().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "/openapi.json") {
+		t.Errorf("GET /docs: got %v", res.Code)
+	}
+}
+
+func TestRouterVersionsHaveSameRoutes(t *testing.T) {
+	routes, ok := SetupRouter().(chi.Routes)
+	if !ok {
+		t.Fatal("SetupRouter does not return chi.Routes")
+	}
+
+	// Route v1 yang deprecated harus punya pengganti di v2 sebelum v1 di-sunset.
+	v1Prefix, v2Prefix := handler.APIVersion1.Prefix()+"/", handler.APIVersion2.Prefix()+"/"
+	v1, v2 := make(map[string]bool), make(map[string]bool)
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if rest, ok := strings.CutPrefix(route, v1Prefix); ok {
+			v1[method+" "+rest] = true
+		}
+		if rest, ok := strings.CutPrefix(route, v2Prefix); ok {
+			v2[method+" "+rest] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+	if len(v1) == 0 {
+		t.Fatal("no v1 routes found")
+	}
+	for route := range v1 {
+		if !v2[route] {
+			t.Errorf("route %s exists under %s but not under %s", route, v1Prefix, v2Prefix)
+		}
+	}
+}
+
+func TestRouterResponsesMatchOpenAPI(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	router := SetupDevRouterWithStore(model.NewBookStore(), handler.WithWebhookStore(model.NewWebhookStore()))
+
+	requests := []struct {
+		method, path, contentType, body string
+	}{
+		{http.MethodPost, "/v1/books", "application/json", `{"title":"Go","author":"Riki","published_year":2024}`},
+		{http.MethodPost, "/v1/books", "application/json", `{"title":"Rust","author":"Riki","published_year":2023}`},
+		{http.MethodGet, "/v1/books?limit=1", "", ""},
+		{http.MethodGet, "/v1/books/1", "", ""},
+		{http.MethodGet, "/v1/books/999", "", ""},
+		{http.MethodGet, "/v1/books/search?q=go", "", ""},
+		{http.MethodPut, "/v1/books/1", "application/json", `{"title":"Go 2","author":"Riki","published_year":2024}`},
+		{http.MethodPatch, "/v1/books/1", "application/merge-patch+json", `{"title":"Go 3"}`},
+		{http.MethodPost, "/v1/books/batch", "application/json", `{"operations":[{"op":"delete","id":2},{"op":"delete","id":99}]}`},
+		{http.MethodGet, "/v1/books/1/history", "", ""},
+		{http.MethodGet, "/v1/books/1/history/1", "", ""},
+		{http.MethodGet, "/v1/books/1/history/diff", "", ""},
+		{http.MethodPost, "/v1/books/import?dry_run=true", "application/x-ndjson", `{"title":"Zig","author":"Riki","published_year":2024}` + "\n"},
+		{http.MethodGet, "/v1/books/export?format=csv", "", ""},
+		{http.MethodDelete, "/v1/books/1", "", ""},
+		{http.MethodGet, "/v1/books/trash", "", ""},
+		{http.MethodPost, "/v1/books/1/restore", "", ""},
+		{http.MethodPost, "/v1/webhooks", "application/json", `{"url":"https://example.com/hook"}`},
+		{http.MethodGet, "/v1/webhooks", "", ""},
+		{http.MethodGet, "/v1/webhooks/1/deliveries", "", ""},
+		{http.MethodDelete, "/v1/webhooks/1", "", ""},
+		{http.MethodPost, "/v2/books", "application/json", `{"title":"Zig","author":{"name":"Riki"},"publication":{"year":2024},"isbn":null}`},
+		{http.MethodPost, "/v2/books", "application/json", `{"title":"","author":{"name":"Riki"},"publication":{"year":2024}}`},
+		{http.MethodGet, "/v2/books?author.name=Riki&sort=-publication.year", "", ""},
+		{http.MethodGet, "/v2/books/1", "", ""},
+		{http.MethodGet, "/v2/books/search?q=go", "", ""},
+		{http.MethodPut, "/v2/books/1", "application/json", `{"title":"Go 4","author":{"name":"Riki"},"publication":{"year":2024},"isbn":"978-0-306-40615-7"}`},
+		{http.MethodPatch, "/v2/books/1", "application/merge-patch+json", `{"publication":{"year":2020}}`},
+		{http.MethodDelete, "/v2/books/1", "", ""},
+		{http.MethodGet, "/v2/books/trash", "", ""},
+		{http.MethodPost, "/v2/books/1/restore", "", ""},
+		{http.MethodPost, "/v2/books/batch?atomic=true", "application/json", `{"operations":[{"op":"update","id":1,"book":{"title":"Go 5","author":{"name":"Riki"},"publication":{"year":2024}}}]}`},
+		{http.MethodPost, "/v2/books/batch", "application/json", `{"operations":[{"op":"delete","id":99}]}`},
+		{http.MethodGet, "/v2/books/1/history", "", ""},
+		{http.MethodGet, "/v2/books/1/history/1", "", ""},
+		{http.MethodGet, "/v2/books/1/history/diff", "", ""},
+		{http.MethodPost, "/v2/books/import?dry_run=true", "application/x-ndjson", `{"title":"Zig 2","author":{"name":"Riki"},"publication":{"year":2024}}` + "\n"},
+		{http.MethodGet, "/v2/books/export?format=jsonl", "", ""},
+		{http.MethodGet, "/v2/webhooks", "", ""},
+		{http.MethodPost, "/books", "application/json", `{"title":"Odin","author":"Riki","published_year":2024}`},
+		{http.MethodGet, "/books?limit=1", "", ""},
+		{http.MethodGet, "/books/2", "", ""},
+		{http.MethodGet, "/books/2/history", "", ""},
+		{http.MethodGet, "/webhooks", "", ""},
+		{http.MethodGet, "/openapi.json", "", ""},
+		{http.MethodPost, "/graphql", "application/json", `{"query":"{ books { total items { id title } } }"}`},
+		{http.MethodPost, "/graphql", "application/json", `{"query":"{ book(id: 999) { title } }"}`},
+		{http.MethodPost, "/graphql", "application/json", `{"query":"{ nope }"}`},
+	}
+	for _, tc := range requests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code >= http.StatusInternalServerError {
+			t.Errorf("%s %s: got %v: %s", tc.method, tc.path, res.Code, res.Body.String())
+		}
+	}
+
+	if strings.Contains(logs.String(), "openapi:") {
+		t.Errorf("responses do not match the OpenAPI document:\n%s", logs.String())
+	}
+}
+
+// conflictingStore menjawab GetBookByID dengan ErrConflict, status yang tidak
+// didokumentasikan untuk GET /books/{id}.
+type conflictingStore struct {
+	model.BookStore
+}
+
+func (conflictingStore) GetBookByID(ctx context.Context, id int) (model.Book, error) {
+	return model.Book{}, model.ErrConflict
+}
+
+func TestRouterValidatesResponsesOnUnprefixedPaths(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	router := SetupDevRouterWithStore(conflictingStore{model.NewBookStore()})
+	req := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+	req.Header.Set(handler.APIVersionHeader, "2")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != http.StatusConflict {
+		t.Fatalf("unexpected status: got %v, want %v", res.Code, http.StatusConflict)
+	}
+	if !strings.Contains(logs.String(), "openapi: GET /v2/books/1 (/v2/books/{id}) -> 409") {
+		t.Errorf("expected undocumented 409 on /books/1 to be logged, got:\n%s", logs.String())
+	}
+}
+
+func TestRouterValidatesRequestsAgainstOpenAPI(t *testing.T) {
+	router := SetupRouter()
+
+	tests := []struct {
+		name, method, path, body string
+		wantField                string
+	}{
+		{"path param", http.MethodGet, "/books/0", "", "path.id"},
+		{"query param", http.MethodGet, "/books?limit=abc", "", "query.limit"},
+		{"body type", http.MethodPost, "/books", `{"title":"Go","author":"Riki","published_year":"2024"}`, "published_year"},
+		{"nested body", http.MethodPost, "/books/batch", `{"operations":[{"op":"rename"}]}`, "operations.0.op"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			var body struct {
+				Details []model.FieldError `json:"details"`
+			}
+			json.NewDecoder(res.Body).Decode(&body)
+			if res.Code != http.StatusBadRequest || len(body.Details) == 0 || body.Details[0].Field != tc.wantField {
+				t.Errorf("got %v with details %+v, want 400 for %s", res.Code, body.Details, tc.wantField)
+			}
+		})
+	}
+}
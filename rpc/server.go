@@ -0,0 +1,396 @@
+// Package rpc menyediakan service gRPC BookService (lihat bookpb/book.proto) di atas
+// model.BookStore, berdampingan dengan REST API.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative bookpb/book.proto
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"book-api/middleware"
+	"book-api/model"
+	"book-api/rpc/bookpb"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// actorMetadata berisi identitas pengguna yang melakukan perubahan, sama seperti
+	// header X-Actor pada REST.
+	actorMetadata = "x-actor"
+	// requestIDMetadata berisi ID request yang dicatat pada revision buku.
+	requestIDMetadata = "x-request-id"
+)
+
+// Server mengimplementasikan bookpb.BookServiceServer.
+type Server struct {
+	bookpb.UnimplementedBookServiceServer
+	store          model.BookStore
+	requireVersion bool
+	shutdown       <-chan struct{}
+}
+
+// Option mengatur perilaku opsional Server.
+type Option func(*Server)
+
+// WithRequireVersion mewajibkan field version pada UpdateBook dan DeleteBook, seperti
+// handler.WithRequireIfMatch pada REST. Request tanpa version dijawab FailedPrecondition.
+func WithRequireVersion(required bool) Option {
+	return func(s *Server) {
+		s.requireVersion = required
+	}
+}
+
+// WithShutdown mengakhiri stream WatchBooks dengan Unavailable ketika done ditutup,
+// sehingga grpc.Server.GracefulStop tidak menunggu stream yang tidak pernah selesai sendiri.
+func WithShutdown(done <-chan struct{}) Option {
+	return func(s *Server) {
+		s.shutdown = done
+	}
+}
+
+// NewServer membuat Server di atas store dengan opsi tambahan.
+func NewServer(store model.BookStore, opts ...Option) *Server {
+	s := &Server{store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewGRPCServer membuat *grpc.Server dengan BookService terdaftar dan interceptor yang
+// menyimpan actor serta request ID dari metadata ke context (lihat model.WithAudit).
+// Server reflection ikut didaftarkan agar tool seperti grpcurl bisa dipakai tanpa file proto.
+func NewGRPCServer(store model.BookStore, opts ...Option) *grpc.Server {
+	gs := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auditUnaryInterceptor),
+		grpc.ChainStreamInterceptor(auditStreamInterceptor),
+	)
+	bookpb.RegisterBookServiceServer(gs, NewServer(store, opts...))
+	reflection.Register(gs)
+	return gs
+}
+
+// GetBook mengembalikan buku dengan ID yang diminta.
+//
+// Returns:
+//   - NotFound jika buku tidak ada
+func (s *Server) GetBook(ctx context.Context, req *bookpb.GetBookRequest) (*bookpb.Book, error) {
+	book, err := s.store.GetBookByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, storeStatus(err)
+	}
+	return toProto(book), nil
+}
+
+// ListBooks mengirim semua buku yang cocok dengan filter sesuai urutan sort (default
+// menurut ID). Buku diambil dari store per halaman model.MaxQueryLimit buku, jadi
+// koleksi besar tidak dimuat sekaligus.
+//
+// Returns:
+//   - InvalidArgument jika filter, sort, atau limit tidak valid
+func (s *Server) ListBooks(req *bookpb.ListBooksRequest, stream grpc.ServerStreamingServer[bookpb.Book]) error {
+	if req.GetLimit() < 0 {
+		return status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+	q := model.BookQuery{Limit: model.MaxQueryLimit}
+	for _, f := range req.GetFilters() {
+		q.Filters = append(q.Filters, model.Filter{Field: f.GetField(), Op: filterOps[f.GetOp()], Value: f.GetValue()})
+	}
+	for _, sf := range req.GetSort() {
+		q.Sort = append(q.Sort, model.SortField{Field: sf.GetField(), Desc: sf.GetDesc()})
+	}
+
+	remaining := int(req.GetLimit())
+	for {
+		if remaining > 0 && remaining < q.Limit {
+			q.Limit = remaining
+		}
+		nq, err := q.Normalize()
+		if err != nil {
+			return storeStatus(err)
+		}
+		page, err := s.store.QueryBooks(stream.Context(), nq)
+		if err != nil {
+			return storeStatus(err)
+		}
+		for _, book := range page.Books {
+			if err := stream.Send(toProto(book)); err != nil {
+				return err
+			}
+		}
+		if remaining > 0 {
+			remaining -= len(page.Books)
+			if remaining == 0 {
+				return nil
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// CreateBook memvalidasi dan menambahkan buku baru.
+//
+// Returns:
+//   - InvalidArgument dengan errdetails.BadRequest jika data buku tidak valid
+func (s *Server) CreateBook(ctx context.Context, req *bookpb.CreateBookRequest) (*bookpb.Book, error) {
+	book := fromInput(req.GetBook())
+	if err := book.Validate(); err != nil {
+		return nil, storeStatus(err)
+	}
+	created, err := s.store.AddBook(ctx, book)
+	if err != nil {
+		return nil, storeStatus(err)
+	}
+	return toProto(created), nil
+}
+
+// UpdateBook mengganti data buku. Field version berperan seperti header If-Match.
+//
+// Returns:
+//   - InvalidArgument jika data buku tidak valid
+//   - NotFound jika buku tidak ada
+//   - FailedPrecondition jika version berbeda dengan versi buku saat ini, atau kosong
+//     saat WithRequireVersion aktif
+func (s *Server) UpdateBook(ctx context.Context, req *bookpb.UpdateBookRequest) (*bookpb.Book, error) {
+	if err := s.checkVersion(req.GetVersion()); err != nil {
+		return nil, err
+	}
+	book := fromInput(req.GetBook())
+	if err := book.Validate(); err != nil {
+		return nil, storeStatus(err)
+	}
+	book.Version = int(req.GetVersion())
+	updated, err := s.store.UpdateBook(ctx, int(req.GetId()), book)
+	if err != nil {
+		return nil, storeStatus(err)
+	}
+	return toProto(updated), nil
+}
+
+// DeleteBook memindahkan buku ke trash.
+//
+// Returns:
+//   - NotFound jika buku tidak ada
+//   - FailedPrecondition seperti pada UpdateBook
+func (s *Server) DeleteBook(ctx context.Context, req *bookpb.DeleteBookRequest) (*emptypb.Empty, error) {
+	if err := s.checkVersion(req.GetVersion()); err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteBook(ctx, int(req.GetId()), int(req.GetVersion())); err != nil {
+		return nil, storeStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WatchBooks mengirim perubahan buku dari ChangeFeed store sampai client membatalkan
+// stream. Seperti GET /books/events, perubahan setelah last_seq yang masih ada di buffer
+// dikirim lebih dulu; jika sudah tidak tersedia atau epoch berbeda (server sudah
+// dijalankan ulang), pesan CHANGE_TYPE_RESET dikirim.
+//
+// Returns:
+//   - Unimplemented jika store tidak menerbitkan perubahan
+//   - Unavailable jika client tertinggal terlalu jauh atau server sedang dimatikan; client
+//     sebaiknya memanggil WatchBooks lagi dengan seq dan epoch terakhir yang diterimanya
+func (s *Server) WatchBooks(req *bookpb.WatchBooksRequest, stream grpc.ServerStreamingServer[bookpb.BookChange]) error {
+	notifier, ok := s.store.(model.ChangeNotifier)
+	if !ok {
+		return status.Error(codes.Unimplemented, "book store does not publish changes")
+	}
+	filter := model.ChangeFilter{Authors: req.GetAuthors()}
+	for _, id := range req.GetIds() {
+		filter.IDs = append(filter.IDs, int(id))
+	}
+
+	feed := notifier.Changes()
+	sub, backlog, resumed := feed.Subscribe(req.GetEpoch(), req.GetLastSeq(), filter)
+	defer sub.Close()
+
+	if !resumed {
+		if err := stream.Send(&bookpb.BookChange{Seq: sub.StartSeq(), Type: bookpb.ChangeType_CHANGE_TYPE_RESET, Epoch: feed.Epoch()}); err != nil {
+			return err
+		}
+	}
+	for _, ev := range backlog {
+		if err := stream.Send(toChangeProto(feed.Epoch(), ev)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-s.shutdown:
+			return status.Error(codes.Unavailable, "server is shutting down; resume with last_seq")
+		case ev, open := <-sub.Events():
+			if !open {
+				return status.Error(codes.Unavailable, "watcher fell behind; resume with last_seq")
+			}
+			if err := stream.Send(toChangeProto(feed.Epoch(), ev)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkVersion menolak version kosong jika WithRequireVersion aktif.
+func (s *Server) checkVersion(version int64) error {
+	if version == 0 && s.requireVersion {
+		return status.Error(codes.FailedPrecondition, "version is required")
+	}
+	return nil
+}
+
+// storeStatus memetakan error dari BookStore ke status gRPC, sejalan dengan pemetaan
+// ke status HTTP pada handler. Rincian validasi dikirim sebagai errdetails.BadRequest.
+func storeStatus(err error) error {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, model.ErrValidation):
+		st := status.New(codes.InvalidArgument, err.Error())
+		var verr *model.ValidationError
+		if errors.As(err, &verr) {
+			br := &errdetails.BadRequest{}
+			for _, fe := range verr.Fields {
+				br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+					Field:       fe.Field,
+					Description: fe.Message,
+					Reason:      fe.Code,
+				})
+			}
+			if detailed, derr := st.WithDetails(br); derr == nil {
+				st = detailed
+			}
+		}
+		return st.Err()
+	case errors.Is(err, model.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, model.ErrPreconditionFailed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, model.ErrUnavailable):
+		log.Printf("book store unavailable: %v", err)
+		return status.Error(codes.Unavailable, "service unavailable")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		log.Printf("book store error: %v", err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// filterOps memetakan bookpb.FilterOp ke model.FilterOp; FILTER_OP_UNSPECIFIED berarti eq.
+// Nilai yang tidak dikenal menjadi operator kosong dan ditolak oleh BookQuery.Normalize.
+var filterOps = map[bookpb.FilterOp]model.FilterOp{
+	bookpb.FilterOp_FILTER_OP_UNSPECIFIED: model.OpEq,
+	bookpb.FilterOp_FILTER_OP_EQ:          model.OpEq,
+	bookpb.FilterOp_FILTER_OP_NE:          model.OpNe,
+	bookpb.FilterOp_FILTER_OP_GT:          model.OpGt,
+	bookpb.FilterOp_FILTER_OP_GTE:         model.OpGte,
+	bookpb.FilterOp_FILTER_OP_LT:          model.OpLt,
+	bookpb.FilterOp_FILTER_OP_LTE:         model.OpLte,
+	bookpb.FilterOp_FILTER_OP_CONTAINS:    model.OpContains,
+}
+
+// changeTypes memetakan model.ChangeEvent.Type ke bookpb.ChangeType.
+var changeTypes = map[string]bookpb.ChangeType{
+	model.ChangeCreated:  bookpb.ChangeType_CHANGE_TYPE_CREATED,
+	model.ChangeUpdated:  bookpb.ChangeType_CHANGE_TYPE_UPDATED,
+	model.ChangeDeleted:  bookpb.ChangeType_CHANGE_TYPE_DELETED,
+	model.ChangeRestored: bookpb.ChangeType_CHANGE_TYPE_RESTORED,
+	model.ChangePurged:   bookpb.ChangeType_CHANGE_TYPE_PURGED,
+}
+
+// toProto mengubah model.Book menjadi pesan bookpb.Book.
+func toProto(b model.Book) *bookpb.Book {
+	return &bookpb.Book{
+		Id:            int64(b.ID),
+		Title:         b.Title,
+		Author:        b.Author,
+		PublishedYear: int32(b.PublishedYear),
+		Isbn:          b.ISBN,
+		Version:       int64(b.Version),
+		UpdatedAt:     timestamppb.New(b.UpdatedAt),
+	}
+}
+
+// toChangeProto mengubah model.ChangeEvent dari feed dengan epoch menjadi pesan bookpb.BookChange.
+func toChangeProto(epoch string, ev model.ChangeEvent) *bookpb.BookChange {
+	change := &bookpb.BookChange{
+		Seq:    ev.Seq,
+		Type:   changeTypes[ev.Type],
+		BookId: int64(ev.BookID),
+		At:     timestamppb.New(ev.At),
+		Epoch:  epoch,
+	}
+	if ev.Book != nil {
+		change.Book = toProto(*ev.Book)
+	}
+	return change
+}
+
+// fromInput mengubah bookpb.BookInput (boleh nil) menjadi model.Book tanpa ID dan versi.
+func fromInput(in *bookpb.BookInput) model.Book {
+	return model.Book{
+		Title:         in.GetTitle(),
+		Author:        in.GetAuthor(),
+		PublishedYear: int(in.GetPublishedYear()),
+		ISBN:          in.GetIsbn(),
+	}
+}
+
+// auditContext menyimpan actor dan request ID dari metadata ke context, seperti
+// middleware.AuditMiddleware pada REST.
+func auditContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	actor, err := middleware.ParseActor(firstMetadata(md, actorMetadata))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return model.WithAudit(ctx, model.Audit{Actor: actor, RequestID: firstMetadata(md, requestIDMetadata)}), nil
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func auditUnaryInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := auditContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func auditStreamInterceptor(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := auditContext(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, auditStream{ServerStream: ss, ctx: ctx})
+}
+
+// auditStream mengganti context ServerStream dengan context yang berisi audit.
+type auditStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s auditStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,310 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"book-api/model"
+	"book-api/rpc/bookpb"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient menjalankan BookService di atas listener bufconn dan mengembalikan
+// client yang tersambung ke sana.
+func newTestClient(t *testing.T, store model.BookStore, opts ...Option) bookpb.BookServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := NewGRPCServer(store, opts...)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return bookpb.NewBookServiceClient(conn)
+}
+
+func validInput(title string) *bookpb.BookInput {
+	return &bookpb.BookInput{Title: title, Author: "Author", PublishedYear: 2020}
+}
+
+// receiveAll membaca stream sampai io.EOF.
+func receiveAll[T any](t *testing.T, stream grpc.ServerStreamingClient[T]) []*T {
+	t.Helper()
+	var items []*T
+	for {
+		item, err := stream.Recv()
+		if err == io.EOF {
+			return items
+		}
+		if err != nil {
+			t.Fatalf("Unexpected stream error: %v", err)
+		}
+		items = append(items, item)
+	}
+}
+
+func TestServer_CRUD(t *testing.T) {
+	store := model.NewBookStore()
+	client := newTestClient(t, store)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), actorMetadata, "alice", requestIDMetadata, "req-1")
+
+	created, err := client.CreateBook(ctx, &bookpb.CreateBookRequest{Book: &bookpb.BookInput{
+		Title: "Go", Author: "Alan", PublishedYear: 2015, Isbn: "9780134190440",
+	}})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	if created.Id != 1 || created.Version != 1 || created.Isbn != "9780134190440" || created.UpdatedAt == nil {
+		t.Errorf("Unexpected created book: %v", created)
+	}
+
+	got, err := client.GetBook(ctx, &bookpb.GetBookRequest{Id: created.Id})
+	if err != nil {
+		t.Fatalf("GetBook failed: %v", err)
+	}
+	if got.Title != "Go" || got.Author != "Alan" || got.PublishedYear != 2015 {
+		t.Errorf("Unexpected book: %v", got)
+	}
+
+	updated, err := client.UpdateBook(ctx, &bookpb.UpdateBookRequest{Id: created.Id, Book: validInput("Go 2"), Version: created.Version})
+	if err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	if updated.Title != "Go 2" || updated.Version != 2 {
+		t.Errorf("Unexpected updated book: %v", updated)
+	}
+
+	if _, err := client.DeleteBook(ctx, &bookpb.DeleteBookRequest{Id: created.Id, Version: updated.Version}); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	if _, err := client.GetBook(ctx, &bookpb.GetBookRequest{Id: created.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound after delete, got %v", err)
+	}
+
+	history, err := store.BookHistory(context.Background(), int(created.Id))
+	if err != nil {
+		t.Fatalf("BookHistory failed: %v", err)
+	}
+	for _, rev := range history {
+		if rev.Actor != "alice" || rev.RequestID != "req-1" {
+			t.Errorf("Expected audit from metadata, got actor %q request ID %q", rev.Actor, rev.RequestID)
+		}
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	client := newTestClient(t, model.NewBookStore(), WithRequireVersion(true))
+	ctx := context.Background()
+	book, err := client.CreateBook(ctx, &bookpb.CreateBookRequest{Book: validInput("Go")})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"get missing", func() error {
+			_, err := client.GetBook(ctx, &bookpb.GetBookRequest{Id: 99})
+			return err
+		}, codes.NotFound},
+		{"create invalid", func() error {
+			_, err := client.CreateBook(ctx, &bookpb.CreateBookRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"update without version", func() error {
+			_, err := client.UpdateBook(ctx, &bookpb.UpdateBookRequest{Id: book.Id, Book: validInput("Go 2")})
+			return err
+		}, codes.FailedPrecondition},
+		{"update stale version", func() error {
+			_, err := client.UpdateBook(ctx, &bookpb.UpdateBookRequest{Id: book.Id, Book: validInput("Go 2"), Version: 5})
+			return err
+		}, codes.FailedPrecondition},
+		{"delete missing", func() error {
+			_, err := client.DeleteBook(ctx, &bookpb.DeleteBookRequest{Id: 99, Version: 1})
+			return err
+		}, codes.NotFound},
+		{"invalid actor", func() error {
+			ctx := metadata.AppendToOutgoingContext(ctx, actorMetadata, strings.Repeat("a", 101))
+			_, err := client.GetBook(ctx, &bookpb.GetBookRequest{Id: book.Id})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	_, err = client.CreateBook(ctx, &bookpb.CreateBookRequest{Book: &bookpb.BookInput{Author: "A", PublishedYear: 2020}})
+	var br *errdetails.BadRequest
+	for _, d := range status.Convert(err).Details() {
+		if d, ok := d.(*errdetails.BadRequest); ok {
+			br = d
+		}
+	}
+	if br == nil || len(br.FieldViolations) != 1 || br.FieldViolations[0].Field != "title" || br.FieldViolations[0].Reason != model.RuleRequired {
+		t.Errorf("Expected title violation in BadRequest details, got %v", br)
+	}
+}
+
+func TestStoreStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{model.ErrNotFound, codes.NotFound},
+		{model.ErrValidation, codes.InvalidArgument},
+		{model.ErrConflict, codes.AlreadyExists},
+		{model.ErrPreconditionFailed, codes.FailedPrecondition},
+		{model.ErrUnavailable, codes.Unavailable},
+		{context.Canceled, codes.Canceled},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{errors.New("boom"), codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(storeStatus(tt.err)); got != tt.want {
+			t.Errorf("storeStatus(%v): expected %v, got %v", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestServer_ListBooks(t *testing.T) {
+	store := model.NewBookStore()
+	client := newTestClient(t, store)
+	ctx := context.Background()
+	for i, title := range []string{"Alpha", "Beta", "Gamma", "Delta"} {
+		if _, err := store.AddBook(ctx, model.Book{Title: title, Author: "Author", PublishedYear: 2000 + i}); err != nil {
+			t.Fatalf("AddBook failed: %v", err)
+		}
+	}
+
+	stream, err := client.ListBooks(ctx, &bookpb.ListBooksRequest{})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	if books := receiveAll(t, stream); len(books) != 4 || books[0].Title != "Alpha" {
+		t.Errorf("Expected all 4 books in ID order, got %v", books)
+	}
+
+	stream, err = client.ListBooks(ctx, &bookpb.ListBooksRequest{
+		Filters: []*bookpb.Filter{{Field: "published_year", Op: bookpb.FilterOp_FILTER_OP_GTE, Value: "2001"}},
+		Sort:    []*bookpb.SortField{{Field: "title", Desc: true}},
+		Limit:   2,
+	})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	books := receiveAll(t, stream)
+	if len(books) != 2 || books[0].Title != "Gamma" || books[1].Title != "Delta" {
+		t.Errorf("Expected [Gamma Delta], got %v", books)
+	}
+
+	stream, err = client.ListBooks(ctx, &bookpb.ListBooksRequest{Filters: []*bookpb.Filter{{Field: "isbn", Value: "x"}}})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for unknown filter field, got %v", err)
+	}
+}
+
+func TestServer_WatchBooks(t *testing.T) {
+	store := model.NewBookStore()
+	client := newTestClient(t, store)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first, err := store.AddBook(ctx, model.Book{Title: "Go", Author: "Alan", PublishedYear: 2015})
+	if err != nil {
+		t.Fatalf("AddBook failed: %v", err)
+	}
+	if _, err := store.AddBook(ctx, model.Book{Title: "Rust", Author: "Steve", PublishedYear: 2018}); err != nil {
+		t.Fatalf("AddBook failed: %v", err)
+	}
+	if _, err := store.UpdateBook(ctx, first.ID, model.Book{Title: "Go 2", Author: "Alan", PublishedYear: 2016}); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+
+	// Resume dari seq 1: event pertama tidak dikirim ulang dan filter author membuang buku lain.
+	epoch := store.(model.ChangeNotifier).Changes().Epoch()
+	stream, err := client.WatchBooks(ctx, &bookpb.WatchBooksRequest{Authors: []string{"alan"}, LastSeq: 1, Epoch: epoch})
+	if err != nil {
+		t.Fatalf("WatchBooks failed: %v", err)
+	}
+	change, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if change.Seq != 3 || change.Epoch != epoch || change.Type != bookpb.ChangeType_CHANGE_TYPE_UPDATED || change.BookId != int64(first.ID) || change.Book.GetTitle() != "Go 2" {
+		t.Errorf("Unexpected backlog change: %v", change)
+	}
+
+	// Backlog sudah diterima, jadi langganan sudah aktif untuk perubahan berikutnya.
+	if err := store.DeleteBook(ctx, first.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	change, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if change.Seq != 4 || change.Type != bookpb.ChangeType_CHANGE_TYPE_DELETED || change.Book.GetTitle() != "Go 2" {
+		t.Errorf("Unexpected live change: %v", change)
+	}
+
+	// last_seq yang tidak dikenal, atau dari epoch lain (sebelum server dijalankan ulang),
+	// menghasilkan reset.
+	for _, req := range []*bookpb.WatchBooksRequest{{LastSeq: 100, Epoch: epoch}, {LastSeq: 2, Epoch: "stale"}} {
+		stream, err = client.WatchBooks(ctx, req)
+		if err != nil {
+			t.Fatalf("WatchBooks failed: %v", err)
+		}
+		change, err = stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if change.Type != bookpb.ChangeType_CHANGE_TYPE_RESET || change.Seq != 4 || change.Epoch != epoch {
+			t.Errorf("%v: expected reset at seq 4, got %v", req, change)
+		}
+	}
+}
+
+func TestServer_WatchBooksEndsOnShutdown(t *testing.T) {
+	store := model.NewBookStore()
+	shuttingDown := make(chan struct{})
+	client := newTestClient(t, store, WithShutdown(shuttingDown))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchBooks(ctx, &bookpb.WatchBooksRequest{})
+	if err != nil {
+		t.Fatalf("WatchBooks failed: %v", err)
+	}
+	close(shuttingDown)
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable after shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,628 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// encodeJSON menulis v sebagai JSON.
+func encodeJSON(w io.Writer, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// object adalah objek JSON yang urutan field-nya dipertahankan, agar format lain
+// menulis field dengan urutan yang sama seperti JSON.
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+// MarshalJSON menulis object sebagai objek JSON dengan urutan field yang sama.
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// toTree mengubah v menjadi pohon nilai JSON: object, []interface{}, string,
+// json.Number, bool, atau nil.
+func toTree(v interface{}) (interface{}, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	return readTree(dec)
+}
+
+func readTree(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key.(string), value})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			value, err := readTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// scalarText mengembalikan representasi teks nilai skalar pohon JSON.
+func scalarText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	buf, _ := json.Marshal(v)
+	return string(buf)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// coerce mengubah pohon nilai yang semua skalarnya berupa teks (hasil decode XML, CSV,
+// atau YAML) menurut tipe tujuan t: teks menjadi json.Number untuk field angka dan bool
+// untuk field boolean. Teks yang tidak bisa diubah dibiarkan agar DecodeStrict
+// melaporkan type mismatch beserta nama field-nya.
+func coerce(v interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if t.Kind() == reflect.Struct && t != timeType {
+			fields := jsonFields(t)
+			for key, child := range v {
+				if ft, ok := lookupField(fields, key); ok {
+					v[key] = coerce(child, ft)
+				}
+			}
+		} else if t.Kind() == reflect.Map {
+			for key, child := range v {
+				v[key] = coerce(child, t.Elem())
+			}
+		}
+		return v
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, child := range v {
+				v[i] = coerce(child, t.Elem())
+			}
+		}
+		return v
+	case string:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if _, err := strconv.ParseFloat(v, 64); err == nil {
+				return json.Number(v)
+			}
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+	}
+	return v
+}
+
+// --- XML ---
+
+// encodeXML menulis v sebagai elemen <response>. Field objek menjadi elemen anak dengan
+// nama field-nya, item array menjadi elemen <item>, dan null menjadi elemen kosong.
+// Nama field yang bukan nama elemen XML yang valid ditulis sebagai <entry key="...">.
+func encodeXML(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := writeXMLElement(enc, "response", tree); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func writeXMLElement(enc *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !isXMLName(name) {
+		start = xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}}
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case object:
+		for _, m := range v {
+			if err := writeXMLElement(enc, m.key, m.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := writeXMLElement(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	default:
+		if text := scalarText(v); text != "" {
+			if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// isXMLName melaporkan apakah name bisa dipakai sebagai nama elemen XML.
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decodeXML membaca elemen root body sebagai objek: elemen anak menjadi field, elemen
+// yang berulang atau bernama <item> menjadi array, dan teks menjadi nilai skalar.
+func decodeXML(body []byte, target reflect.Type) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			v, err := readXMLElement(dec)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(coerce(v, target))
+		}
+	}
+}
+
+// readXMLElement membaca isi elemen yang start tag-nya baru saja dibaca dec.
+func readXMLElement(dec *xml.Decoder) (interface{}, error) {
+	var text strings.Builder
+	children := make(map[string]interface{})
+	repeated := make(map[string]bool)
+	hasChildren := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			hasChildren = true
+			name := t.Name.Local
+			for _, attr := range t.Attr {
+				if name == "entry" && attr.Name.Local == "key" {
+					name = attr.Value
+				}
+			}
+			v, err := readXMLElement(dec)
+			if err != nil {
+				return nil, err
+			}
+			switch existing, ok := children[name]; {
+			case !ok:
+				children[name] = v
+			case repeated[name]:
+				children[name] = append(existing.([]interface{}), v)
+			default:
+				children[name] = []interface{}{existing, v}
+				repeated[name] = true
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if !hasChildren {
+				return strings.TrimSpace(text.String()), nil
+			}
+			if item, ok := children["item"]; ok && len(children) == 1 {
+				if repeated["item"] {
+					return item, nil
+				}
+				return []interface{}{item}, nil
+			}
+			return children, nil
+		}
+	}
+}
+
+// --- CSV ---
+
+// csvFormulaPrefixes adalah karakter awal sel yang dijalankan sebagai formula oleh
+// aplikasi spreadsheet (Excel, LibreOffice, Google Sheets).
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// EscapeCSVCell menambahkan awalan ' pada teks yang akan dibaca sebagai formula oleh
+// aplikasi spreadsheet sehingga teks itu ditampilkan apa adanya. Teks yang sudah
+// diawali ' sebelum karakter formula juga diberi awalan agar UnescapeCSVCell selalu
+// mengembalikan teks aslinya.
+//
+// Parameters:
+//   - s: isi sel
+//
+// Returns:
+//   - isi sel yang aman dibuka di aplikasi spreadsheet
+func EscapeCSVCell(s string) string {
+	if csvNeedsQuote(s) {
+		return "'" + s
+	}
+	return s
+}
+
+// UnescapeCSVCell membuang awalan ' yang ditambahkan EscapeCSVCell.
+//
+// Parameters:
+//   - s: isi sel dari file CSV
+//
+// Returns:
+//   - teks asli sebelum EscapeCSVCell
+func UnescapeCSVCell(s string) string {
+	if strings.HasPrefix(s, "'") && csvNeedsQuote(s[1:]) {
+		return s[1:]
+	}
+	return s
+}
+
+// csvNeedsQuote melaporkan apakah s (setelah awalan ' yang ada) diawali karakter formula.
+func csvNeedsQuote(s string) bool {
+	s = strings.TrimLeft(s, "'")
+	return s != "" && strings.IndexByte(csvFormulaPrefixes, s[0]) >= 0
+}
+
+// encodeCSV menulis isi "data" sebagai tabel: satu baris per buku (atau satu baris untuk
+// objek tunggal) dengan header dari nama field. Objek bertingkat diratakan dengan nama
+// bertitik (author.name), array ditulis sebagai JSON, dan teks yang terlihat seperti
+// formula diberi awalan ' (lihat EscapeCSVCell). Field "meta" tidak ikut ditulis;
+// response error ditulis sebagai satu baris berisi kolom error dan details.
+func encodeCSV(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	records := csvRecords(tree)
+	if len(records) == 0 {
+		return nil
+	}
+
+	var header []string
+	columns := make(map[string]bool)
+	rows := make([]map[string]string, len(records))
+	for i, record := range records {
+		rows[i] = make(map[string]string)
+		flattenCSV("", record, rows[i], func(column string) {
+			if !columns[column] {
+				columns[column] = true
+				header = append(header, column)
+			}
+		})
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	for _, row := range rows {
+		line := make([]string, len(header))
+		for i, column := range header {
+			line[i] = row[column]
+		}
+		cw.Write(line)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvRecords mengembalikan nilai yang ditulis sebagai baris CSV.
+func csvRecords(tree interface{}) []interface{} {
+	obj, ok := tree.(object)
+	if !ok {
+		return []interface{}{tree}
+	}
+	rest := object{}
+	for _, m := range obj {
+		switch m.key {
+		case "data":
+			if items, ok := m.value.([]interface{}); ok {
+				return items
+			}
+			return []interface{}{m.value}
+		case "meta":
+		default:
+			rest = append(rest, m)
+		}
+	}
+	return []interface{}{rest}
+}
+
+func flattenCSV(prefix string, v interface{}, row map[string]string, addColumn func(string)) {
+	if obj, ok := v.(object); ok {
+		for _, m := range obj {
+			flattenCSV(prefix+m.key+".", m.value, row, addColumn)
+		}
+		return
+	}
+	column := strings.TrimSuffix(prefix, ".")
+	if column == "" {
+		column = "value"
+	}
+	addColumn(column)
+	if arr, ok := v.([]interface{}); ok {
+		buf, _ := json.Marshal(arr)
+		row[column] = string(buf)
+		return
+	}
+	if s, ok := v.(string); ok {
+		row[column] = EscapeCSVCell(s)
+		return
+	}
+	row[column] = scalarText(v)
+}
+
+// decodeCSV membaca body berisi baris header dan tepat satu baris data. Nama kolom
+// bertitik (author.name) menjadi objek bertingkat; sel kosong dianggap field tidak diisi
+// dan awalan ' dari EscapeCSVCell dibuang.
+func decodeCSV(body []byte, target reflect.Type) ([]byte, error) {
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != 2 {
+		return nil, errors.New("body must contain a header row and exactly one record")
+	}
+
+	doc := make(map[string]interface{})
+	for i, column := range records[0] {
+		if records[1][i] == "" {
+			continue
+		}
+		path := strings.Split(column, ".")
+		parent := doc
+		for _, key := range path[:len(path)-1] {
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				if _, exists := parent[key]; exists {
+					return nil, fmt.Errorf("column %q conflicts with column %q", column, key)
+				}
+				child = make(map[string]interface{})
+				parent[key] = child
+			}
+			parent = child
+		}
+		parent[path[len(path)-1]] = UnescapeCSVCell(records[1][i])
+	}
+	return json.Marshal(coerce(doc, target))
+}
+
+// --- YAML ---
+
+// encodeYAML menulis v sebagai dokumen YAML dengan urutan field yang sama seperti JSON.
+func encodeYAML(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(tree)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func yamlNode(v interface{}) *yaml.Node {
+	switch v := v.(type) {
+	case object:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, m := range v {
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: m.key}, yamlNode(m.value))
+		}
+		return n
+	case []interface{}:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			n.Content = append(n.Content, yamlNode(item))
+		}
+		return n
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: scalarText(v)}
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: scalarText(v)}
+}
+
+// decodeYAML membaca dokumen YAML. Semua skalar dibaca sebagai teks lalu diubah
+// menurut tipe tujuan, sehingga judul seperti 1984 tetap menjadi string. Alias YAML
+// ditolak agar dokumen kecil tidak bisa mengembang menjadi besar.
+func decodeYAML(body []byte, target reflect.Type) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, errors.New("document is empty")
+	}
+	v, err := yamlValue(doc.Content[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(coerce(v, target))
+}
+
+func yamlValue(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := yamlValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		items := make([]interface{}, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := yamlValue(c)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!null" {
+			return nil, nil
+		}
+		return n.Value, nil
+	}
+	return nil, fmt.Errorf("line %d: aliases are not supported", n.Line)
+}
+
+// --- MessagePack ---
+
+// encodeMsgPack menulis v sebagai MessagePack dengan struktur yang sama seperti JSON.
+func encodeMsgPack(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	return writeMsgPack(msgpack.NewEncoder(w), tree)
+}
+
+func writeMsgPack(enc *msgpack.Encoder, v interface{}) error {
+	switch v := v.(type) {
+	case object:
+		if err := enc.EncodeMapLen(len(v)); err != nil {
+			return err
+		}
+		for _, m := range v {
+			if err := enc.EncodeString(m.key); err != nil {
+				return err
+			}
+			if err := writeMsgPack(enc, m.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if err := enc.EncodeArrayLen(len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := writeMsgPack(enc, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return enc.EncodeInt(i)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return enc.EncodeFloat64(f)
+	}
+	return enc.Encode(v)
+}
+
+// decodeMsgPack membaca tepat satu nilai MessagePack.
+func decodeMsgPack(body []byte, _ reflect.Type) ([]byte, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(body))
+	v, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.DecodeInterface(); err != io.EOF {
+		return nil, errors.New("unexpected data after the first value")
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,172 @@
+package utils_test
+
+import (
+	"book-api/utils"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestWriteJSON_Formats(t *testing.T) {
+	type author struct {
+		Name string `json:"name"`
+	}
+	data := []struct {
+		Title  string  `json:"title"`
+		Author author  `json:"author"`
+		ISBN   *string `json:"isbn"`
+	}{{Title: "1984", Author: author{"Orwell"}}}
+
+	tests := []struct {
+		accept, contentType, want string
+	}{
+		{"application/xml", "application/xml", `<response><data><item><title>1984</title><author><name>Orwell</name></author><isbn></isbn></item></data><meta><total>1</total></meta></response>`},
+		{"text/csv", "text/csv; charset=utf-8", "title,author.name,isbn\n1984,Orwell,\n"},
+		{"application/yaml", "application/yaml", "data:\n  - title: \"1984\"\n    author:\n      name: Orwell\n    isbn: null\nmeta:\n  total: 1\n"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		rr := httptest.NewRecorder()
+		utils.WriteJSONWithMeta(rr, req, http.StatusOK, data, map[string]int{"total": 1})
+
+		if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("%s: expected Content-Type %q, got %q", tt.accept, tt.contentType, got)
+		}
+		if got := rr.Header().Get("Vary"); got != "Accept" {
+			t.Errorf("%s: expected Vary Accept, got %q", tt.accept, got)
+		}
+		if !strings.Contains(rr.Body.String(), tt.want) {
+			t.Errorf("%s: expected body to contain\n%s\ngot\n%s", tt.accept, tt.want, rr.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", utils.MediaTypeMsgPack)
+	rr := httptest.NewRecorder()
+	utils.WriteJSON(rr, req, http.StatusOK, data)
+	var resp struct {
+		Data []map[string]interface{} `msgpack:"data"`
+	}
+	if err := msgpack.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode msgpack: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0]["title"] != "1984" {
+		t.Errorf("unexpected msgpack response: %+v", resp)
+	}
+}
+
+func TestCSVCellEscaping(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"1984", "1984"},
+		{"", ""},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tx", "'\tx"},
+		{"'quoted", "'quoted"},
+		{"'=x", "''=x"},
+	}
+	for _, tt := range tests {
+		got := utils.EscapeCSVCell(tt.in)
+		if got != tt.want {
+			t.Errorf("EscapeCSVCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if back := utils.UnescapeCSVCell(got); back != tt.in {
+			t.Errorf("UnescapeCSVCell(%q) = %q, want %q", got, back, tt.in)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/csv")
+	rr := httptest.NewRecorder()
+	utils.WriteJSON(rr, req, http.StatusOK, map[string]interface{}{"title": "=1+1", "year": -5})
+	if want := "title,year\n'=1+1,-5\n"; rr.Body.String() != want {
+		t.Errorf("expected CSV %q, got %q", want, rr.Body.String())
+	}
+
+	var dst struct {
+		Title string `json:"title"`
+		Year  int    `json:"year"`
+	}
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(rr.Body.String()))
+	req.Header.Set("Content-Type", "text/csv")
+	if err := utils.DecodeBody(httptest.NewRecorder(), req, &dst, 0); err != nil || dst.Title != "=1+1" || dst.Year != -5 {
+		t.Errorf("expected round trip to restore the title, got %+v (%v)", dst, err)
+	}
+}
+
+func TestWriteError_NegotiatedFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+	rr := httptest.NewRecorder()
+	utils.WriteError(rr, req, http.StatusNotFound, "book not found")
+
+	if rr.Code != http.StatusNotFound || rr.Header().Get("Content-Type") != utils.MediaTypeXML ||
+		!strings.Contains(rr.Body.String(), "<response><error>book not found</error></response>") {
+		t.Errorf("unexpected XML error: %d %v %s", rr.Code, rr.Header(), rr.Body.String())
+	}
+}
+
+func TestDecodeBody_Formats(t *testing.T) {
+	type book struct {
+		Title         string `json:"title"`
+		PublishedYear int    `json:"published_year"`
+		Author        struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		ISBN *string `json:"isbn"`
+	}
+	msgpackBody, _ := msgpack.Marshal(map[string]interface{}{"title": "1984", "published_year": 1949, "author": map[string]string{"name": "Orwell"}})
+
+	tests := []struct {
+		contentType, body string
+	}{
+		{"application/xml", `<book><title>1984</title><published_year>1949</published_year><author><name>Orwell</name></author></book>`},
+		{"text/csv; charset=utf-8", "title,published_year,author.name,isbn\n1984,1949,Orwell,\n"},
+		{"application/yaml", "title: 1984\npublished_year: 1949\nauthor:\n  name: Orwell\nisbn: null\n"},
+		{"application/msgpack", string(msgpackBody)},
+	}
+	for _, tt := range tests {
+		var dst book
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		if err := utils.DecodeBody(httptest.NewRecorder(), req, &dst, 0); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.contentType, err)
+			continue
+		}
+		if dst.Title != "1984" || dst.PublishedYear != 1949 || dst.Author.Name != "Orwell" || dst.ISBN != nil {
+			t.Errorf("%s: unexpected result: %+v", tt.contentType, dst)
+		}
+	}
+
+	errTests := []struct {
+		contentType, body string
+		status            int
+		code              string
+	}{
+		{"text/plain", "title: Go", http.StatusUnsupportedMediaType, utils.DecodeUnsupportedMediaType},
+		{"application/xml", `<book><title>Go</title><publisher>X</publisher></book>`, http.StatusBadRequest, utils.DecodeUnknownField},
+		{"application/xml", `<book><published_year>soon</published_year></book>`, http.StatusBadRequest, utils.DecodeTypeMismatch},
+		{"application/xml", `<book><title>Go`, http.StatusBadRequest, utils.DecodeSyntax},
+		{"text/csv", "title\nGo\nRust\n", http.StatusBadRequest, utils.DecodeSyntax},
+		{"application/yaml", "a: &x [1]\nb: *x\n", http.StatusBadRequest, utils.DecodeSyntax},
+		{"application/yaml", "", http.StatusBadRequest, utils.DecodeEmptyBody},
+	}
+	for _, tt := range errTests {
+		var dst book
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		err := utils.DecodeBody(httptest.NewRecorder(), req, &dst, 0)
+		var decErr *utils.DecodeError
+		if !errors.As(err, &decErr) || decErr.Status != tt.status || decErr.Code != tt.code {
+			t.Errorf("%s %q: expected %d %s, got %v", tt.contentType, tt.body, tt.status, tt.code, err)
+		}
+	}
+}
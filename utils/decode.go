@@ -0,0 +1,365 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// DefaultMaxBodyBytes adalah batas ukuran body request jika tidak dikonfigurasi (1 MiB).
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// Kode pada DecodeError.Code.
+const (
+	DecodeUnsupportedMediaType = "unsupported_media_type"
+	DecodeBodyTooLarge         = "body_too_large"
+	DecodeEmptyBody            = "empty_body"
+	DecodeSyntax               = "syntax_error"
+	DecodeTypeMismatch         = "type_mismatch"
+	DecodeUnknownField         = "unknown_field"
+	DecodeTrailingData         = "trailing_data"
+)
+
+// DecodeError menjelaskan kenapa body request tidak bisa di-decode, termasuk field
+// dan posisi byte (offset) yang bermasalah agar client bisa menunjuk lokasinya.
+type DecodeError struct {
+	// Status adalah kode status HTTP yang sesuai (400, 413, atau 415).
+	Status int `json:"-"`
+	// Code adalah kode jenis kesalahan (lihat konstanta Decode*).
+	Code string `json:"code"`
+	// Field adalah path field yang bermasalah, jika diketahui.
+	Field string `json:"field,omitempty"`
+	// Offset adalah posisi byte pada body tempat kesalahan ditemukan.
+	Offset int64 `json:"offset"`
+	// Message adalah penjelasan yang bisa dibaca manusia.
+	Message string `json:"message"`
+}
+
+func (e *DecodeError) Error() string {
+	return e.Message
+}
+
+// ReadBody membaca seluruh body request dengan batas ukuran maxBytes.
+//
+// Parameters:
+//   - w: http.ResponseWriter, dipakai http.MaxBytesReader untuk menutup koneksi jika body terlalu besar.
+//   - r: request yang body-nya dibaca.
+//   - maxBytes: batas ukuran body; 0 atau negatif berarti DefaultMaxBodyBytes.
+//
+// Returns:
+//   - isi body
+//   - *DecodeError dengan status 413 jika body melebihi batas, atau 400 jika gagal dibaca
+func ReadBody(w http.ResponseWriter, r *http.Request, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, &DecodeError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Code:    DecodeBodyTooLarge,
+				Offset:  tooLarge.Limit,
+				Message: fmt.Sprintf("request body must not be larger than %d bytes", tooLarge.Limit),
+			}
+		}
+		return nil, &DecodeError{Status: http.StatusBadRequest, Code: DecodeSyntax, Message: "failed to read request body"}
+	}
+	return body, nil
+}
+
+// DecodeJSON membaca body request JSON ke dst secara ketat: Content-Type harus
+// application/json, ukuran body dibatasi maxBytes, field yang tidak dikenal dan data
+// setelah nilai JSON pertama ditolak.
+//
+// Parameters:
+//   - w: http.ResponseWriter untuk request yang sedang diproses.
+//   - r: request yang body-nya di-decode.
+//   - dst: pointer tujuan decode.
+//   - maxBytes: batas ukuran body; 0 atau negatif berarti DefaultMaxBodyBytes.
+//
+// Returns:
+//   - nil jika berhasil
+//   - *DecodeError yang bisa dikirim ke client dengan WriteDecodeError
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return &DecodeError{
+			Status:  http.StatusUnsupportedMediaType,
+			Code:    DecodeUnsupportedMediaType,
+			Message: "Content-Type must be application/json",
+		}
+	}
+
+	body, err := ReadBody(w, r, maxBytes)
+	if err != nil {
+		return err
+	}
+	return DecodeStrict(body, dst)
+}
+
+// DecodeBody sama seperti DecodeJSON, tetapi menerima body dalam semua format terdaftar
+// yang bisa di-decode (JSON, XML, CSV, YAML, MessagePack) sesuai Content-Type. Body
+// diubah dulu menjadi dokumen JSON sehingga aturan decode yang ketat tetap berlaku.
+//
+// Parameters:
+//   - w: http.ResponseWriter untuk request yang sedang diproses.
+//   - r: request yang body-nya di-decode.
+//   - dst: pointer tujuan decode.
+//   - maxBytes: batas ukuran body; 0 atau negatif berarti DefaultMaxBodyBytes.
+//
+// Returns:
+//   - nil jika berhasil
+//   - *DecodeError yang bisa dikirim ke client dengan WriteDecodeError; offset hanya
+//     diisi untuk body JSON
+func DecodeBody(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	f, ok := LookupFormat(mediaType)
+	if !ok || f.Decode == nil {
+		return &DecodeError{
+			Status:  http.StatusUnsupportedMediaType,
+			Code:    DecodeUnsupportedMediaType,
+			Message: "Content-Type must be one of " + supportedMediaTypes(true),
+		}
+	}
+
+	body, err := ReadBody(w, r, maxBytes)
+	if err != nil {
+		return err
+	}
+	if f.MediaType == MediaTypeJSON || len(bytes.TrimSpace(body)) == 0 {
+		return DecodeStrict(body, dst)
+	}
+
+	doc, err := f.Decode(body, reflect.TypeOf(dst))
+	if err != nil {
+		return &DecodeError{Status: http.StatusBadRequest, Code: DecodeSyntax, Message: "invalid " + mediaType + " body: " + err.Error()}
+	}
+	if err := DecodeStrict(doc, dst); err != nil {
+		var de *DecodeError
+		if errors.As(err, &de) {
+			// Offset menunjuk dokumen JSON hasil konversi, bukan body asli.
+			de.Offset = 0
+		}
+		return err
+	}
+	return nil
+}
+
+// DecodeStrict men-decode tepat satu nilai JSON dari body ke dst dengan aturan yang
+// sama seperti DecodeJSON, tanpa pengecekan Content-Type dan ukuran.
+//
+// Parameters:
+//   - body: dokumen JSON
+//   - dst: pointer tujuan decode
+//
+// Returns:
+//   - nil jika berhasil
+//   - *DecodeError berisi field dan offset jika gagal
+func DecodeStrict(body []byte, dst interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		decErr := describeJSONError(err, dec.InputOffset())
+		if decErr.Code == DecodeUnknownField {
+			if offset, ok := unknownFieldOffset(body, reflect.TypeOf(dst)); ok {
+				decErr.Offset = offset
+			}
+		}
+		return decErr
+	}
+
+	end := dec.InputOffset()
+	rest := bytes.TrimLeft(body[end:], " \t\r\n")
+	if len(rest) > 0 {
+		offset := int64(len(body) - len(rest))
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Code:    DecodeTrailingData,
+			Offset:  offset,
+			Message: fmt.Sprintf("request body must contain a single JSON value; unexpected data at offset %d", offset),
+		}
+	}
+	return nil
+}
+
+// unknownFieldOffset mencari posisi byte awal key pertama yang tidak dikenal oleh tipe t.
+// encoding/json tidak menyertakan posisi untuk error unknown field, jadi body dipindai
+// ulang token demi token bersama tipe tujuan; key pada map selalu dianggap dikenal.
+func unknownFieldOffset(body []byte, t reflect.Type) (int64, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	offset, found, _ := scanUnknownField(dec, body, t)
+	return offset, found
+}
+
+// scanUnknownField membaca satu nilai JSON dari dec dengan tipe tujuan t (nil berarti
+// tipe bebas) dan berhenti pada key objek yang tidak ada di struct tujuannya.
+func scanUnknownField(dec *json.Decoder, body []byte, t reflect.Type) (int64, bool, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		var fields map[string]reflect.Type
+		var elem reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			fields = jsonFields(t)
+		} else if t != nil && t.Kind() == reflect.Map {
+			elem = t.Elem()
+		}
+		for dec.More() {
+			start := dec.InputOffset()
+			keyTok, err := dec.Token()
+			if err != nil {
+				return 0, false, err
+			}
+			next := elem
+			if fields != nil {
+				key, _ := keyTok.(string)
+				ft, ok := lookupField(fields, key)
+				if !ok {
+					// start bisa berada sebelum koma pemisah atau spasi; geser ke tanda kutip key.
+					return start + int64(bytes.IndexByte(body[start:], '"')), true, nil
+				}
+				next = ft
+			}
+			if offset, found, err := scanUnknownField(dec, body, next); found || err != nil {
+				return offset, found, err
+			}
+		}
+		_, err = dec.Token()
+	case json.Delim('['):
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for dec.More() {
+			if offset, found, err := scanUnknownField(dec, body, elem); found || err != nil {
+				return offset, found, err
+			}
+		}
+		_, err = dec.Token()
+	}
+	return 0, false, err
+}
+
+// jsonFields mengembalikan nama JSON setiap field struct beserta tipenya, termasuk
+// field dari struct embedded, mengikuti aturan tag encoding/json.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = ft
+	}
+	return fields
+}
+
+// lookupField mencari field seperti encoding/json: nama persis, lalu tanpa membedakan huruf besar/kecil.
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if ft, ok := fields[key]; ok {
+		return ft, true
+	}
+	for name, ft := range fields {
+		if strings.EqualFold(name, key) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
+
+// describeJSONError mengubah error encoding/json menjadi DecodeError dengan field dan offset.
+// offset adalah posisi decoder saat error terjadi, dipakai jika error tidak membawa posisinya sendiri.
+func describeJSONError(err error, offset int64) *DecodeError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &DecodeError{Status: http.StatusBadRequest, Code: DecodeEmptyBody, Message: "request body must not be empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Code:    DecodeSyntax,
+			Offset:  offset,
+			Message: fmt.Sprintf("request body contains incomplete JSON at offset %d", offset),
+		}
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Code:    DecodeSyntax,
+			Offset:  syntaxErr.Offset,
+			Message: fmt.Sprintf("request body contains malformed JSON at offset %d: %v", syntaxErr.Offset, syntaxErr),
+		}
+	case errors.As(err, &typeErr):
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Code:    DecodeTypeMismatch,
+			Field:   typeErr.Field,
+			Offset:  typeErr.Offset,
+			Message: fmt.Sprintf("field %q must be of type %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value),
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Code:    DecodeUnknownField,
+			Field:   field,
+			Offset:  offset,
+			Message: fmt.Sprintf("unknown field %q", field),
+		}
+	}
+	return &DecodeError{Status: http.StatusBadRequest, Code: DecodeSyntax, Offset: offset, Message: err.Error()}
+}
+
+// WriteDecodeError mengirim DecodeError ke client dengan status yang sesuai dan
+// rincian (field, offset) di "details". Error lain dikirim sebagai 400.
+//
+// Parameters:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: request yang sedang diproses.
+//   - err: error dari DecodeJSON atau ReadBody.
+func WriteDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		WriteError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	WriteErrorWithDetails(w, r, decErr.Status, decErr.Message, []*DecodeError{decErr})
+}
@@ -0,0 +1,47 @@
+package utils_test
+
+import (
+	"book-api/utils"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON_UnknownFieldOffset(t *testing.T) {
+	var dst struct {
+		Note  string            `json:"note"`
+		Extra map[string]string `json:"extra"`
+	}
+	// Nilai "secret" di dalam note dan extra tidak boleh dianggap sebagai key yang salah.
+	body := `{"note":"secret","extra":{"secret":"x"}, "secret":1}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	err := utils.DecodeJSON(httptest.NewRecorder(), req, &dst, 0)
+
+	var decErr *utils.DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("expected *DecodeError, got %v", err)
+	}
+	want := int64(strings.LastIndex(body, `"secret"`))
+	if decErr.Code != utils.DecodeUnknownField || decErr.Field != "secret" || decErr.Offset != want {
+		t.Errorf("expected unknown_field secret@%d, got %s %q@%d", want, decErr.Code, decErr.Field, decErr.Offset)
+	}
+}
+
+func TestDecodeJSON_Success(t *testing.T) {
+	var dst struct {
+		Title string `json:"title"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{\"title\":\"Go\"}\n"))
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := utils.DecodeJSON(httptest.NewRecorder(), req, &dst, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dst.Title != "Go" {
+		t.Errorf("expected title Go, got %q", dst.Title)
+	}
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Media type format bawaan.
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeXML     = "application/xml"
+	MediaTypeCSV     = "text/csv"
+	MediaTypeYAML    = "application/yaml"
+	MediaTypeMsgPack = "application/msgpack"
+)
+
+// Format adalah satu representasi body (media type) yang bisa dipakai untuk response
+// dan body request.
+type Format struct {
+	// MediaType adalah media type format ini, contoh "application/xml".
+	MediaType string
+	// Encode menulis v (APIResponse atau nilai lain yang bisa di-encode ke JSON) ke w.
+	// Format selain JSON memakai nama field JSON-nya.
+	Encode func(w io.Writer, v interface{}) error
+	// Decode mengubah body request menjadi dokumen JSON yang setara, sehingga aturan
+	// decode yang ketat (lihat DecodeStrict) berlaku untuk semua format. target adalah
+	// tipe tujuan decode; format tanpa tipe data (XML, CSV, YAML) memakainya untuk
+	// mengubah teks menjadi angka atau boolean. Nil jika format hanya untuk response.
+	Decode func(body []byte, target reflect.Type) ([]byte, error)
+}
+
+// ContentType mengembalikan nilai header Content-Type untuk format f.
+func (f Format) ContentType() string {
+	if strings.HasPrefix(f.MediaType, "text/") {
+		return f.MediaType + "; charset=utf-8"
+	}
+	return f.MediaType
+}
+
+var (
+	formatsMu sync.RWMutex
+	// formats berurutan menurut pendaftaran; JSON selalu yang pertama sehingga menjadi
+	// pilihan untuk Accept kosong atau */*.
+	formats = []Format{
+		{MediaType: MediaTypeJSON, Encode: encodeJSON, Decode: func(body []byte, _ reflect.Type) ([]byte, error) { return body, nil }},
+		{MediaType: MediaTypeXML, Encode: encodeXML, Decode: decodeXML},
+		{MediaType: MediaTypeCSV, Encode: encodeCSV, Decode: decodeCSV},
+		{MediaType: MediaTypeYAML, Encode: encodeYAML, Decode: decodeYAML},
+		{MediaType: MediaTypeMsgPack, Encode: encodeMsgPack, Decode: decodeMsgPack},
+	}
+)
+
+// RegisterFormat menambahkan format ke registry, atau mengganti format dengan media
+// type yang sama.
+func RegisterFormat(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for i := range formats {
+		if formats[i].MediaType == f.MediaType {
+			formats[i] = f
+			return
+		}
+	}
+	formats = append(formats, f)
+}
+
+// Formats mengembalikan semua format yang terdaftar, dimulai dari JSON.
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	return append([]Format(nil), formats...)
+}
+
+// LookupFormat mengembalikan format untuk media type (tanpa parameter seperti charset).
+func LookupFormat(mediaType string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, f := range formats {
+		if f.MediaType == mediaType {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// acceptRange adalah satu media range pada header Accept.
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept membaca header Accept. Entri yang tidak valid diabaikan; q yang tidak
+// valid dianggap 1.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType, q})
+	}
+	return ranges
+}
+
+// matchAccept mengembalikan q dan tingkat kecocokan (2 sama persis, 1 type/*, 0 */*)
+// media range paling spesifik yang cocok dengan mediaType, beserta posisinya di
+// Accept. ok bernilai false jika tidak ada yang cocok.
+func matchAccept(ranges []acceptRange, mediaType string) (q float64, specificity, index int, ok bool) {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	specificity = -1
+	for i, ar := range ranges {
+		s := -1
+		switch {
+		case ar.mediaType == mediaType,
+			// application/problem+json adalah JSON; client yang hanya meminta format error
+			// tetap menerima response sukses dalam JSON.
+			mediaType == MediaTypeJSON && ar.mediaType == ContentTypeProblemJSON:
+			s = 2
+		case ar.mediaType == typ+"/*":
+			s = 1
+		case ar.mediaType == "*/*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity, index, ok = ar.q, s, i, true
+		}
+	}
+	return q, specificity, index, ok
+}
+
+// NegotiateFormat memilih format response dari header Accept request. Format dengan q
+// tertinggi dipilih; jika sama, yang cocok lebih spesifik, lalu yang lebih dulu ditulis
+// di Accept, lalu yang lebih dulu terdaftar. Accept kosong atau request nil berarti JSON.
+//
+// Returns:
+//   - format yang dipilih
+//   - false jika tidak ada format terdaftar yang diterima client (406)
+func NegotiateFormat(r *http.Request) (Format, bool) {
+	all := Formats()
+	if r == nil || strings.TrimSpace(r.Header.Get("Accept")) == "" {
+		return all[0], true
+	}
+	ranges := parseAccept(r.Header.Get("Accept"))
+	if len(ranges) == 0 {
+		return all[0], true
+	}
+
+	best, found := Format{}, false
+	bestQ, bestSpec, bestIndex := 0.0, -1, 0
+	for _, f := range all {
+		q, spec, index, ok := matchAccept(ranges, f.MediaType)
+		if !ok || q <= 0 {
+			continue
+		}
+		if !found || q > bestQ || (q == bestQ && (spec > bestSpec || (spec == bestSpec && index < bestIndex))) {
+			best, found = f, true
+			bestQ, bestSpec, bestIndex = q, spec, index
+		}
+	}
+	return best, found
+}
+
+// responseFormat mengembalikan format response untuk r, atau JSON jika client tidak
+// menerima format apa pun. Penolakan 406 dilakukan lebih awal oleh middleware sebelum
+// handler berjalan, sehingga di sini cukup kembali ke format default.
+func responseFormat(r *http.Request) Format {
+	if f, ok := NegotiateFormat(r); ok {
+		return f
+	}
+	return Formats()[0]
+}
+
+// supportedMediaTypes mengembalikan daftar media type terdaftar dipisah koma.
+func supportedMediaTypes(decodable bool) string {
+	var types []string
+	for _, f := range Formats() {
+		if !decodable || f.Decode != nil {
+			types = append(types, f.MediaType)
+		}
+	}
+	return strings.Join(types, ", ")
+}
@@ -0,0 +1,36 @@
+package utils_test
+
+import (
+	"book-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", utils.MediaTypeJSON, true},
+		{"*/*", utils.MediaTypeJSON, true},
+		{"application/xml", utils.MediaTypeXML, true},
+		{"text/*", utils.MediaTypeCSV, true},
+		{"application/xml, application/yaml", utils.MediaTypeXML, true},
+		{"application/xml;q=0.5, application/yaml", utils.MediaTypeYAML, true},
+		{"text/html, application/msgpack;q=0.2, */*;q=0.1", utils.MediaTypeMsgPack, true},
+		{"application/problem+json", utils.MediaTypeJSON, true},
+		{"application/json;q=0, */*", utils.MediaTypeXML, true},
+		{"text/html", "", false},
+		{"application/xml;q=0", "", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		f, ok := utils.NegotiateFormat(req)
+		if ok != tt.ok || f.MediaType != tt.want {
+			t.Errorf("Accept %q: got %q %v, want %q %v", tt.accept, f.MediaType, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ContentTypeProblemJSON adalah media type untuk Problem Details (RFC 7807).
+const ContentTypeProblemJSON = "application/problem+json"
+
+// ProblemTypeBase adalah awalan URI relatif untuk field "type" pada Problem.
+// Setiap jenis error memiliki type yang stabil, misalnya "/problems/not-found".
+const ProblemTypeBase = "/problems/"
+
+// Problem adalah error response dengan format RFC 7807 (application/problem+json).
+type Problem struct {
+	// Type adalah URI yang mengidentifikasi jenis error.
+	Type string
+	// Title adalah ringkasan singkat jenis error yang sama untuk setiap kejadian.
+	Title string
+	// Status adalah kode status HTTP.
+	Status int
+	// Detail adalah penjelasan spesifik untuk kejadian error ini.
+	Detail string
+	// Instance mengidentifikasi kejadian error ini; diisi dengan request ID.
+	Instance string
+	// Extensions berisi member tambahan yang ditulis sejajar dengan member standar.
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON menulis member standar beserta Extensions dalam satu objek JSON.
+// Extension dengan nama yang sama dengan member standar diabaikan.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		switch k {
+		case "type", "title", "status", "detail", "instance":
+			continue
+		}
+		out[k] = v
+	}
+	out["type"] = p.Type
+	out["title"] = p.Title
+	out["status"] = p.Status
+	if p.Detail != "" {
+		out["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		out["instance"] = p.Instance
+	}
+	return json.Marshal(out)
+}
+
+// statusTitles melengkapi http.StatusText untuk kode non-standar.
+var statusTitles = map[int]string{
+	499: "Client Closed Request",
+}
+
+// statusTitle mengembalikan judul untuk kode status HTTP.
+func statusTitle(status int) string {
+	if t, ok := statusTitles[status]; ok {
+		return t
+	}
+	if t := http.StatusText(status); t != "" {
+		return t
+	}
+	return "Error " + strconv.Itoa(status)
+}
+
+// ProblemType mengembalikan URI type default untuk kode status, dibentuk dari
+// judul statusnya (contoh: 404 menjadi "/problems/not-found").
+func ProblemType(status int) string {
+	slug := strings.ToLower(statusTitle(status))
+	slug = strings.NewReplacer(" ", "-", "'", "").Replace(slug)
+	return ProblemTypeBase + slug
+}
+
+// NewProblem membuat Problem untuk kode status dengan type dan title default,
+// serta instance berisi request ID dari middleware chi (jika ada).
+//
+// Parameters:
+//   - r: request yang sedang diproses; boleh nil.
+//   - status: kode status HTTP.
+//   - detail: penjelasan spesifik error.
+//
+// Returns:
+//   - Problem yang siap ditulis dengan WriteProblem
+func NewProblem(r *http.Request, status int, detail string) Problem {
+	p := Problem{
+		Type:   ProblemType(status),
+		Title:  statusTitle(status),
+		Status: status,
+		Detail: detail,
+	}
+	if r != nil {
+		p.Instance = middleware.GetReqID(r.Context())
+	}
+	return p
+}
+
+// WantsProblem mengecek header Accept untuk menentukan apakah client meminta
+// application/problem+json. Problem dipilih jika media type itu diterima dengan
+// prioritas (q) yang tidak lebih rendah dari application/json.
+func WantsProblem(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	problemQ, jsonQ := -1.0, -1.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch mediaType {
+		case ContentTypeProblemJSON:
+			problemQ = max(problemQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return problemQ > 0 && problemQ >= jsonQ
+}
+
+// WriteProblem mengirimkan Problem sebagai application/problem+json.
+//
+// Parameters:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - p: Problem yang akan dikirim; p.Status dipakai sebagai kode status HTTP.
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	buf, err := json.Marshal(p)
+	if err != nil {
+		fallback := NewProblem(nil, http.StatusInternalServerError, "failed to encode error response")
+		fallback.Instance = p.Instance
+		buf, _ = json.Marshal(fallback)
+		p.Status = fallback.Status
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblemJSON)
+	w.WriteHeader(p.Status)
+	w.Write(buf)
+}
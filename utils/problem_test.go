@@ -0,0 +1,92 @@
+package utils_test
+
+import (
+	"book-api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestWantsProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/json, application/problem+json", true},
+		{"application/json, application/problem+json;q=0.5", false},
+		{"application/json;q=0.5, application/problem+json", true},
+		{"application/problem+json;q=0", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tc.accept)
+			if got := utils.WantsProblem(req); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteError_Problem(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/books/9", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "host/abc-000001"))
+	rr := httptest.NewRecorder()
+
+	utils.WriteErrorWithDetails(rr, req, http.StatusNotFound, "book not found", []string{"x"})
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != utils.ContentTypeProblemJSON {
+		t.Errorf("expected Content-Type %s, got %s", utils.ContentTypeProblemJSON, ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	want := map[string]interface{}{
+		"type":     "/problems/not-found",
+		"title":    "Not Found",
+		"status":   float64(404),
+		"detail":   "book not found",
+		"instance": "host/abc-000001",
+	}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, body[k])
+		}
+	}
+	if details, ok := body["details"].([]interface{}); !ok || len(details) != 1 {
+		t.Errorf("expected details extension, got %v", body["details"])
+	}
+}
+
+func TestProblem_MarshalJSON(t *testing.T) {
+	p := utils.NewProblem(nil, 499, "request canceled")
+	p.Extensions = map[string]interface{}{"status": "ignored", "retry": true}
+
+	buf, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("failed to marshal problem: %v", err)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(buf, &body)
+
+	if body["status"] != float64(499) || body["title"] != "Client Closed Request" || body["retry"] != true {
+		t.Errorf("unexpected problem body: %s", buf)
+	}
+	if _, ok := body["instance"]; ok {
+		t.Errorf("expected instance to be omitted without request ID, got %s", buf)
+	}
+}
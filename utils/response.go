@@ -1,51 +1,112 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 )
 
 type APIResponse struct {
 	Data  interface{} `json:"data,omitempty"`
+	Meta  interface{} `json:"meta,omitempty"`
 	Error string      `json:"error,omitempty"`
+	// Details berisi rincian error per field, misalnya daftar pelanggaran validasi.
+	Details interface{} `json:"details,omitempty"`
 }
 
-// WriteJSON mengirimkan response HTTP dalam format JSON standar.
+// WriteJSON mengirimkan response HTTP dengan envelope APIResponse. Formatnya JSON,
+// atau format lain yang terdaftar (XML, CSV, YAML, MessagePack) jika client memintanya
+// lewat header Accept (lihat NegotiateFormat).
 //
 // Parameters:
 //   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: request yang sedang diproses (untuk header Accept); boleh nil untuk JSON.
 //   - status: kode status HTTP (contoh: 200, 400, 500).
 //   - data: objek data yang akan dikirim ke field "data".
-func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+func WriteJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	WriteJSONWithMeta(w, r, status, data, nil)
+}
+
+// WriteJSONWithMeta sama seperti WriteJSON, tetapi juga mengisi field "meta"
+// (misalnya informasi paginasi).
+//
+// Parameters:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: request yang sedang diproses (untuk header Accept); boleh nil untuk JSON.
+//   - status: kode status HTTP.
+//   - data: objek data yang akan dikirim ke field "data".
+//   - meta: metadata tambahan; diabaikan jika nil.
+func WriteJSONWithMeta(w http.ResponseWriter, r *http.Request, status int, data interface{}, meta interface{}) {
+	writeEnvelope(w, r, status, APIResponse{Data: data, Meta: meta}, "failed to encode response")
+}
 
-	resp := APIResponse{Data: data}
-	buf, err := json.Marshal(resp)
-	if err != nil {
-		http.Error(w, `{"error": "failed to encode response"}`, http.StatusInternalServerError)
+// writeEnvelope meng-encode resp dengan format hasil negosiasi lalu mengirimnya.
+// Jika encode gagal, dikirim 500 berisi failure dalam JSON.
+func writeEnvelope(w http.ResponseWriter, r *http.Request, status int, resp APIResponse, failure string) {
+	f := responseFormat(r)
+	var buf bytes.Buffer
+	if err := f.Encode(&buf, resp); err != nil {
+		writeEncodeFailure(w, failure)
 		return
 	}
 
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", f.ContentType())
 	w.WriteHeader(status)
-	w.Write(buf)
+	w.Write(buf.Bytes())
 }
 
-// WriteError mengirimkan pesan error dalam format JSON standar.
+// WriteError mengirimkan pesan error. Formatnya dipilih lewat content negotiation:
+// application/problem+json (RFC 7807) jika client memintanya di header Accept,
+// selain itu envelope standar {"error": "..."} dalam format hasil NegotiateFormat.
 //
 // Parameters:
 //   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: request yang sedang diproses (untuk header Accept dan request ID); boleh nil.
 //   - status: kode status HTTP yang merepresentasikan jenis error.
-//   - message: pesan error yang akan dikirim ke client dalam field "error".
-func WriteError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
+//   - message: pesan error yang akan dikirim ke client dalam field "error" / "detail".
+func WriteError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	WriteErrorResponse(w, r, NewProblem(r, status, message))
+}
+
+// WriteErrorWithDetails sama seperti WriteError, tetapi juga mengisi field "details"
+// sehingga client dapat menandai input yang salah satu per satu.
+//
+// Parameters:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: request yang sedang diproses; boleh nil.
+//   - status: kode status HTTP yang merepresentasikan jenis error.
+//   - message: ringkasan error untuk field "error" / "detail".
+//   - details: rincian error (contoh: daftar pelanggaran per field); diabaikan jika nil.
+func WriteErrorWithDetails(w http.ResponseWriter, r *http.Request, status int, message string, details interface{}) {
+	p := NewProblem(r, status, message)
+	if details != nil {
+		p.Extensions = map[string]interface{}{"details": details}
+	}
+	WriteErrorResponse(w, r, p)
+}
 
-	resp := APIResponse{Error: message}
-	buf, err := json.Marshal(resp)
-	if err != nil {
-		http.Error(w, `{"error": "failed to encode error response"}`, http.StatusInternalServerError)
+// WriteErrorResponse mengirimkan Problem sebagai application/problem+json jika client
+// memintanya, atau sebagai envelope standar dengan "error" berisi p.Detail dan
+// "details" berisi extension "details" (jika ada) dalam format hasil NegotiateFormat.
+//
+// Parameters:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: request yang sedang diproses; boleh nil.
+//   - p: Problem yang akan dikirim.
+func WriteErrorResponse(w http.ResponseWriter, r *http.Request, p Problem) {
+	if WantsProblem(r) {
+		w.Header().Add("Vary", "Accept")
+		WriteProblem(w, p)
 		return
 	}
+	writeEnvelope(w, r, p.Status, APIResponse{Error: p.Detail, Details: p.Extensions["details"]}, "failed to encode error response")
+}
 
-	w.WriteHeader(status)
+// writeEncodeFailure mengirim 500 dengan body JSON tetap ketika response gagal di-encode.
+func writeEncodeFailure(w http.ResponseWriter, message string) {
+	buf, _ := json.Marshal(APIResponse{Error: message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
 	w.Write(buf)
 }
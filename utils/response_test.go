@@ -13,7 +13,7 @@ func TestWriteJSON_Success(t *testing.T) {
 	rr := httptest.NewRecorder()
 	data := map[string]string{"message": "success"}
 
-	utils.WriteJSON(rr, http.StatusOK, data)
+	utils.WriteJSON(rr, nil, http.StatusOK, data)
 
 	if rr.Code != http.StatusOK {
 		t.Errorf("expected status 200, got %d", rr.Code)
@@ -44,7 +44,7 @@ func TestWriteJSON_Success(t *testing.T) {
 
 func TestWriteError_Success(t *testing.T) {
 	rr := httptest.NewRecorder()
-	utils.WriteError(rr, http.StatusBadRequest, "invalid input")
+	utils.WriteError(rr, nil, http.StatusBadRequest, "invalid input")
 
 	if rr.Code != http.StatusBadRequest {
 		t.Errorf("expected status 400, got %d", rr.Code)
@@ -69,10 +69,31 @@ func TestWriteError_Success(t *testing.T) {
 	}
 }
 
+func TestWriteErrorWithDetails(t *testing.T) {
+	rr := httptest.NewRecorder()
+	details := []map[string]string{{"field": "title", "code": "required"}}
+	utils.WriteErrorWithDetails(rr, nil, http.StatusBadRequest, "invalid book", details)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rr.Code)
+	}
+
+	var resp struct {
+		Error   string              `json:"error"`
+		Details []map[string]string `json:"details"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	if resp.Error != "invalid book" || len(resp.Details) != 1 || resp.Details[0]["field"] != "title" {
+		t.Errorf("unexpected error response: %+v", resp)
+	}
+}
+
 func TestWriteJSON_EncodingError(t *testing.T) {
 	rr := httptest.NewRecorder()
 
-	utils.WriteJSON(rr, http.StatusOK, make(chan int))
+	utils.WriteJSON(rr, nil, http.StatusOK, make(chan int))
 
 	res := rr.Result()
 	defer res.Body.Close()
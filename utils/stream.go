@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// StreamFlushEvery adalah jumlah elemen yang ditulis WriteJSONStream sebelum response
+// di-flush ke client.
+const StreamFlushEvery = 100
+
+// ErrResponseCommitted menandai error yang terjadi setelah sebagian response terkirim.
+// Status dan header sudah tidak bisa diubah, sehingga pemanggil sebaiknya memutus
+// koneksi (panic(http.ErrAbortHandler)) agar client tidak menganggap response lengkap.
+var ErrResponseCommitted = errors.New("response already committed")
+
+// CommitWriter meneruskan tulisan ke W dan mencatat apakah response sudah mulai
+// dikirim; OnCommit dipanggil sekali tepat sebelum byte pertama ditulis.
+type CommitWriter struct {
+	W         io.Writer
+	Committed bool
+	OnCommit  func()
+}
+
+func (cw *CommitWriter) Write(p []byte) (int, error) {
+	if !cw.Committed {
+		cw.Committed = true
+		if cw.OnCommit != nil {
+			cw.OnCommit()
+		}
+	}
+	return cw.W.Write(p)
+}
+
+// WriteJSONStream mengirim envelope APIResponse yang field "data"-nya berupa array
+// dengan elemen yang ditulis satu per satu, sehingga daftar besar tidak perlu ditampung
+// di memori. each memanggil emit untuk setiap elemen; meta dipanggil setelah each selesai
+// dan hasilnya (jika tidak nil) dikirim sebagai "meta". Response di-flush setiap
+// StreamFlushEvery elemen. Output JSON sama persis dengan WriteJSONWithMeta.
+//
+// Hanya JSON yang di-stream; format lain hasil negosiasi (lihat NegotiateFormat)
+// ditampung dulu lalu dikirim dengan WriteJSONWithMeta.
+//
+// Parameters:
+//   - w: http.ResponseWriter untuk menulis response ke client.
+//   - r: request yang sedang diproses (untuk header Accept); boleh nil untuk JSON.
+//   - status: kode status HTTP.
+//   - each: fungsi yang memanggil emit untuk setiap elemen "data"; berhenti jika emit gagal.
+//   - meta: fungsi yang mengembalikan metadata setelah semua elemen terkirim; boleh nil.
+//
+// Returns:
+//   - nil jika response terkirim lengkap
+//   - error dari each (atau error tulis) jika belum ada byte yang terkirim; pemanggil
+//     masih bisa mengirim response error
+//   - error yang membungkus ErrResponseCommitted jika gagal setelah sebagian response terkirim
+func WriteJSONStream(w http.ResponseWriter, r *http.Request, status int, each func(emit func(v interface{}) error) error, meta func() interface{}) error {
+	metaValue := func() interface{} {
+		if meta == nil {
+			return nil
+		}
+		return meta()
+	}
+
+	if f := responseFormat(r); f.MediaType != MediaTypeJSON {
+		items := []interface{}{}
+		err := each(func(v interface{}) error {
+			items = append(items, v)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		WriteJSONWithMeta(w, r, status, items, metaValue())
+		return nil
+	}
+
+	cw := &CommitWriter{W: w, OnCommit: func() {
+		w.Header().Add("Vary", "Accept")
+		w.Header().Set("Content-Type", MediaTypeJSON)
+		w.WriteHeader(status)
+	}}
+	bw := bufio.NewWriter(cw)
+	rc := http.NewResponseController(w)
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	bw.WriteString(`{"data":[`)
+	n := 0
+	err := each(func(v interface{}) error {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			bw.WriteByte(',')
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		n++
+		if n%StreamFlushEvery == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		bw.WriteByte(']')
+		if m := metaValue(); m != nil {
+			var buf []byte
+			if buf, err = json.Marshal(m); err == nil {
+				bw.WriteString(`,"meta":`)
+				bw.Write(buf)
+			}
+		}
+	}
+	if err == nil {
+		bw.WriteByte('}')
+		err = flush()
+	}
+	if err != nil && cw.Committed {
+		return fmt.Errorf("%w: %w", ErrResponseCommitted, err)
+	}
+	return err
+}
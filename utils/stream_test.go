@@ -0,0 +1,87 @@
+package utils_test
+
+import (
+	"book-api/utils"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONStream_MatchesWriteJSON(t *testing.T) {
+	items := make([]interface{}, utils.StreamFlushEvery*2+1)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i, "title": "<Go>"}
+	}
+	meta := map[string]int{"total": len(items)}
+
+	for _, accept := range []string{"", "application/xml"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", accept)
+
+		want := httptest.NewRecorder()
+		utils.WriteJSONWithMeta(want, req, http.StatusOK, items, meta)
+
+		got := httptest.NewRecorder()
+		err := utils.WriteJSONStream(got, req, http.StatusOK, func(emit func(interface{}) error) error {
+			for _, item := range items {
+				if err := emit(item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, func() interface{} { return meta })
+		if err != nil {
+			t.Fatalf("Accept %q: unexpected error: %v", accept, err)
+		}
+
+		if got.Code != want.Code || got.Header().Get("Content-Type") != want.Header().Get("Content-Type") ||
+			got.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: got %d %v, want %d %v", accept, got.Code, got.Header(), want.Code, want.Header())
+		}
+		if got.Body.String() != want.Body.String() {
+			t.Errorf("Accept %q: body mismatch\ngot  %s\nwant %s", accept, got.Body.String(), want.Body.String())
+		}
+	}
+}
+
+func TestWriteJSONStream_Empty(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := utils.WriteJSONStream(rr, nil, http.StatusOK, func(func(interface{}) error) error { return nil }, nil)
+	if err != nil || rr.Body.String() != `{"data":[]}` {
+		t.Errorf("expected empty data array, got %v %s", err, rr.Body.String())
+	}
+}
+
+func TestWriteJSONStream_Errors(t *testing.T) {
+	errStore := errors.New("store failed")
+
+	rr := httptest.NewRecorder()
+	err := utils.WriteJSONStream(rr, nil, http.StatusOK, func(emit func(interface{}) error) error {
+		emit("first")
+		return errStore
+	}, nil)
+	if !errors.Is(err, errStore) || errors.Is(err, utils.ErrResponseCommitted) {
+		t.Errorf("error before flush: expected uncommitted store error, got %v", err)
+	}
+	if rr.Body.Len() != 0 || rr.Header().Get("Content-Type") != "" {
+		t.Errorf("error before flush: expected nothing written, got %v %q", rr.Header(), rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	err = utils.WriteJSONStream(rr, nil, http.StatusOK, func(emit func(interface{}) error) error {
+		for i := 0; i < utils.StreamFlushEvery; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return errStore
+	}, nil)
+	if !errors.Is(err, errStore) || !errors.Is(err, utils.ErrResponseCommitted) {
+		t.Errorf("error after flush: expected committed store error, got %v", err)
+	}
+	if !rr.Flushed || !strings.HasPrefix(rr.Body.String(), `{"data":[0,1,`) {
+		t.Errorf("error after flush: expected partial body to be flushed, got %s", rr.Body.String())
+	}
+}